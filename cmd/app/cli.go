@@ -3,21 +3,44 @@ package main
 import (
 	"errors"
 	"flag"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/postgresql/migrations"
+)
+
+const (
+	migrateCommand       = "migrate"
+	migrateUpCommand     = "up"
+	migrateDownCommand   = "down"
+	migrateStatusCommand = "status"
+	migrateCreateCommand = "create"
 )
 
 type cliParams struct {
-	configPath string
+	configPath    string
+	migrationsDir string
+	args          []string
 }
 
 func readCLIParams() (cliParams, error) {
 	params := cliParams{}
 
 	flag.StringVar(&params.configPath, "config", "", "Path to config file.")
+	flag.StringVar(&params.migrationsDir, "migrations-dir", migrations.SourceDir, "Directory for new migrations, used by 'migrate create'.")
 	flag.Parse()
 
-	if params.configPath == "" {
+	params.args = flag.Args()
+
+	if params.configPath == "" && !params.isMigrateCreate() {
 		return cliParams{}, errors.New("empty config path")
 	}
 
 	return params, nil
 }
+
+func (p cliParams) isMigrate() bool {
+	return len(p.args) > 0 && p.args[0] == migrateCommand
+}
+
+func (p cliParams) isMigrateCreate() bool {
+	return p.isMigrate() && len(p.args) > 1 && p.args[1] == migrateCreateCommand
+}
@@ -14,6 +14,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// handle migrate subcommands
+	if params.isMigrate() {
+		if err := runMigrate(params); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// init config
 	cfg, err := config.InitConfig(params.configPath)
 	if err != nil {
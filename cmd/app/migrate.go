@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/postgresql/migrations"
+	"github.com/ziyadovea/task_manager/users/internal/config"
+)
+
+const migrateUsage = "usage: migrate up|down|status|create <name>"
+
+// runMigrate handles 'migrate' subcommands, args[0] is always "migrate".
+func runMigrate(params cliParams) error {
+	if len(params.args) < 2 {
+		return errors.New(migrateUsage)
+	}
+
+	if params.isMigrateCreate() {
+		if len(params.args) < 3 {
+			return errors.New("empty migration name, " + migrateUsage)
+		}
+		return migrations.Create(params.migrationsDir, params.args[2])
+	}
+
+	switch params.args[1] {
+	case migrateUpCommand, migrateDownCommand, migrateStatusCommand:
+	default:
+		return fmt.Errorf("unknown migrate command '%s', %s", params.args[1], migrateUsage)
+	}
+
+	cfg, err := config.InitConfig(params.configPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlx.Connect("pgx", cfg.DBUrl)
+	if err != nil {
+		return fmt.Errorf("unable to connect to db: %w", err)
+	}
+	defer db.Close()
+
+	switch params.args[1] {
+	case migrateUpCommand:
+		return migrations.Up(db.DB)
+	case migrateDownCommand:
+		return migrations.Down(db.DB)
+	default:
+		return migrations.Status(db.DB)
+	}
+}
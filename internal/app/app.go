@@ -21,10 +21,16 @@ import (
 
 	delivery_grpc "github.com/ziyadovea/task_manager/users/internal/app/delivery/grpc"
 	"github.com/ziyadovea/task_manager/users/internal/app/delivery/grpc/interceptors"
+	"github.com/ziyadovea/task_manager/users/internal/app/delivery/rest"
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/memory"
 	"github.com/ziyadovea/task_manager/users/internal/app/repository/postgresql"
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/postgresql/migrations"
 	"github.com/ziyadovea/task_manager/users/internal/app/usecase"
 	"github.com/ziyadovea/task_manager/users/internal/authentication/jwt"
+	"github.com/ziyadovea/task_manager/users/internal/authentication/webauthn"
 	"github.com/ziyadovea/task_manager/users/internal/config"
+	"github.com/ziyadovea/task_manager/users/internal/passwords"
 	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
 )
 
@@ -39,32 +45,180 @@ func Run(cfg config.Config) {
 		log.Fatal(err)
 	}
 	defer db.Close()
+
+	// apply embedded migrations, so a fresh database works without goose CLI
+	if cfg.MigrateOnStartup {
+		if err := migrations.Up(db.DB); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	repo := postgresql.NewUserRepository(db)
+	roleRepo := postgresql.NewRoleRepository(db)
+	refreshTokenRepo := postgresql.NewRefreshTokenRepository(db)
+	revocationRepo := newRevocationRepository(cfg, db)
+	clientRepo := postgresql.NewClientRepository(db)
+	codeRepo := postgresql.NewAuthorizationCodeRepository(db)
+	identityRepo := postgresql.NewIdentityRepository(db)
+	mfaRepo := postgresql.NewMFARepository(db)
+	passkeyRepo := postgresql.NewPasskeyRepository(db)
+	resetRepo := postgresql.NewPasswordResetRepository(db)
+	loginThrottleRepo := postgresql.NewLoginThrottleRepository(db)
+	apiKeyRepo := postgresql.NewAPIKeyRepository(db)
+	serviceAccountRepo := postgresql.NewServiceAccountRepository(db)
 
 	// init JWT authenticator
+	accessKeys, err := newKeyring(cfg.AccessTokenSigning)
+	if err != nil {
+		log.Fatalf("unable to init access token keys: %v", err)
+	}
+	refreshKeys, err := newKeyring(cfg.RefreshTokenSigning)
+	if err != nil {
+		log.Fatalf("unable to init refresh token keys: %v", err)
+	}
 	auth := jwt.NewAuthenticator(
-		[]byte(os.Getenv(config.AccessTokenSecretEnvKey)),
-		[]byte(os.Getenv(config.RefreshTokenSecretEnvKey)),
+		accessKeys,
+		refreshKeys,
 		cfg.AccessTokenExpirationDuration,
 		cfg.RefreshTokenExpirationDuration,
+		jwt.ClaimsOptions{
+			Issuer:   cfg.TokenClaims.Issuer,
+			Audience: cfg.TokenClaims.Audience,
+			Leeway:   cfg.TokenClaims.Leeway,
+		},
+		revocationRepo,
 	)
+	rotator := &keyRotator{
+		cfg:         cfg,
+		accessKeys:  accessKeys,
+		refreshKeys: refreshKeys,
+	}
+
+	// init WebAuthn relying party
+	relyingParty, err := webauthn.NewRelyingParty(webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+	})
+	if err != nil {
+		log.Fatalf("unable to init webauthn relying party: %v", err)
+	}
+
+	mailer, err := newMailer(cfg.Mailer)
+	if err != nil {
+		log.Fatalf("unable to init mailer: %v", err)
+	}
+
+	hasher, err := newPasswordHasher(cfg.PasswordHashing)
+	if err != nil {
+		log.Fatalf("unable to init password hasher: %v", err)
+	}
+	var breachedPasswords usecase.BreachedPasswords
+	if cfg.PasswordPolicy.BreachedPasswordsPath != "" {
+		breachedList, err := passwords.LoadBreachedList(cfg.PasswordPolicy.BreachedPasswordsPath)
+		if err != nil {
+			log.Fatalf("unable to load breached passwords: %v", err)
+		}
+		log.Printf("loaded %d breached passwords", breachedList.Len())
+		breachedPasswords = breachedList
+	}
 
 	// init usecase layer
-	uc := usecase.NewUserUsecase(repo, auth)
+	uc := usecase.NewUserUsecase(
+		repo,
+		roleRepo,
+		refreshTokenRepo,
+		revocationRepo,
+		mfaRepo,
+		mfaRepo,
+		resetRepo,
+		auth,
+		hasher,
+		cfg.MFA.ChallengeExpirationDuration,
+	).
+		WithClaimsHooks(claimsHooks(cfg, repo)...).
+		WithMailer(mailer).
+		WithEmailVerification(usecase.EmailVerificationOptions{
+			Required:                cfg.EmailVerification.Required,
+			TokenExpirationDuration: cfg.EmailVerification.TokenExpirationDuration,
+			ResendInterval:          cfg.EmailVerification.ResendInterval,
+			LinkURL:                 cfg.EmailVerification.LinkURL,
+		}).
+		WithPasswordReset(usecase.PasswordResetOptions{
+			TokenExpirationDuration: cfg.PasswordReset.TokenExpirationDuration,
+			RequestInterval:         cfg.PasswordReset.RequestInterval,
+			LinkURL:                 cfg.PasswordReset.LinkURL,
+		}).
+		WithPasswordPolicy(entity.PasswordPolicy{
+			MinLength:        cfg.PasswordPolicy.MinLength,
+			MaxLength:        cfg.PasswordPolicy.MaxLength,
+			RequireLowercase: cfg.PasswordPolicy.RequireLowercase,
+			RequireUppercase: cfg.PasswordPolicy.RequireUppercase,
+			RequireDigit:     cfg.PasswordPolicy.RequireDigit,
+			RequireSymbol:    cfg.PasswordPolicy.RequireSymbol,
+			DisallowUserInfo: cfg.PasswordPolicy.DisallowUserInfo,
+		}, breachedPasswords).
+		WithLoginThrottling(loginThrottleRepo, usecase.LoginThrottlingOptions{
+			BaseDelay:          cfg.LoginThrottling.BaseDelay,
+			MaxDelay:           cfg.LoginThrottling.MaxDelay,
+			MaxAccountFailures: cfg.LoginThrottling.MaxAccountFailures,
+			MaxIPFailures:      cfg.LoginThrottling.MaxIPFailures,
+			LockoutDuration:    cfg.LoginThrottling.LockoutDuration,
+			FailureWindow:      cfg.LoginThrottling.FailureWindow,
+		})
+	roleUC := usecase.NewRoleUsecase(roleRepo)
+	keyUC := usecase.NewKeyUsecase(rotator)
+	clientUC := usecase.NewClientUsecase(clientRepo)
+	apiKeyUC := usecase.NewAPIKeyUsecase(apiKeyRepo, roleRepo)
+	serviceAccountUC := usecase.NewServiceAccountUsecase(serviceAccountRepo, roleRepo, revocationRepo, auth, auth)
+	mfaUC := usecase.NewMFAUsecase(mfaRepo, repo, cfg.MFA.Issuer)
+	passkeyUC := usecase.NewPasskeyUsecase(relyingParty, passkeyRepo, repo, uc, cfg.WebAuthn.SessionExpirationDuration)
+	federationUC := usecase.NewFederationUsecase(newIdentityProviders(cfg), identityRepo, repo, uc)
+	oauth2UC := usecase.NewOAuth2Usecase(
+		repo,
+		serviceAccountRepo,
+		clientRepo,
+		codeRepo,
+		refreshTokenRepo,
+		revocationRepo,
+		auth,
+		uc,
+		cfg.AuthorizationCodeExpirationDuration,
+	)
 
 	// init delivery layer
 	userGRPCService := delivery_grpc.NewUserService(uc)
+	roleGRPCService := delivery_grpc.NewRoleService(roleUC)
+	keyGRPCService := delivery_grpc.NewKeyService(keyUC)
+	clientGRPCService := delivery_grpc.NewClientService(clientUC)
+	mfaGRPCService := delivery_grpc.NewMFAService(mfaUC)
+	passkeyGRPCService := delivery_grpc.NewPasskeyService(passkeyUC)
+	apiKeyGRPCService := delivery_grpc.NewAPIKeyService(apiKeyUC)
+	serviceAccountGRPCService := delivery_grpc.NewServiceAccountService(serviceAccountUC)
+
+	// requests are limited by the client IP before authentication and by the caller after it
+	rateLimiter := interceptors.NewRateLimiter(rateLimits(cfg.RateLimiting))
 
 	// start the gRPC server
 	gRPCServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			interceptors.Logging(),
-			interceptors.Auth(auth),
+			interceptors.ClientIP(),
+			interceptors.RateLimit(rateLimiter),
+			interceptors.Auth(auth, roleUC, apiKeyUC, serviceAccountUC, delivery_grpc.MethodPermissions),
+			interceptors.PrincipalRateLimit(rateLimiter),
 		),
 		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),   // prometheus unary interceptor
 		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor), // prometheus stream interceptor
 	)
 	pb.RegisterUserServiceServer(gRPCServer, userGRPCService)
+	pb.RegisterRoleServiceServer(gRPCServer, roleGRPCService)
+	pb.RegisterKeyServiceServer(gRPCServer, keyGRPCService)
+	pb.RegisterClientServiceServer(gRPCServer, clientGRPCService)
+	pb.RegisterMFAServiceServer(gRPCServer, mfaGRPCService)
+	pb.RegisterPasskeyServiceServer(gRPCServer, passkeyGRPCService)
+	pb.RegisterAPIKeyServiceServer(gRPCServer, apiKeyGRPCService)
+	pb.RegisterServiceAccountServiceServer(gRPCServer, serviceAccountGRPCService)
 	reflection.Register(gRPCServer)
 
 	// prometheus metrics handler
@@ -76,15 +230,50 @@ func Run(cfg config.Config) {
 	}
 
 	// start the gRPC gateway server
-	gatewayMux := runtime.NewServeMux()
+	gatewayMux := runtime.NewServeMux(runtime.WithErrorHandler(delivery_grpc.GatewayErrorHandler))
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 	if err = pb.RegisterUserServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
 		log.Fatalf("failed to register gateway: %v", err)
 	}
+	if err = pb.RegisterRoleServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterKeyServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterClientServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterMFAServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterPasskeyServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterAPIKeyServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
+	if err = pb.RegisterServiceAccountServiceHandlerFromEndpoint(ctx, gatewayMux, ":"+cfg.GRPCPort, opts); err != nil {
+		log.Fatalf("failed to register gateway: %v", err)
+	}
 
 	// Convert gatewayMux to http.ServeMux
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler()) // Register the promhttp.Handler()
+	mux.Handle(rest.JWKSPath, rest.NewJWKSHandler(auth))
+	mux.Handle(rest.DiscoveryPath, rest.NewDiscoveryHandler(cfg.TokenClaims.Issuer, auth))
+	oauth2Handlers := map[string]http.Handler{
+		rest.UserInfoPath:          rest.NewUserInfoHandler(oauth2UC),
+		rest.AuthorizePath:         rest.NewAuthorizeHandler(oauth2UC, federationUC),
+		rest.FederatedLoginPath:    rest.NewFederatedLoginHandler(oauth2UC, federationUC, cfg.TokenClaims.Issuer),
+		rest.FederatedCallbackPath: rest.NewFederatedCallbackHandler(oauth2UC, federationUC, cfg.TokenClaims.Issuer),
+		rest.TokenPath:             rest.NewTokenHandler(oauth2UC),
+		rest.IntrospectionPath:     rest.NewIntrospectionHandler(oauth2UC),
+		rest.RevocationPath:        rest.NewRevocationHandler(oauth2UC),
+	}
+	for path, handler := range oauth2Handlers {
+		mux.Handle(path, rest.WithRateLimit(rateLimiter, path, handler))
+	}
 
 	// Register the gateway mux with the http.ServeMux
 	mux.Handle("/", gatewayMux)
@@ -95,6 +284,19 @@ func Run(cfg config.Config) {
 		Handler: mux,
 	}
 
+	// reload signing keys from config on SIGHUP
+	go rotateSigningKeysOnSIGHUP(ctx, rotator)
+
+	// periodically clean up deny list from tokens that are expired anyway
+	go cleanupExpired(ctx, "revoked tokens", revocationRepo.DeleteExpiredRevokedTokens)
+	go cleanupExpired(ctx, "authorization codes", codeRepo.DeleteExpiredAuthorizationCodes)
+	go cleanupExpired(ctx, "mfa challenges", mfaRepo.DeleteExpiredMFAChallenges)
+	go cleanupExpired(ctx, "webauthn sessions", passkeyRepo.DeleteExpiredWebAuthnSessions)
+	go cleanupExpired(ctx, "password reset tokens", resetRepo.DeleteExpiredPasswordResetTokens)
+	go cleanupExpired(ctx, "login throttles", func(ctx context.Context) (int64, error) {
+		return loginThrottleRepo.DeleteExpiredLoginThrottles(ctx, cfg.LoginThrottling.FailureWindow)
+	})
+
 	// start the gRPC server goroutine
 	go func() {
 		log.Printf("starting gRPC server on %s", gRPCListener.Addr())
@@ -128,3 +330,53 @@ func Run(cfg config.Config) {
 		log.Fatalf("gateway server shutdown errror: %v", err)
 	}
 }
+
+type revocationRepository interface {
+	jwt.RevocationStore
+	usecase.RevocationRepository
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+}
+
+func newRevocationRepository(cfg config.Config, db *sqlx.DB) revocationRepository {
+	if cfg.RevocationStore == config.MemoryRevocationStore {
+		return memory.NewRevocationStore()
+	}
+	return postgresql.NewRevocationRepository(db)
+}
+
+// claimsHooks returns hooks adding configured extra claims to access tokens.
+func claimsHooks(cfg config.Config, repo usecase.UserRepository) []usecase.ClaimsHook {
+	var hooks []usecase.ClaimsHook
+	for _, claim := range cfg.TokenClaims.ExtraClaims {
+		if claim == config.EmailExtraClaim {
+			hooks = append(hooks, usecase.EmailClaimsHook(repo))
+		}
+	}
+	return hooks
+}
+
+// rateLimits converts configured limits to token buckets of the rate limit interceptor.
+func rateLimits(cfg config.RateLimitingConfig) (interceptors.TokenBucket, map[string]interceptors.TokenBucket) {
+	methodLimits := make(map[string]interceptors.TokenBucket, len(cfg.Methods))
+	for method, limit := range cfg.Methods {
+		methodLimits[method] = interceptors.TokenBucket{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return interceptors.TokenBucket{Rate: cfg.Default.Rate, Burst: cfg.Default.Burst}, methodLimits
+}
+
+// cleanupExpired periodically deletes expired records with deleteExpired.
+func cleanupExpired(ctx context.Context, name string, deleteExpired func(ctx context.Context) (int64, error)) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := deleteExpired(ctx); err != nil {
+				log.Printf("unable to delete expired %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package clientip resolves the IP address of the client behind trusted proxies,
+// it's shared by gRPC and HTTP handlers, so both throttle and limit the same address.
+package clientip
+
+import (
+	"net"
+	"strings"
+)
+
+// ForwardedForHeader is set by proxies, such as the gRPC gateway, to the address of their clients.
+const ForwardedForHeader = "X-Forwarded-For"
+
+// Resolve returns the IP address of the client connected from peerAddr.
+// Proxies run next to the service, so X-Forwarded-For is trusted from loopback peers only,
+// its last entry is the client address seen by the proxy.
+func Resolve(peerAddr string, forwardedFor []string) string {
+	ip := addrIP(peerAddr)
+	if parsed := net.ParseIP(ip); parsed == nil || !parsed.IsLoopback() || len(forwardedFor) == 0 {
+		return ip
+	}
+
+	entries := strings.Split(forwardedFor[len(forwardedFor)-1], ",")
+	if forwarded := addrIP(strings.TrimSpace(entries[len(entries)-1])); forwarded != "" {
+		return forwarded
+	}
+	return ip
+}
+
+// addrIP strips the port from the address, if any.
+func addrIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
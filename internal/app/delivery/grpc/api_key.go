@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type apiKeyService struct {
+	pb.UnimplementedAPIKeyServiceServer
+	uc APIKeyUsecase
+}
+
+func NewAPIKeyService(uc APIKeyUsecase) pb.APIKeyServiceServer {
+	return apiKeyService{
+		uc: uc,
+	}
+}
+
+func (a apiKeyService) CreateAPIKey(ctx context.Context, request *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	key := uc_model.APIKey{
+		Name:   request.Name,
+		Scopes: request.Scopes,
+	}
+	if request.ExpiresAt != 0 {
+		expiresAt := time.Unix(request.ExpiresAt, 0)
+		key.ExpiresAt = &expiresAt
+	}
+
+	createdKey, rawKey, err := a.uc.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, errorStatus(err, "unable to create api key")
+	}
+
+	return &pb.CreateAPIKeyResponse{
+		ApiKey: UcAPIKey2ProtoAPIKey(createdKey),
+		Key:    rawKey,
+	}, nil
+}
+
+func (a apiKeyService) ListAPIKeys(ctx context.Context, request *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	keys, err := a.uc.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to list api keys")
+	}
+
+	response := &pb.ListAPIKeysResponse{ApiKeys: make([]*pb.APIKey, len(keys))}
+	for i, key := range keys {
+		response.ApiKeys[i] = UcAPIKey2ProtoAPIKey(key)
+	}
+	return response, nil
+}
+
+func (a apiKeyService) RevokeAPIKey(ctx context.Context, request *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	removedCount, err := a.uc.RevokeAPIKey(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(err, "unable to revoke api key")
+	}
+
+	return &pb.RevokeAPIKeyResponse{RemovedCount: removedCount}, nil
+}
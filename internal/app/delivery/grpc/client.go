@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type clientService struct {
+	pb.UnimplementedClientServiceServer
+	uc ClientUsecase
+}
+
+func NewClientService(uc ClientUsecase) pb.ClientServiceServer {
+	return clientService{
+		uc: uc,
+	}
+}
+
+func (c clientService) CreateClient(ctx context.Context, client *pb.Client) (*pb.CreateClientResponse, error) {
+	if client == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil client")
+	}
+
+	createdClient, secret, err := c.uc.CreateClient(ctx, ProtoClient2UcClient(client))
+	if err != nil {
+		return nil, errorStatus(err, "unable to create client")
+	}
+
+	return &pb.CreateClientResponse{
+		Client:       UcClient2ProtoClient(createdClient),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (c clientService) GetClient(ctx context.Context, request *pb.GetClientRequest) (*pb.Client, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	client, err := c.uc.GetClient(ctx, request.ClientId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to get client")
+	}
+
+	return UcClient2ProtoClient(client), nil
+}
+
+func (c clientService) ListClients(ctx context.Context, _ *pb.ListClientsRequest) (*pb.ListClientsResponse, error) {
+	clients, err := c.uc.ListClients(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to list clients")
+	}
+
+	resp := &pb.ListClientsResponse{Clients: make([]*pb.Client, 0, len(clients))}
+	for _, client := range clients {
+		resp.Clients = append(resp.Clients, UcClient2ProtoClient(client))
+	}
+
+	return resp, nil
+}
+
+func (c clientService) UpdateClient(ctx context.Context, client *pb.Client) (*pb.Client, error) {
+	if client == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil client")
+	}
+
+	updatedClient, err := c.uc.UpdateClient(ctx, ProtoClient2UcClient(client))
+	if err != nil {
+		return nil, errorStatus(err, "unable to update client")
+	}
+
+	return UcClient2ProtoClient(updatedClient), nil
+}
+
+func (c clientService) RotateClientSecret(ctx context.Context, request *pb.RotateClientSecretRequest) (*pb.RotateClientSecretResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	secret, err := c.uc.RotateClientSecret(ctx, request.ClientId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to rotate client secret")
+	}
+
+	return &pb.RotateClientSecretResponse{ClientSecret: secret}, nil
+}
+
+func (c clientService) RemoveClient(ctx context.Context, request *pb.RemoveClientRequest) (*pb.RemoveClientResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	removedCount, err := c.uc.RemoveClient(ctx, request.ClientId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to remove client")
+	}
+
+	return &pb.RemoveClientResponse{RemovedCount: removedCount}, nil
+}
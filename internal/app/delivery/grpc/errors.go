@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// errorDomain is the logical name of the service, returned in google.rpc.ErrorInfo.
+const errorDomain = "users.task_manager"
+
+// Reasons returned in google.rpc.ErrorInfo, stable identifiers for clients.
+const (
+	ReasonNotFound           = "NOT_FOUND"
+	ReasonAlreadyExists      = "ALREADY_EXISTS"
+	ReasonInvalidCredentials = "INVALID_CREDENTIALS"
+	ReasonValidation         = "VALIDATION_FAILED"
+	ReasonInvalidScope       = "INVALID_SCOPE"
+	ReasonPermissionDenied   = "PERMISSION_DENIED"
+	ReasonEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	ReasonTooManyRequests    = "TOO_MANY_REQUESTS"
+	ReasonInternal           = "INTERNAL"
+)
+
+var domainErrors = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{err: uc_model.ErrNotFound, code: codes.NotFound, reason: ReasonNotFound},
+	{err: uc_model.ErrAlreadyExists, code: codes.AlreadyExists, reason: ReasonAlreadyExists},
+	{err: uc_model.ErrInvalidCredentials, code: codes.Unauthenticated, reason: ReasonInvalidCredentials},
+	{err: uc_model.ErrValidation, code: codes.InvalidArgument, reason: ReasonValidation},
+	{err: uc_model.ErrInvalidScope, code: codes.InvalidArgument, reason: ReasonInvalidScope},
+	{err: uc_model.ErrPermissionDenied, code: codes.PermissionDenied, reason: ReasonPermissionDenied},
+	{err: uc_model.ErrEmailNotVerified, code: codes.FailedPrecondition, reason: ReasonEmailNotVerified},
+	{err: uc_model.ErrTooManyRequests, code: codes.ResourceExhausted, reason: ReasonTooManyRequests},
+}
+
+// errorStatus maps usecase error to gRPC status with google.rpc.ErrorInfo details,
+// google.rpc.BadRequest and google.rpc.RetryInfo are added for errors carrying them.
+// Errors unknown to the domain become codes.Internal.
+func errorStatus(err error, msg string) error {
+	code, reason := codes.Internal, ReasonInternal
+	for _, de := range domainErrors {
+		if errors.Is(err, de.err) {
+			code, reason = de.code, de.reason
+			break
+		}
+	}
+
+	st := status.New(code, fmt.Sprintf("%s: %s", msg, err))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+	}}
+	var validationErr uc_model.ValidationError
+	if errors.As(err, &validationErr) {
+		details = append(details, badRequest(validationErr))
+	}
+	var tooManyRequestsErr uc_model.TooManyRequestsError
+	if errors.As(err, &tooManyRequestsErr) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(tooManyRequestsErr.RetryAfter)})
+	}
+	stWithDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return stWithDetails.Err()
+}
+
+// badRequest returns google.rpc.BadRequest with field violations of the request.
+func badRequest(err uc_model.ValidationError) *errdetails.BadRequest {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(err.Violations))
+	for i, violation := range err.Violations {
+		violations[i] = &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		}
+	}
+	return &errdetails.BadRequest{FieldViolations: violations}
+}
@@ -3,13 +3,16 @@ package interceptors
 import (
 	"context"
 	"errors"
-	"strconv"
+	"fmt"
+	"slices"
 	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
 )
 
 const (
@@ -17,42 +20,112 @@ const (
 	BearerTokenType = "Bearer"
 )
 
-func Auth(authenticator Authenticator) grpc.UnaryServerInterceptor {
+// Auth authenticates callers of secured methods and checks their permissions.
+// Bearer tokens are either access tokens or API keys starting with entity.APIKeyPrefix.
+// methodPermissions maps full gRPC method name to the required permission,
+// methods missing in the table are public, an empty permission requires the user signed in to the service,
+// so API keys and tokens issued to clients are rejected.
+// Tokens issued to clients are limited to permissions among their scopes or implied by OpenID Connect scopes.
+func Auth(
+	authenticator Authenticator,
+	resolver PermissionResolver,
+	apiKeys APIKeyAuthenticator,
+	serviceAccounts ServiceAccountAuthenticator,
+	methodPermissions map[string]string,
+) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		if isSecuredMethod(info.FullMethod) {
-			md, ok := metadata.FromIncomingContext(ctx)
-			if !ok {
-				return nil, status.Error(codes.Unauthenticated, "no metadata with auth token")
-			}
+		permission, secured := methodPermissions[info.FullMethod]
+		if !secured {
+			return handler(ctx, req)
+		}
 
-			var authHeader string
-			headers := md.Get(AuthHeaderKey)
-			if len(headers) > 0 {
-				authHeader = headers[0]
-			}
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "no metadata with auth token")
+		}
+
+		var authHeader string
+		headers := md.Get(AuthHeaderKey)
+		if len(headers) > 0 {
+			authHeader = headers[0]
+		}
 
-			token, err := parseAuthHeader(authHeader)
-			if err != nil {
-				return nil, status.Error(codes.Unauthenticated, err.Error())
-			}
+		token, err := parseAuthHeader(authHeader)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
 
-			userID, err := authenticator.VerifyAccessToken(token)
-			if err != nil {
-				return nil, status.Error(codes.Unauthenticated, err.Error())
-			}
+		var principal entity.Principal
+		if strings.HasPrefix(token, entity.APIKeyPrefix) {
+			principal, err = apiKeys.AuthenticateAPIKey(ctx, token)
+		} else {
+			principal, err = tokenPrincipal(ctx, authenticator, resolver, serviceAccounts, token)
+		}
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "unable to authenticate: %s", err)
+		}
 
-			md.Append("user_id", strconv.FormatInt(userID, 10))
-			ctx = metadata.NewIncomingContext(ctx, md)
+		// API keys and tokens delegated to clients act only within their scopes,
+		// managing the account itself requires signing in to the service
+		if principal.APIKeyID != "" && permission == "" {
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed for api keys")
+		}
+		if principal.ClientID != "" && permission == "" {
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed for tokens issued to clients")
 		}
-		return handler(ctx, req)
+		// service accounts and clients acting on their own have no account of a user to manage
+		if principal.UserID == 0 && permission == "" {
+			return nil, status.Errorf(codes.PermissionDenied, "method is not allowed for %s principals", principal.PrincipalType)
+		}
+		if permission != "" && !principal.HasPermission(permission) {
+			return nil, status.Errorf(codes.PermissionDenied, "missing permission %s", permission)
+		}
+
+		return handler(entity.ContextWithPrincipal(ctx, principal), req)
 	}
 }
 
-func isSecuredMethod(name string) bool {
-	return strings.HasSuffix(name, "UpdateUser") ||
-		strings.HasSuffix(name, "GetUser") ||
-		strings.HasSuffix(name, "ListUsers") ||
-		strings.HasSuffix(name, "RemoveUser")
+// tokenPrincipal returns the principal the access token is issued to.
+func tokenPrincipal(
+	ctx context.Context,
+	authenticator Authenticator,
+	resolver PermissionResolver,
+	serviceAccounts ServiceAccountAuthenticator,
+	token string,
+) (entity.Principal, error) {
+	claims, err := authenticator.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to verify token: %w", err)
+	}
+	if claims.ServiceAccountID != 0 {
+		return serviceAccounts.ServiceAccountPrincipal(ctx, claims)
+	}
+
+	permissions, err := resolver.PermissionsForRoles(ctx, claims.Roles)
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to resolve permissions: %w", err)
+	}
+	// tokens issued to clients act only within the granted scopes, as API keys do,
+	// OpenID Connect scopes allow access to the profile of the user
+	if claims.ClientID != "" {
+		permissions = slices.DeleteFunc(permissions, func(permission string) bool {
+			return !entity.ScopesAllow(claims.Scopes, permission)
+		})
+	}
+
+	return entity.Principal{
+		UserID:         claims.UserID,
+		PrincipalType:  claims.PrincipalType,
+		Roles:          claims.Roles,
+		Permissions:    permissions,
+		ClientID:       claims.ClientID,
+		Scopes:         claims.Scopes,
+		TokenID:        claims.ID,
+		TokenExpiresAt: claims.ExpiresAt,
+	}, nil
 }
 
 func parseAuthHeader(header string) (string, error) {
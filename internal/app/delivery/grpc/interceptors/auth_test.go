@@ -0,0 +1,161 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	accountMethod = "/users.v1.PasskeyService/BeginPasskeyRegistration"
+	readMethod    = "/users.v1.UserService/GetUser"
+	updateMethod  = "/users.v1.UserService/UpdateUser"
+	publicMethod  = "/users.v1.UserService/Login"
+)
+
+var testMethodPermissions = map[string]string{
+	accountMethod: "",
+	readMethod:    entity.PermissionUsersRead,
+	updateMethod:  entity.PermissionUsersUpdate,
+}
+
+// fakeTokens verifies access tokens by their value, other tokens are invalid.
+type fakeTokens map[string]entity.TokenClaims
+
+func (f fakeTokens) VerifyAccessToken(_ context.Context, token string) (entity.TokenClaims, error) {
+	claims, ok := f[token]
+	if !ok {
+		return entity.TokenClaims{}, entity.ErrInvalidCredentials
+	}
+	return claims, nil
+}
+
+// fakePermissions grants users.read and users.update to the user role.
+type fakePermissions struct{}
+
+func (fakePermissions) PermissionsForRoles(_ context.Context, roles []string) ([]string, error) {
+	var permissions []string
+	for _, role := range roles {
+		if role == entity.RoleUser {
+			permissions = append(permissions, entity.PermissionUsersRead, entity.PermissionUsersUpdate)
+		}
+	}
+	return permissions, nil
+}
+
+// fakeAPIKeys authenticates any API key as the key of user 1 scoped to users.read.
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) AuthenticateAPIKey(context.Context, string) (entity.Principal, error) {
+	return entity.Principal{
+		UserID:        1,
+		PrincipalType: entity.PrincipalTypeUser,
+		Permissions:   []string{entity.PermissionUsersRead},
+		APIKeyID:      "key",
+	}, nil
+}
+
+// fakeServiceAccounts grants service accounts the permissions of their roles.
+type fakeServiceAccounts struct{}
+
+func (fakeServiceAccounts) ServiceAccountPrincipal(ctx context.Context, claims entity.TokenClaims) (entity.Principal, error) {
+	permissions, _ := fakePermissions{}.PermissionsForRoles(ctx, claims.Roles)
+	return entity.Principal{
+		PrincipalType:    entity.PrincipalTypeServiceAccount,
+		ServiceAccountID: claims.ServiceAccountID,
+		Roles:            claims.Roles,
+		Permissions:      permissions,
+	}, nil
+}
+
+func TestAuth(t *testing.T) {
+	tokens := fakeTokens{
+		"user": {UserID: 1, PrincipalType: entity.PrincipalTypeUser, Roles: []string{entity.RoleUser}},
+		"delegated": {
+			UserID:        1,
+			PrincipalType: entity.PrincipalTypeUser,
+			Roles:         []string{entity.RoleUser},
+			ClientID:      "client",
+			Scopes:        []string{entity.ScopeOpenID, entity.PermissionUsersRead},
+		},
+		"delegated-profile": {
+			UserID:        1,
+			PrincipalType: entity.PrincipalTypeUser,
+			Roles:         []string{entity.RoleUser},
+			ClientID:      "client",
+			Scopes:        []string{entity.ScopeOpenID, entity.ScopeProfile},
+		},
+		"delegated-openid": {
+			UserID:        1,
+			PrincipalType: entity.PrincipalTypeUser,
+			Roles:         []string{entity.RoleUser},
+			ClientID:      "client",
+			Scopes:        []string{entity.ScopeOpenID},
+		},
+		"client": {PrincipalType: entity.PrincipalTypeClient, ClientID: "client", Scopes: []string{entity.PermissionUsersRead}},
+		"service-account": {
+			PrincipalType:    entity.PrincipalTypeServiceAccount,
+			ServiceAccountID: 1,
+			Roles:            []string{entity.RoleUser},
+		},
+	}
+	interceptor := Auth(tokens, fakePermissions{}, fakeAPIKeys{}, fakeServiceAccounts{}, testMethodPermissions)
+
+	tests := []struct {
+		name     string
+		method   string
+		token    string
+		wantCode codes.Code
+	}{
+		{name: "public method without token", method: publicMethod, wantCode: codes.OK},
+		{name: "secured method without token", method: readMethod, wantCode: codes.Unauthenticated},
+		{name: "unknown token", method: readMethod, token: "unknown", wantCode: codes.Unauthenticated},
+
+		{name: "user manages the account", method: accountMethod, token: "user", wantCode: codes.OK},
+		{name: "user with permission", method: readMethod, token: "user", wantCode: codes.OK},
+
+		{name: "client token manages the account", method: accountMethod, token: "delegated", wantCode: codes.PermissionDenied},
+		{name: "client token with scope", method: readMethod, token: "delegated", wantCode: codes.OK},
+		{name: "client token without scope", method: updateMethod, token: "delegated", wantCode: codes.PermissionDenied},
+		{name: "client token reads the profile", method: readMethod, token: "delegated-profile", wantCode: codes.OK},
+		{name: "client token updates the profile", method: updateMethod, token: "delegated-profile", wantCode: codes.OK},
+		{name: "profile client token manages the account", method: accountMethod, token: "delegated-profile", wantCode: codes.PermissionDenied},
+		{name: "openid client token reads the profile", method: readMethod, token: "delegated-openid", wantCode: codes.PermissionDenied},
+		{name: "openid client token manages the account", method: accountMethod, token: "delegated-openid", wantCode: codes.PermissionDenied},
+
+		{name: "api key manages the account", method: accountMethod, token: entity.APIKeyPrefix + "key_secret", wantCode: codes.PermissionDenied},
+		{name: "api key with scope", method: readMethod, token: entity.APIKeyPrefix + "key_secret", wantCode: codes.OK},
+
+		{name: "client credentials token manages an account", method: accountMethod, token: "client", wantCode: codes.PermissionDenied},
+		{name: "client credentials token with scope", method: readMethod, token: "client", wantCode: codes.PermissionDenied},
+		{name: "service account manages an account", method: accountMethod, token: "service-account", wantCode: codes.PermissionDenied},
+		{name: "service account with permission", method: readMethod, token: "service-account", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(AuthHeaderKey, BearerTokenType+" "+tt.token))
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				if _, ok := entity.PrincipalFromContext(ctx); !ok && tt.method != publicMethod {
+					t.Errorf("handler of the secured method is called without principal")
+				}
+				return nil, nil
+			}
+
+			_, err := interceptor(ctx, nil, info, handler)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("got code %s, want %s: %v", got, tt.wantCode, err)
+			}
+		})
+	}
+}
@@ -1,5 +1,26 @@
 package interceptors
 
+import (
+	"context"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
 type Authenticator interface {
-	VerifyAccessToken(token string) (userID int64, err error)
+	VerifyAccessToken(ctx context.Context, token string) (claims entity.TokenClaims, err error)
+}
+
+// PermissionResolver resolves roles from the token into effective permissions.
+type PermissionResolver interface {
+	PermissionsForRoles(ctx context.Context, roles []string) ([]string, error)
+}
+
+// APIKeyAuthenticator resolves API keys into principals of their owners.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (entity.Principal, error)
+}
+
+// ServiceAccountAuthenticator resolves access tokens of service accounts into principals with their current roles.
+type ServiceAccountAuthenticator interface {
+	ServiceAccountPrincipal(ctx context.Context, claims entity.TokenClaims) (entity.Principal, error)
 }
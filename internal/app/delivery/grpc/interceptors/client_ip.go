@@ -0,0 +1,30 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/delivery/clientip"
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// ClientIP puts the IP address of the caller to the context.
+// The gateway dials the server on loopback and passes the address of its HTTP client in X-Forwarded-For,
+// it's resolved as by the HTTP handlers.
+func ClientIP() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		ip := clientip.Resolve(p.Addr.String(), md.Get(strings.ToLower(clientip.ForwardedForHeader)))
+
+		return handler(entity.ContextWithClientIP(ctx, ip), req)
+	}
+}
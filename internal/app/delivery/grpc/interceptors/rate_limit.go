@@ -0,0 +1,162 @@
+package interceptors
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// bucketSweepInterval is how often full buckets are dropped, they behave as new ones.
+const bucketSweepInterval = time.Minute
+
+// TokenBucket is a rate limit: Rate requests per second on average with bursts of up to Burst requests.
+// The zero bucket doesn't limit requests.
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+type bucketKey struct {
+	method string
+	caller string
+}
+
+// RateLimiter keeps token buckets of callers in memory, each method has its own buckets.
+type RateLimiter struct {
+	defaultLimit TokenBucket
+	methodLimits map[string]TokenBucket
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*rate.Limiter
+	lastSweep time.Time
+}
+
+// NewRateLimiter returns the limiter shared by gRPC methods and HTTP handlers,
+// methods missing in methodLimits get defaultLimit.
+func NewRateLimiter(defaultLimit TokenBucket, methodLimits map[string]TokenBucket) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit: defaultLimit,
+		methodLimits: methodLimits,
+		buckets:      make(map[bucketKey]*rate.Limiter),
+		lastSweep:    time.Now(),
+	}
+}
+
+// RateLimit limits request rates of each client IP to each method.
+// It runs before Auth, so calls are limited before their credentials are checked,
+// ClientIP interceptor must run before.
+// Rejected requests get codes.ResourceExhausted with google.rpc.RetryInfo details.
+func RateLimit(limiter *RateLimiter) grpc.UnaryServerInterceptor {
+	return rateLimit(limiter, clientIPCaller)
+}
+
+// PrincipalRateLimit limits request rates of each authenticated caller to each method:
+// the user, the service account or the OAuth2 client of client_credentials tokens,
+// so callers behind one IP don't share the limit and a caller can't escape it by changing IPs.
+// It runs after Auth, calls of public methods without credentials are limited by RateLimit only.
+func PrincipalRateLimit(limiter *RateLimiter) grpc.UnaryServerInterceptor {
+	return rateLimit(limiter, principalCaller)
+}
+
+// rateLimit limits request rates of callers told apart by caller,
+// calls it doesn't identify are not limited.
+func rateLimit(limiter *RateLimiter, caller func(ctx context.Context) (string, bool)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		key, ok := caller(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if delay := limiter.Reserve(info.FullMethod, key); delay > 0 {
+			st := status.Newf(codes.ResourceExhausted, "rate limit of %s is exceeded, retry after %s", info.FullMethod, delay.Round(time.Millisecond))
+			if stWithDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); err == nil {
+				st = stWithDetails
+			}
+			return nil, st.Err()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Reserve takes a token from the bucket of the caller to the method,
+// it returns the delay until a token is available if the bucket is empty.
+func (l *RateLimiter) Reserve(method, caller string) time.Duration {
+	return l.reserve(method, caller, time.Now())
+}
+
+// reserve takes a token from the bucket at the moment now.
+func (l *RateLimiter) reserve(method, caller string, now time.Time) time.Duration {
+	limit, ok := l.methodLimits[method]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit == (TokenBucket{}) {
+		return 0
+	}
+	key := bucketKey{method: method, caller: caller}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		for k, bucket := range l.buckets {
+			if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+		l.buckets[key] = bucket
+	}
+
+	reservation := bucket.ReserveN(now, 1)
+	if !reservation.OK() {
+		// zero burst, no request ever passes
+		return bucketSweepInterval
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// clientIPCaller identifies the caller by the client IP, calls without it share one bucket.
+func clientIPCaller(ctx context.Context) (string, bool) {
+	if ip, ok := entity.ClientIPFromContext(ctx); ok {
+		return "ip:" + ip, true
+	}
+	return "unknown", true
+}
+
+// principalCaller identifies the authenticated caller, API keys and tokens of the user share the user's bucket.
+func principalCaller(ctx context.Context) (string, bool) {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	switch {
+	case principal.UserID != 0:
+		return "user:" + strconv.FormatInt(principal.UserID, 10), true
+	case principal.ServiceAccountID != 0:
+		return "service_account:" + strconv.FormatInt(principal.ServiceAccountID, 10), true
+	case principal.ClientID != "":
+		return "client:" + principal.ClientID, true
+	}
+	return "", false
+}
@@ -0,0 +1,214 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	const (
+		limitedMethod   = "/users.v1.UserService/Login"
+		unlimitedMethod = "/users.v1.UserService/GetUser"
+		blockedMethod   = "/users.v1.UserService/Register"
+	)
+
+	type request struct {
+		// elapsed is the time of the request since the first one
+		elapsed   time.Duration
+		method    string
+		caller    string
+		wantDelay time.Duration
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "burst passes and the next request waits for a token",
+			requests: []request{
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+				{elapsed: 400 * time.Millisecond, method: limitedMethod, caller: "ip:a", wantDelay: 600 * time.Millisecond},
+			},
+		},
+		{
+			name: "bucket refills at the rate",
+			requests: []request{
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a"},
+				{elapsed: time.Second, method: limitedMethod, caller: "ip:a"},
+				{elapsed: time.Second, method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+				{elapsed: 3 * time.Second, method: limitedMethod, caller: "ip:a"},
+				{elapsed: 3 * time.Second, method: limitedMethod, caller: "ip:a"},
+				{elapsed: 3 * time.Second, method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+			},
+		},
+		{
+			name: "rejected requests take no tokens",
+			requests: []request{
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+				{method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+				{elapsed: time.Second, method: limitedMethod, caller: "ip:a"},
+			},
+		},
+		{
+			name: "callers have their own buckets",
+			requests: []request{
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a", wantDelay: time.Second},
+				{method: limitedMethod, caller: "ip:b"},
+			},
+		},
+		{
+			name: "methods have their own buckets",
+			requests: []request{
+				{method: limitedMethod, caller: "ip:a"},
+				{method: limitedMethod, caller: "ip:a"},
+				{method: "/users.v1.UserService/Logout", caller: "ip:a"},
+			},
+		},
+		{
+			name: "method with zero bucket is not limited",
+			requests: []request{
+				{method: unlimitedMethod, caller: "ip:a"},
+				{method: unlimitedMethod, caller: "ip:a"},
+				{method: unlimitedMethod, caller: "ip:a"},
+			},
+		},
+		{
+			name: "method with zero burst is always rejected",
+			requests: []request{
+				{method: blockedMethod, caller: "ip:a", wantDelay: bucketSweepInterval},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(TokenBucket{Rate: 1, Burst: 2}, map[string]TokenBucket{
+				unlimitedMethod: {},
+				blockedMethod:   {Rate: 1},
+			})
+			start := time.Now()
+
+			for i, r := range tt.requests {
+				if delay := limiter.reserve(r.method, r.caller, start.Add(r.elapsed)); delay != r.wantDelay {
+					t.Fatalf("request #%d: got delay %s, want %s", i, delay, r.wantDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	limiter := NewRateLimiter(TokenBucket{Rate: 1, Burst: 1}, nil)
+	start := time.Now()
+
+	limiter.reserve("/a", "ip:a", start)
+	limiter.reserve("/b", "ip:a", start.Add(bucketSweepInterval-100*time.Millisecond))
+	limiter.reserve("/c", "ip:a", start.Add(bucketSweepInterval))
+
+	if _, ok := limiter.buckets[bucketKey{method: "/a", caller: "ip:a"}]; ok {
+		t.Errorf("refilled bucket is kept after the sweep")
+	}
+	if _, ok := limiter.buckets[bucketKey{method: "/b", caller: "ip:a"}]; !ok {
+		t.Errorf("bucket without tokens is dropped by the sweep")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := NewRateLimiter(TokenBucket{Rate: 0.5, Burst: 1}, nil)
+	interceptor := RateLimit(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/Login"}
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		name     string
+		clientIP string
+		wantCode codes.Code
+	}{
+		{name: "first request", clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "limited request", clientIP: "192.0.2.1", wantCode: codes.ResourceExhausted},
+		{name: "request of another client ip", clientIP: "192.0.2.2", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := entity.ContextWithClientIP(context.Background(), tt.clientIP)
+
+			_, err := interceptor(ctx, nil, info, handler)
+			st := status.Convert(err)
+			if st.Code() != tt.wantCode {
+				t.Fatalf("got code %s, want %s", st.Code(), tt.wantCode)
+			}
+			if tt.wantCode == codes.OK {
+				return
+			}
+
+			var retryInfo *errdetails.RetryInfo
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.RetryInfo); ok {
+					retryInfo = info
+				}
+			}
+			if retryInfo == nil {
+				t.Fatalf("no retry info in details %v", st.Details())
+			}
+			if delay := retryInfo.GetRetryDelay().AsDuration(); delay <= time.Second || delay > 2*time.Second {
+				t.Errorf("got retry delay %s, want about 2s", delay)
+			}
+		})
+	}
+}
+
+func TestPrincipalRateLimit(t *testing.T) {
+	limiter := NewRateLimiter(TokenBucket{Rate: 0.5, Burst: 1}, nil)
+	interceptor := PrincipalRateLimit(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		name      string
+		principal *entity.Principal
+		clientIP  string
+		wantCode  codes.Code
+	}{
+		{name: "first request of the user", principal: &entity.Principal{UserID: 1}, clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "user from another client ip", principal: &entity.Principal{UserID: 1}, clientIP: "192.0.2.2", wantCode: codes.ResourceExhausted},
+		{name: "api key of the user", principal: &entity.Principal{UserID: 1, APIKeyID: "key"}, clientIP: "192.0.2.1", wantCode: codes.ResourceExhausted},
+		{name: "another user from the same client ip", principal: &entity.Principal{UserID: 2}, clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "service account with the id of the user", principal: &entity.Principal{ServiceAccountID: 1}, clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "service account again", principal: &entity.Principal{ServiceAccountID: 1}, clientIP: "192.0.2.1", wantCode: codes.ResourceExhausted},
+		{name: "client credentials token", principal: &entity.Principal{ClientID: "client"}, clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "client credentials token again", principal: &entity.Principal{ClientID: "client"}, clientIP: "192.0.2.3", wantCode: codes.ResourceExhausted},
+		{name: "unauthenticated call is left to the client ip limit", clientIP: "192.0.2.1", wantCode: codes.OK},
+		{name: "unauthenticated call again", clientIP: "192.0.2.1", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := entity.ContextWithClientIP(context.Background(), tt.clientIP)
+			if tt.principal != nil {
+				ctx = entity.ContextWithPrincipal(ctx, *tt.principal)
+			}
+
+			_, err := interceptor(ctx, nil, info, handler)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("got code %s, want %s: %v", got, tt.wantCode, err)
+			}
+		})
+	}
+}
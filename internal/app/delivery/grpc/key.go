@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type keyService struct {
+	pb.UnimplementedKeyServiceServer
+	uc KeyUsecase
+}
+
+func NewKeyService(uc KeyUsecase) pb.KeyServiceServer {
+	return keyService{
+		uc: uc,
+	}
+}
+
+func (k keyService) RotateSigningKeys(ctx context.Context, request *pb.RotateSigningKeysRequest) (*pb.RotateSigningKeysResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	activeKeyID, keyIDs, err := k.uc.RotateSigningKeys(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to rotate signing keys")
+	}
+
+	return &pb.RotateSigningKeysResponse{
+		ActiveKeyId: activeKeyID,
+		KeyIds:      keyIDs,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type mfaService struct {
+	pb.UnimplementedMFAServiceServer
+	uc MFAUsecase
+}
+
+func NewMFAService(uc MFAUsecase) pb.MFAServiceServer {
+	return mfaService{
+		uc: uc,
+	}
+}
+
+func (m mfaService) EnrollTOTP(ctx context.Context, request *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	secret, otpauthURI, err := m.uc.EnrollTOTP(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to enroll totp")
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret:     secret,
+		OtpauthUri: otpauthURI,
+	}, nil
+}
+
+func (m mfaService) ConfirmTOTP(ctx context.Context, request *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	recoveryCodes, err := m.uc.ConfirmTOTP(ctx, request.Code)
+	if err != nil {
+		return nil, errorStatus(err, "unable to confirm totp")
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (m mfaService) ResetMFA(ctx context.Context, request *pb.ResetMFARequest) (*pb.ResetMFAResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	removedCount, err := m.uc.ResetMFA(ctx, request.UserId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to reset mfa")
+	}
+
+	return &pb.ResetMFAResponse{RemovedCount: removedCount}, nil
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type passkeyService struct {
+	pb.UnimplementedPasskeyServiceServer
+	uc PasskeyUsecase
+}
+
+func NewPasskeyService(uc PasskeyUsecase) pb.PasskeyServiceServer {
+	return passkeyService{
+		uc: uc,
+	}
+}
+
+func (p passkeyService) BeginPasskeyRegistration(
+	ctx context.Context,
+	request *pb.BeginPasskeyRegistrationRequest,
+) (*pb.BeginPasskeyRegistrationResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	options, sessionID, err := p.uc.BeginPasskeyRegistration(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to begin passkey registration")
+	}
+
+	return &pb.BeginPasskeyRegistrationResponse{
+		OptionsJson: options,
+		SessionId:   sessionID,
+	}, nil
+}
+
+func (p passkeyService) FinishPasskeyRegistration(
+	ctx context.Context,
+	request *pb.FinishPasskeyRegistrationRequest,
+) (*pb.FinishPasskeyRegistrationResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	passkey, err := p.uc.FinishPasskeyRegistration(ctx, request.SessionId, request.CredentialJson, request.Name)
+	if err != nil {
+		return nil, errorStatus(err, "unable to finish passkey registration")
+	}
+
+	return &pb.FinishPasskeyRegistrationResponse{Passkey: UcPasskey2ProtoPasskey(passkey)}, nil
+}
+
+func (p passkeyService) BeginPasskeyLogin(ctx context.Context, request *pb.BeginPasskeyLoginRequest) (*pb.BeginPasskeyLoginResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	options, sessionID, err := p.uc.BeginPasskeyLogin(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to begin passkey login")
+	}
+
+	return &pb.BeginPasskeyLoginResponse{
+		OptionsJson: options,
+		SessionId:   sessionID,
+	}, nil
+}
+
+func (p passkeyService) FinishPasskeyLogin(ctx context.Context, request *pb.FinishPasskeyLoginRequest) (*pb.FinishPasskeyLoginResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	tokens, err := p.uc.FinishPasskeyLogin(ctx, request.SessionId, request.CredentialJson)
+	if err != nil {
+		return nil, errorStatus(err, "unable to finish passkey login")
+	}
+
+	return &pb.FinishPasskeyLoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (p passkeyService) ListPasskeys(ctx context.Context, request *pb.ListPasskeysRequest) (*pb.ListPasskeysResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	passkeys, err := p.uc.ListPasskeys(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to list passkeys")
+	}
+
+	protoPasskeys := make([]*pb.Passkey, len(passkeys))
+	for i, passkey := range passkeys {
+		protoPasskeys[i] = UcPasskey2ProtoPasskey(passkey)
+	}
+
+	return &pb.ListPasskeysResponse{Passkeys: protoPasskeys}, nil
+}
+
+func (p passkeyService) RemovePasskey(ctx context.Context, request *pb.RemovePasskeyRequest) (*pb.RemovePasskeyResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	removedCount, err := p.uc.RemovePasskey(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(err, "unable to remove passkey")
+	}
+
+	return &pb.RemovePasskeyResponse{RemovedCount: removedCount}, nil
+}
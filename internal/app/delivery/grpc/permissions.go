@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+// MethodPermissions is a permission required to call each secured RPC,
+// RPCs missing in the table are public, an empty permission requires the user signed in to the service,
+// it's not granted to API keys and tokens issued to clients.
+var MethodPermissions = map[string]string{
+	pb.UserService_Logout_FullMethodName:            "",
+	pb.UserService_LogoutAllSessions_FullMethodName: "",
+	pb.UserService_ChangePassword_FullMethodName:    "",
+
+	pb.UserService_UpdateUser_FullMethodName: uc_model.PermissionUsersUpdate,
+	pb.UserService_RemoveUser_FullMethodName: uc_model.PermissionUsersDelete,
+	pb.UserService_UnlockUser_FullMethodName: uc_model.PermissionUsersUnlock,
+	pb.UserService_GetUser_FullMethodName:    uc_model.PermissionUsersRead,
+	pb.UserService_ListUsers_FullMethodName:  uc_model.PermissionUsersList,
+
+	pb.RoleService_CreateRole_FullMethodName:          uc_model.PermissionRolesManage,
+	pb.RoleService_GrantRole_FullMethodName:           uc_model.PermissionRolesManage,
+	pb.RoleService_RevokeRole_FullMethodName:          uc_model.PermissionRolesManage,
+	pb.RoleService_ListUserPermissions_FullMethodName: uc_model.PermissionRolesManage,
+
+	pb.KeyService_RotateSigningKeys_FullMethodName: uc_model.PermissionKeysManage,
+
+	pb.MFAService_EnrollTOTP_FullMethodName:  "",
+	pb.MFAService_ConfirmTOTP_FullMethodName: "",
+	pb.MFAService_ResetMFA_FullMethodName:    uc_model.PermissionMFAReset,
+
+	pb.PasskeyService_BeginPasskeyRegistration_FullMethodName:  "",
+	pb.PasskeyService_FinishPasskeyRegistration_FullMethodName: "",
+	pb.PasskeyService_ListPasskeys_FullMethodName:              "",
+	pb.PasskeyService_RemovePasskey_FullMethodName:             "",
+
+	pb.APIKeyService_CreateAPIKey_FullMethodName: "",
+	pb.APIKeyService_ListAPIKeys_FullMethodName:  "",
+	pb.APIKeyService_RevokeAPIKey_FullMethodName: "",
+
+	pb.ClientService_CreateClient_FullMethodName:       uc_model.PermissionClientsManage,
+	pb.ClientService_GetClient_FullMethodName:          uc_model.PermissionClientsManage,
+	pb.ClientService_ListClients_FullMethodName:        uc_model.PermissionClientsManage,
+	pb.ClientService_UpdateClient_FullMethodName:       uc_model.PermissionClientsManage,
+	pb.ClientService_RotateClientSecret_FullMethodName: uc_model.PermissionClientsManage,
+	pb.ClientService_RemoveClient_FullMethodName:       uc_model.PermissionClientsManage,
+
+	pb.ServiceAccountService_CreateServiceAccount_FullMethodName:       uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_GetServiceAccount_FullMethodName:          uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_ListServiceAccounts_FullMethodName:        uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_UpdateServiceAccount_FullMethodName:       uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_RotateServiceAccountSecret_FullMethodName: uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_RemoveServiceAccount_FullMethodName:       uc_model.PermissionServiceAccountsManage,
+	pb.ServiceAccountService_GrantServiceAccountRole_FullMethodName:    uc_model.PermissionRolesManage,
+	pb.ServiceAccountService_RevokeServiceAccountRole_FullMethodName:   uc_model.PermissionRolesManage,
+}
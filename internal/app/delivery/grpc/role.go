@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type roleService struct {
+	pb.UnimplementedRoleServiceServer
+	uc RoleUsecase
+}
+
+func NewRoleService(uc RoleUsecase) pb.RoleServiceServer {
+	return roleService{
+		uc: uc,
+	}
+}
+
+func (r roleService) CreateRole(ctx context.Context, role *pb.Role) (*pb.Role, error) {
+	if role == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil role")
+	}
+
+	createdRole, err := r.uc.CreateRole(ctx, ProtoRole2UcRole(role))
+	if err != nil {
+		return nil, errorStatus(err, "unable to create role")
+	}
+
+	return UcRole2ProtoRole(createdRole), nil
+}
+
+func (r roleService) GrantRole(ctx context.Context, request *pb.GrantRoleRequest) (*pb.GrantRoleResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := r.uc.GrantRole(ctx, request.UserId, request.Role); err != nil {
+		return nil, errorStatus(err, "unable to grant role")
+	}
+
+	return &pb.GrantRoleResponse{}, nil
+}
+
+func (r roleService) RevokeRole(ctx context.Context, request *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	revokedCount, err := r.uc.RevokeRole(ctx, request.UserId, request.Role)
+	if err != nil {
+		return nil, errorStatus(err, "unable to revoke role")
+	}
+
+	return &pb.RevokeRoleResponse{RevokedCount: revokedCount}, nil
+}
+
+func (r roleService) ListUserPermissions(ctx context.Context, request *pb.ListUserPermissionsRequest) (*pb.ListUserPermissionsResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	roles, permissions, err := r.uc.ListUserPermissions(ctx, request.UserId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to list user permissions")
+	}
+
+	return &pb.ListUserPermissionsResponse{
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
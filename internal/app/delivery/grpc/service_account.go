@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
+)
+
+type serviceAccountService struct {
+	pb.UnimplementedServiceAccountServiceServer
+	uc ServiceAccountUsecase
+}
+
+func NewServiceAccountService(uc ServiceAccountUsecase) pb.ServiceAccountServiceServer {
+	return serviceAccountService{
+		uc: uc,
+	}
+}
+
+func (s serviceAccountService) CreateServiceAccount(ctx context.Context, request *pb.CreateServiceAccountRequest) (*pb.CreateServiceAccountResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	createdAccount, secret, err := s.uc.CreateServiceAccount(ctx, uc_model.ServiceAccount{
+		Name:        request.Name,
+		Description: request.Description,
+		PublicKey:   request.PublicKey,
+	})
+	if err != nil {
+		return nil, errorStatus(err, "unable to create service account")
+	}
+
+	return &pb.CreateServiceAccountResponse{
+		ServiceAccount: UcServiceAccount2ProtoServiceAccount(createdAccount),
+		ClientSecret:   secret,
+	}, nil
+}
+
+func (s serviceAccountService) GetServiceAccount(ctx context.Context, request *pb.GetServiceAccountRequest) (*pb.ServiceAccount, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	account, err := s.uc.GetServiceAccount(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(err, "unable to get service account")
+	}
+
+	return UcServiceAccount2ProtoServiceAccount(account), nil
+}
+
+func (s serviceAccountService) ListServiceAccounts(ctx context.Context, _ *pb.ListServiceAccountsRequest) (*pb.ListServiceAccountsResponse, error) {
+	accounts, err := s.uc.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, errorStatus(err, "unable to list service accounts")
+	}
+
+	response := &pb.ListServiceAccountsResponse{ServiceAccounts: make([]*pb.ServiceAccount, len(accounts))}
+	for i, account := range accounts {
+		response.ServiceAccounts[i] = UcServiceAccount2ProtoServiceAccount(account)
+	}
+	return response, nil
+}
+
+func (s serviceAccountService) UpdateServiceAccount(ctx context.Context, request *pb.UpdateServiceAccountRequest) (*pb.ServiceAccount, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	updatedAccount, err := s.uc.UpdateServiceAccount(ctx, uc_model.ServiceAccount{
+		ID:          request.Id,
+		Description: request.Description,
+		PublicKey:   request.PublicKey,
+	})
+	if err != nil {
+		return nil, errorStatus(err, "unable to update service account")
+	}
+
+	return UcServiceAccount2ProtoServiceAccount(updatedAccount), nil
+}
+
+func (s serviceAccountService) RotateServiceAccountSecret(ctx context.Context, request *pb.RotateServiceAccountSecretRequest) (*pb.RotateServiceAccountSecretResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	secret, err := s.uc.RotateServiceAccountSecret(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(err, "unable to rotate service account secret")
+	}
+
+	return &pb.RotateServiceAccountSecretResponse{ClientSecret: secret}, nil
+}
+
+func (s serviceAccountService) RemoveServiceAccount(ctx context.Context, request *pb.RemoveServiceAccountRequest) (*pb.RemoveServiceAccountResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	removedCount, err := s.uc.RemoveServiceAccount(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(err, "unable to remove service account")
+	}
+
+	return &pb.RemoveServiceAccountResponse{RemovedCount: removedCount}, nil
+}
+
+func (s serviceAccountService) GrantServiceAccountRole(ctx context.Context, request *pb.GrantServiceAccountRoleRequest) (*pb.GrantServiceAccountRoleResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := s.uc.GrantServiceAccountRole(ctx, request.Id, request.Role); err != nil {
+		return nil, errorStatus(err, "unable to grant service account role")
+	}
+
+	return &pb.GrantServiceAccountRoleResponse{}, nil
+}
+
+func (s serviceAccountService) RevokeServiceAccountRole(ctx context.Context, request *pb.RevokeServiceAccountRoleRequest) (*pb.RevokeServiceAccountRoleResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	revokedCount, err := s.uc.RevokeServiceAccountRole(ctx, request.Id, request.Role)
+	if err != nil {
+		return nil, errorStatus(err, "unable to revoke service account role")
+	}
+
+	return &pb.RevokeServiceAccountRoleResponse{RevokedCount: revokedCount}, nil
+}
+
+func (s serviceAccountService) IssueServiceAccountToken(ctx context.Context, request *pb.IssueServiceAccountTokenRequest) (*pb.IssueServiceAccountTokenResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	accessToken, claims, err := s.uc.IssueServiceAccountToken(ctx, request.Id, request.ClientSecret, request.Assertion)
+	if err != nil {
+		return nil, errorStatus(err, "unable to issue service account token")
+	}
+
+	return &pb.IssueServiceAccountTokenResponse{
+		AccessToken: accessToken,
+		ExpiresAt:   claims.ExpiresAt.Unix(),
+	}, nil
+}
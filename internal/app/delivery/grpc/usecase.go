@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"encoding/base64"
 
 	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
 	"github.com/ziyadovea/task_manager/users/proto/v1/pb"
@@ -9,15 +10,50 @@ import (
 
 type UserUsecase interface {
 	RegisterUser(ctx context.Context, user uc_model.User) (uc_model.User, error)
-	AuthenticateUser(ctx context.Context, user uc_model.User) (string, string, error)
-	RefreshUserToken(ctx context.Context, refreshToken string) (string, error)
-	ValidateUserToken(ctx context.Context, token string) (int64, error)
+	AuthenticateUser(ctx context.Context, user uc_model.User) (accessToken, refreshToken, mfaToken string, err error)
+	VerifyMFA(ctx context.Context, mfaToken, code string) (accessToken, refreshToken string, err error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	RefreshUserToken(ctx context.Context, refreshToken string) (string, string, error)
+	ValidateUserToken(ctx context.Context, token string) (uc_model.TokenClaims, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAllSessions(ctx context.Context) error
 	UpdateUser(ctx context.Context, user uc_model.User) (int64, error)
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) (uc_model.TokenSet, error)
 	RemoveUser(ctx context.Context, user uc_model.User) (int64, error)
+	UnlockUser(ctx context.Context, userID int64) (int64, error)
 	GetUser(ctx context.Context, user uc_model.User) (uc_model.User, error)
 	ListUsers(ctx context.Context) ([]uc_model.User, error)
 }
 
+type RoleUsecase interface {
+	CreateRole(ctx context.Context, role uc_model.Role) (uc_model.Role, error)
+	GrantRole(ctx context.Context, userID int64, roleName string) error
+	RevokeRole(ctx context.Context, userID int64, roleName string) (int64, error)
+	ListUserPermissions(ctx context.Context, userID int64) (roles []string, permissions []string, err error)
+}
+
+type KeyUsecase interface {
+	RotateSigningKeys(ctx context.Context) (activeKeyID string, keyIDs []string, err error)
+}
+
+type ClientUsecase interface {
+	CreateClient(ctx context.Context, client uc_model.Client) (createdClient uc_model.Client, secret string, err error)
+	GetClient(ctx context.Context, id string) (uc_model.Client, error)
+	ListClients(ctx context.Context) ([]uc_model.Client, error)
+	UpdateClient(ctx context.Context, client uc_model.Client) (uc_model.Client, error)
+	RotateClientSecret(ctx context.Context, id string) (secret string, err error)
+	RemoveClient(ctx context.Context, id string) (int64, error)
+}
+
+type MFAUsecase interface {
+	EnrollTOTP(ctx context.Context) (secret, otpauthURI string, err error)
+	ConfirmTOTP(ctx context.Context, code string) (recoveryCodes []string, err error)
+	ResetMFA(ctx context.Context, userID int64) (int64, error)
+}
+
 func ProtoUser2UcUser(u *pb.User) uc_model.User {
 	return uc_model.User{
 		ID:       u.Id,
@@ -29,8 +65,119 @@ func ProtoUser2UcUser(u *pb.User) uc_model.User {
 
 func UcUser2ProtoUserView(u uc_model.User) *pb.UserView {
 	return &pb.UserView{
-		Id:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
+		Id:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func ProtoRole2UcRole(r *pb.Role) uc_model.Role {
+	return uc_model.Role{
+		ID:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+	}
+}
+
+func UcRole2ProtoRole(r uc_model.Role) *pb.Role {
+	return &pb.Role{
+		Id:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+	}
+}
+
+func ProtoClient2UcClient(c *pb.Client) uc_model.Client {
+	return uc_model.Client{
+		ID:           c.ClientId,
+		Name:         c.Name,
+		Scopes:       c.Scopes,
+		RedirectURIs: c.RedirectUris,
+		Public:       c.Public,
+	}
+}
+
+// UcClient2ProtoClient converts client without its secret.
+func UcClient2ProtoClient(c uc_model.Client) *pb.Client {
+	return &pb.Client{
+		ClientId:     c.ID,
+		Name:         c.Name,
+		Scopes:       c.Scopes,
+		RedirectUris: c.RedirectURIs,
+		Public:       c.Public,
+	}
+}
+
+type PasskeyUsecase interface {
+	BeginPasskeyRegistration(ctx context.Context) (options, sessionID string, err error)
+	FinishPasskeyRegistration(ctx context.Context, sessionID, credential, name string) (uc_model.Passkey, error)
+	BeginPasskeyLogin(ctx context.Context) (options, sessionID string, err error)
+	FinishPasskeyLogin(ctx context.Context, sessionID, assertion string) (uc_model.TokenSet, error)
+	ListPasskeys(ctx context.Context) ([]uc_model.Passkey, error)
+	RemovePasskey(ctx context.Context, id string) (int64, error)
+}
+
+func UcPasskey2ProtoPasskey(p uc_model.Passkey) *pb.Passkey {
+	passkey := &pb.Passkey{
+		Id:         base64.RawURLEncoding.EncodeToString(p.ID),
+		Name:       p.Name,
+		Transports: p.Transports,
+		BackedUp:   p.BackupState,
+		CreatedAt:  p.CreatedAt.Unix(),
+	}
+	if p.LastUsedAt != nil {
+		passkey.LastUsedAt = p.LastUsedAt.Unix()
+	}
+	return passkey
+}
+
+type APIKeyUsecase interface {
+	CreateAPIKey(ctx context.Context, key uc_model.APIKey) (createdKey uc_model.APIKey, rawKey string, err error)
+	ListAPIKeys(ctx context.Context) ([]uc_model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (int64, error)
+}
+
+type ServiceAccountUsecase interface {
+	CreateServiceAccount(ctx context.Context, account uc_model.ServiceAccount) (createdAccount uc_model.ServiceAccount, secret string, err error)
+	GetServiceAccount(ctx context.Context, id int64) (uc_model.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]uc_model.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, account uc_model.ServiceAccount) (uc_model.ServiceAccount, error)
+	RotateServiceAccountSecret(ctx context.Context, id int64) (secret string, err error)
+	RemoveServiceAccount(ctx context.Context, id int64) (int64, error)
+	GrantServiceAccountRole(ctx context.Context, id int64, roleName string) error
+	RevokeServiceAccountRole(ctx context.Context, id int64, roleName string) (int64, error)
+	IssueServiceAccountToken(ctx context.Context, id int64, secret, assertion string) (accessToken string, claims uc_model.TokenClaims, err error)
+}
+
+// UcAPIKey2ProtoAPIKey converts API key without its hash.
+func UcAPIKey2ProtoAPIKey(k uc_model.APIKey) *pb.APIKey {
+	key := &pb.APIKey{
+		Id:        k.ID,
+		Name:      k.Name,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Unix(),
+	}
+	if k.ExpiresAt != nil {
+		key.ExpiresAt = k.ExpiresAt.Unix()
+	}
+	if k.LastUsedAt != nil {
+		key.LastUsedAt = k.LastUsedAt.Unix()
+	}
+	return key
+}
+
+// UcServiceAccount2ProtoServiceAccount converts service account without its secret hash.
+func UcServiceAccount2ProtoServiceAccount(a uc_model.ServiceAccount) *pb.ServiceAccount {
+	return &pb.ServiceAccount{
+		Id:          a.ID,
+		Name:        a.Name,
+		Description: a.Description,
+		OwnerId:     a.OwnerID,
+		PublicKey:   a.PublicKey,
+		Roles:       a.Roles,
+		CreatedAt:   a.CreatedAt.Unix(),
 	}
 }
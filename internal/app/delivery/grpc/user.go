@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	uc_model "github.com/ziyadovea/task_manager/users/internal/app/entity"
@@ -30,7 +29,7 @@ func (u userService) RegisterUser(ctx context.Context, user *pb.User) (*pb.UserV
 	ucUser := ProtoUser2UcUser(user)
 	registeredUser, err := u.uc.RegisterUser(ctx, ucUser)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to register user: %s", err)
+		return nil, errorStatus(err, "unable to register user")
 	}
 
 	return UcUser2ProtoUserView(registeredUser), nil
@@ -41,33 +40,106 @@ func (u userService) AuthenticateUser(ctx context.Context, request *pb.Authentic
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	accessToken, refreshToken, err := u.uc.AuthenticateUser(ctx, uc_model.User{
+	accessToken, refreshToken, mfaToken, err := u.uc.AuthenticateUser(ctx, uc_model.User{
 		Name:     request.Name,
 		Email:    request.Email,
 		Password: request.Password,
 	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to auth user: %s", err)
+		return nil, errorStatus(err, "unable to auth user")
 	}
 
 	return &pb.AuthenticateUserResponse{
 		Token:        accessToken,
 		RefreshToken: refreshToken,
+		MfaRequired:  mfaToken != "",
+		MfaToken:     mfaToken,
 	}, nil
 }
 
+func (u userService) VerifyMFA(ctx context.Context, request *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	accessToken, refreshToken, err := u.uc.VerifyMFA(ctx, request.MfaToken, request.Code)
+	if err != nil {
+		return nil, errorStatus(err, "unable to verify mfa")
+	}
+
+	return &pb.VerifyMFAResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (u userService) VerifyEmail(ctx context.Context, request *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.VerifyEmail(ctx, request.Token); err != nil {
+		return nil, errorStatus(err, "unable to verify email")
+	}
+
+	return &pb.VerifyEmailResponse{}, nil
+}
+
+func (u userService) ResendVerificationEmail(
+	ctx context.Context,
+	request *pb.ResendVerificationEmailRequest,
+) (*pb.ResendVerificationEmailResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.ResendVerificationEmail(ctx, request.Email); err != nil {
+		return nil, errorStatus(err, "unable to resend verification email")
+	}
+
+	return &pb.ResendVerificationEmailResponse{}, nil
+}
+
+func (u userService) RequestPasswordReset(
+	ctx context.Context,
+	request *pb.RequestPasswordResetRequest,
+) (*pb.RequestPasswordResetResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.RequestPasswordReset(ctx, request.Email); err != nil {
+		return nil, errorStatus(err, "unable to request password reset")
+	}
+
+	return &pb.RequestPasswordResetResponse{}, nil
+}
+
+func (u userService) ResetPassword(ctx context.Context, request *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.ResetPassword(ctx, request.Token, request.NewPassword); err != nil {
+		return nil, errorStatus(err, "unable to reset password")
+	}
+
+	return &pb.ResetPasswordResponse{}, nil
+}
+
 func (u userService) RefreshUserToken(ctx context.Context, request *pb.RefreshUserTokenRequest) (*pb.RefreshUserTokenResponse, error) {
 	if request == nil {
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	newAccessToken, err := u.uc.RefreshUserToken(ctx, request.RefreshToken)
+	newAccessToken, newRefreshToken, err := u.uc.RefreshUserToken(ctx, request.RefreshToken)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to refresh user token: %s", err)
+		return nil, errorStatus(err, "unable to refresh user token")
 	}
 
 	return &pb.RefreshUserTokenResponse{
-		AccessToken: newAccessToken,
+		AccessToken:  newAccessToken,
+		RefreshToken: newRefreshToken,
 	}, nil
 }
 
@@ -76,56 +148,105 @@ func (u userService) ValidateUserToken(ctx context.Context, request *pb.Validate
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	userID, err := u.uc.ValidateUserToken(ctx, request.Token)
+	claims, err := u.uc.ValidateUserToken(ctx, request.Token)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to validate user token: %s", err)
+		return nil, errorStatus(err, "unable to validate user token")
 	}
 
 	return &pb.ValidateUserTokenResponse{
-		UserId: userID,
+		UserId:           claims.UserID,
+		ServiceAccountId: claims.ServiceAccountID,
+		PrincipalType:    claims.PrincipalType,
 	}, nil
 }
 
+func (u userService) Logout(ctx context.Context, request *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.Logout(ctx, request.RefreshToken); err != nil {
+		return nil, errorStatus(err, "unable to logout")
+	}
+
+	return &pb.LogoutResponse{}, nil
+}
+
+func (u userService) LogoutAllSessions(ctx context.Context, request *pb.LogoutAllSessionsRequest) (*pb.LogoutAllSessionsResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
+
+	if err := u.uc.LogoutAllSessions(ctx); err != nil {
+		return nil, errorStatus(err, "unable to logout all sessions")
+	}
+
+	return &pb.LogoutAllSessionsResponse{}, nil
+}
+
 func (u userService) UpdateUser(ctx context.Context, user *pb.User) (*pb.UpdateUserResponse, error) {
 	if user == nil {
 		return nil, status.Error(codes.InvalidArgument, "nil user")
 	}
 
-	ctx = contextWithUserId(ctx)
-
 	updatedCount, err := u.uc.UpdateUser(ctx, ProtoUser2UcUser(user))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to update user: %s", err)
+		return nil, errorStatus(err, "unable to update user")
 	}
 
 	return &pb.UpdateUserResponse{UpdatedCount: updatedCount}, nil
 }
 
-func (u userService) RemoveUser(ctx context.Context, request *pb.RemoveUserRequest) (*pb.RemoveUserResponse, error) {
+func (u userService) ChangePassword(ctx context.Context, request *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
 	if request == nil {
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	ctx = contextWithUserId(ctx)
+	tokens, err := u.uc.ChangePassword(ctx, request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		return nil, errorStatus(err, "unable to change password")
+	}
+
+	return &pb.ChangePasswordResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (u userService) RemoveUser(ctx context.Context, request *pb.RemoveUserRequest) (*pb.RemoveUserResponse, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
 
 	removedCount, err := u.uc.RemoveUser(ctx, uc_model.User{ID: request.UserId})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to remove user: %s", err)
+		return nil, errorStatus(err, "unable to remove user")
 	}
 
 	return &pb.RemoveUserResponse{RemovedCount: removedCount}, nil
 }
 
-func (u userService) GetUser(ctx context.Context, request *pb.GetUserRequest) (*pb.UserView, error) {
+func (u userService) UnlockUser(ctx context.Context, request *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
 	if request == nil {
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	ctx = contextWithUserId(ctx)
+	unlockedCount, err := u.uc.UnlockUser(ctx, request.UserId)
+	if err != nil {
+		return nil, errorStatus(err, "unable to unlock user")
+	}
+
+	return &pb.UnlockUserResponse{UnlockedCount: unlockedCount}, nil
+}
+
+func (u userService) GetUser(ctx context.Context, request *pb.GetUserRequest) (*pb.UserView, error) {
+	if request == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil request")
+	}
 
 	deletedUser, err := u.uc.GetUser(ctx, uc_model.User{ID: request.UserId})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to get user: %s", err)
+		return nil, errorStatus(err, "unable to get user")
 	}
 
 	return UcUser2ProtoUserView(deletedUser), nil
@@ -136,11 +257,9 @@ func (u userService) ListUsers(ctx context.Context, request *pb.ListUsersRequest
 		return nil, status.Error(codes.InvalidArgument, "nil request")
 	}
 
-	ctx = contextWithUserId(ctx)
-
 	users, err := u.uc.ListUsers(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to get list users: %s", err)
+		return nil, errorStatus(err, "unable to get list users")
 	}
 
 	pbUserViews := make([]*pb.UserView, len(users))
@@ -152,15 +271,3 @@ func (u userService) ListUsers(ctx context.Context, request *pb.ListUsersRequest
 		Users: pbUserViews,
 	}, nil
 }
-
-type userIDCtxKey string
-
-func contextWithUserId(ctx context.Context) context.Context {
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		userIds := md.Get("user_id")
-		if len(userIds) > 0 {
-			ctx = context.WithValue(ctx, userIDCtxKey("user_id"), userIds[0])
-		}
-	}
-	return ctx
-}
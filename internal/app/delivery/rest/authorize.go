@@ -0,0 +1,309 @@
+package rest
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/delivery/clientip"
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const AuthorizePath = "/oauth2/authorize"
+
+const codeResponseType = "code"
+
+// Authorization error codes, RFC 6749 section 4.1.2.1.
+const (
+	errAccessDenied            = "access_denied"
+	errUnsupportedResponseType = "unsupported_response_type"
+	errTemporarilyUnavailable  = "temporarily_unavailable"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var loginTemplate = template.Must(template.ParseFS(templates, "templates/login.html"))
+
+type loginPage struct {
+	Action              string
+	ClientName          string
+	Error               string
+	Login               string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	Providers           []providerLink
+	// MFAToken is set once the password is checked and the second factor is required.
+	MFAToken string
+}
+
+type providerLink struct {
+	Name string
+	URL  string
+}
+
+// NewAuthorizeHandler serves the authorization endpoint of the authorization code flow with PKCE:
+// GET renders the login page, POST checks credentials and redirects back to the client with the code.
+// The login page also links to sign-in with upstream identity providers.
+func NewAuthorizeHandler(uc OAuth2Usecase, federation FederationUsecase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+
+		request := authorizationRequestFromForm(r.Form)
+		client, redirectURI, ok := resolveAuthorization(w, r, uc, request, r.Form.Get("response_type"))
+		if !ok {
+			return
+		}
+
+		page := newLoginPage(client, redirectURI, request, federation.ProviderNames())
+		if r.Method == http.MethodGet {
+			renderLoginPage(w, http.StatusOK, page)
+			return
+		}
+
+		request.RedirectURI = redirectURI
+		ctx := entity.ContextWithClientIP(r.Context(), clientIP(r))
+		var (
+			code string
+			err  error
+		)
+		if mfaToken := r.PostForm.Get("mfa_token"); mfaToken != "" {
+			// the password is already checked, the second factor completes sign-in
+			page.MFAToken = mfaToken
+			code, err = uc.AuthorizeMFA(ctx, request, mfaToken, r.PostForm.Get("mfa_code"))
+			if errors.Is(err, entity.ErrInvalidCredentials) {
+				page.Error = "Invalid code, sign in again if it keeps failing"
+				renderLoginPage(w, http.StatusUnauthorized, page)
+				return
+			}
+		} else {
+			page.Login = r.PostForm.Get("login")
+			if page.Login == "" {
+				page.Error = "Name or email is required"
+				renderLoginPage(w, http.StatusBadRequest, page)
+				return
+			}
+
+			code, page.MFAToken, err = uc.Authorize(ctx, request, loginUser(page.Login, r.PostForm.Get("password")))
+			if errors.Is(err, entity.ErrInvalidCredentials) {
+				page.Error = "Invalid name, email or password"
+				renderLoginPage(w, http.StatusUnauthorized, page)
+				return
+			}
+			if errors.Is(err, entity.ErrEmailNotVerified) {
+				page.Error = "Verify your email with the link we sent you before signing in"
+				renderLoginPage(w, http.StatusForbidden, page)
+				return
+			}
+			if err == nil && page.MFAToken != "" {
+				renderLoginPage(w, http.StatusOK, page)
+				return
+			}
+		}
+		if renderTooManyRequests(w, page, err) {
+			return
+		}
+		if err != nil {
+			errorCode := authorizationErrorCode(err)
+			if errorCode == errServerError {
+				log.Printf("unable to authorize: %v", err)
+			}
+			redirectWithError(w, r, redirectURI, request.State, errorCode, "unable to authorize")
+			return
+		}
+
+		redirectWithParams(w, r, redirectURI, url.Values{"code": {code}}, request.State)
+	})
+}
+
+// newLoginPage returns the login page posting the authorization request back to the authorization endpoint.
+func newLoginPage(client entity.Client, redirectURI string, request entity.AuthorizationRequest, providers []string) loginPage {
+	return loginPage{
+		Action:              AuthorizePath,
+		ClientName:          client.Name,
+		ClientID:            client.ID,
+		RedirectURI:         redirectURI,
+		Scope:               strings.Join(request.Scopes, " "),
+		State:               request.State,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		Nonce:               request.Nonce,
+		Providers:           providerLinks(providers, request),
+	}
+}
+
+func authorizationRequestFromForm(form url.Values) entity.AuthorizationRequest {
+	return entity.AuthorizationRequest{
+		ClientID:            form.Get("client_id"),
+		RedirectURI:         form.Get("redirect_uri"),
+		Scopes:              strings.Fields(form.Get("scope")),
+		State:               form.Get("state"),
+		CodeChallenge:       form.Get("code_challenge"),
+		CodeChallengeMethod: form.Get("code_challenge_method"),
+		Nonce:               form.Get("nonce"),
+	}
+}
+
+// authorizationRequestQuery encodes the request back, so it's passed through an upstream sign-in.
+func authorizationRequestQuery(request entity.AuthorizationRequest) url.Values {
+	query := url.Values{
+		"response_type":         {codeResponseType},
+		"client_id":             {request.ClientID},
+		"code_challenge":        {request.CodeChallenge},
+		"code_challenge_method": {request.CodeChallengeMethod},
+	}
+	for name, value := range map[string]string{
+		"redirect_uri": request.RedirectURI,
+		"scope":        strings.Join(request.Scopes, " "),
+		"state":        request.State,
+		"nonce":        request.Nonce,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	return query
+}
+
+// resolveAuthorization resolves the client and its redirect URI and validates the request.
+// Errors are written to the response, ok is false then.
+func resolveAuthorization(
+	w http.ResponseWriter,
+	r *http.Request,
+	uc OAuth2Usecase,
+	request entity.AuthorizationRequest,
+	responseType string,
+) (client entity.Client, redirectURI string, ok bool) {
+	// the redirect uri is not trusted yet, so errors are shown to the user
+	client, redirectURI, err := uc.ResolveAuthorizationClient(r.Context(), request)
+	if errors.Is(err, entity.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return entity.Client{}, "", false
+	}
+	if err != nil {
+		log.Printf("unable to resolve authorization client: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return entity.Client{}, "", false
+	}
+
+	if responseType != codeResponseType {
+		redirectWithError(w, r, redirectURI, request.State, errUnsupportedResponseType, "only code response type is supported")
+		return entity.Client{}, "", false
+	}
+	if err := uc.ValidateAuthorizationRequest(client, request); err != nil {
+		redirectWithError(w, r, redirectURI, request.State, authorizationErrorCode(err), err.Error())
+		return entity.Client{}, "", false
+	}
+
+	return client, redirectURI, true
+}
+
+func providerLinks(names []string, request entity.AuthorizationRequest) []providerLink {
+	links := make([]providerLink, 0, len(names))
+	for _, name := range names {
+		query := authorizationRequestQuery(request)
+		query.Set("provider", name)
+		links = append(links, providerLink{
+			Name: name,
+			URL:  FederatedLoginPath + "?" + query.Encode(),
+		})
+	}
+	return links
+}
+
+// clientIP returns the IP address of the HTTP client, as the gRPC handlers resolve it.
+func clientIP(r *http.Request) string {
+	return clientip.Resolve(r.RemoteAddr, r.Header.Values(clientip.ForwardedForHeader))
+}
+
+// loginUser treats login with @ as email, otherwise as name.
+func loginUser(login, password string) entity.User {
+	if strings.Contains(login, "@") {
+		return entity.User{Email: login, Password: password}
+	}
+	return entity.User{Name: login, Password: password}
+}
+
+func authorizationErrorCode(err error) string {
+	switch {
+	case errors.Is(err, entity.ErrInvalidScope):
+		return errInvalidScope
+	case errors.Is(err, entity.ErrValidation):
+		return errInvalidRequest
+	case errors.Is(err, entity.ErrPermissionDenied):
+		return errAccessDenied
+	default:
+		return errServerError
+	}
+}
+
+// renderTooManyRequests renders the login page with Retry-After if sign-in is throttled,
+// it reports whether the response is written.
+func renderTooManyRequests(w http.ResponseWriter, page loginPage, err error) bool {
+	var tooManyRequestsErr entity.TooManyRequestsError
+	if !errors.As(err, &tooManyRequestsErr) {
+		return false
+	}
+	page.Error = "Too many failed attempts, try again later"
+	w.Header().Set("Retry-After", strconv.Itoa(int(tooManyRequestsErr.RetryAfter.Seconds())))
+	renderLoginPage(w, http.StatusTooManyRequests, page)
+	return true
+}
+
+func renderLoginPage(w http.ResponseWriter, status int, page loginPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// the page takes credentials, so it must not be framed by other sites
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+	if err := loginTemplate.Execute(w, page); err != nil {
+		log.Printf("unable to render login page: %v", err)
+	}
+}
+
+func redirectWithError(w http.ResponseWriter, r *http.Request, redirectURI, state, code, description string) {
+	redirectWithParams(w, r, redirectURI, url.Values{
+		"error":             {code},
+		"error_description": {description},
+	}, state)
+}
+
+// redirectWithParams redirects back to the client, params are added to the query of the redirect URI.
+func redirectWithParams(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values, state string) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
@@ -0,0 +1,199 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	FederatedLoginPath    = "/oauth2/federated/login"
+	FederatedCallbackPath = "/oauth2/federated/callback"
+)
+
+const (
+	federatedLoginCookie = "federated_login"
+	// federatedLoginTTL is how long the user may take to sign in with the provider.
+	federatedLoginTTL = 10 * time.Minute
+)
+
+type FederationUsecase interface {
+	ProviderNames() []string
+	StartLogin(ctx context.Context, provider string) (authURL string, login entity.FederatedLogin, err error)
+	CompleteLogin(ctx context.Context, login entity.FederatedLogin, code string) (entity.User, error)
+}
+
+// pendingLogin is kept in the cookie during the sign-in with the provider,
+// so the callback is bound to the browser that started it.
+type pendingLogin struct {
+	Login entity.FederatedLogin `json:"login"`
+	// Authorization is the encoded authorization request of the client.
+	Authorization string `json:"authorization"`
+}
+
+// NewFederatedLoginHandler starts sign-in with an upstream identity provider
+// for the authorization request of the client.
+// The sign-in cookie is Secure if the issuer is https URL, TLS may be terminated by a proxy in front of the service.
+func NewFederatedLoginHandler(uc OAuth2Usecase, federation FederationUsecase, issuer string) http.Handler {
+	secure := secureCookies(issuer)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		request := authorizationRequestFromForm(query)
+		_, redirectURI, ok := resolveAuthorization(w, r, uc, request, query.Get("response_type"))
+		if !ok {
+			return
+		}
+
+		authURL, login, err := federation.StartLogin(r.Context(), query.Get("provider"))
+		if errors.Is(err, entity.ErrValidation) {
+			redirectWithError(w, r, redirectURI, request.State, errInvalidRequest, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("unable to start federated login: %v", err)
+			redirectWithError(w, r, redirectURI, request.State, errTemporarilyUnavailable, "identity provider is unavailable")
+			return
+		}
+
+		value, err := json.Marshal(pendingLogin{
+			Login:         login,
+			Authorization: authorizationRequestQuery(request).Encode(),
+		})
+		if err != nil {
+			log.Printf("unable to encode federated login: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		setFederatedLoginCookie(w, base64.RawURLEncoding.EncodeToString(value), int(federatedLoginTTL.Seconds()), secure)
+
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+}
+
+// NewFederatedCallbackHandler completes sign-in with an upstream identity provider
+// and redirects back to the client with the authorization code.
+// Users with enrolled second factor get the login page asking for it, which completes sign-in as usual.
+func NewFederatedCallbackHandler(uc OAuth2Usecase, federation FederationUsecase, issuer string) http.Handler {
+	secure := secureCookies(issuer)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		pending, ok := readFederatedLoginCookie(r)
+		if !ok {
+			http.Error(w, "sign-in session is expired, start it again", http.StatusBadRequest)
+			return
+		}
+		// the login is single use
+		setFederatedLoginCookie(w, "", -1, secure)
+
+		query := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(pending.Login.State)) != 1 {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		authorization, err := url.ParseQuery(pending.Authorization)
+		if err != nil {
+			http.Error(w, "invalid sign-in session", http.StatusBadRequest)
+			return
+		}
+		request := authorizationRequestFromForm(authorization)
+		client, redirectURI, ok := resolveAuthorization(w, r, uc, request, authorization.Get("response_type"))
+		if !ok {
+			return
+		}
+
+		if providerError := query.Get("error"); providerError != "" {
+			redirectWithError(w, r, redirectURI, request.State, errAccessDenied, "identity provider returned "+providerError)
+			return
+		}
+
+		user, err := federation.CompleteLogin(r.Context(), pending.Login, query.Get("code"))
+		if errors.Is(err, entity.ErrInvalidCredentials) || errors.Is(err, entity.ErrPermissionDenied) {
+			redirectWithError(w, r, redirectURI, request.State, errAccessDenied, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("unable to complete federated login: %v", err)
+			redirectWithError(w, r, redirectURI, request.State, errServerError, "unable to sign in with identity provider")
+			return
+		}
+
+		request.RedirectURI = redirectURI
+		code, mfaToken, err := uc.AuthorizeUser(entity.ContextWithClientIP(r.Context(), clientIP(r)), request, user.ID)
+		page := newLoginPage(client, redirectURI, request, nil)
+		if renderTooManyRequests(w, page, err) {
+			return
+		}
+		if err == nil && mfaToken != "" {
+			page.MFAToken = mfaToken
+			renderLoginPage(w, http.StatusOK, page)
+			return
+		}
+		if err != nil {
+			errorCode := authorizationErrorCode(err)
+			if errorCode == errServerError {
+				log.Printf("unable to issue authorization code: %v", err)
+			}
+			redirectWithError(w, r, redirectURI, request.State, errorCode, "unable to authorize")
+			return
+		}
+
+		redirectWithParams(w, r, redirectURI, url.Values{"code": {code}}, request.State)
+	})
+}
+
+// secureCookies reports whether cookies must be sent over TLS only, that is the service is reached by https issuer URL.
+func secureCookies(issuer string) bool {
+	u, err := url.Parse(issuer)
+	return err == nil && u.Scheme == "https"
+}
+
+func setFederatedLoginCookie(w http.ResponseWriter, value string, maxAge int, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedLoginCookie,
+		Value:    value,
+		Path:     FederatedCallbackPath,
+		MaxAge:   maxAge,
+		Secure:   secure,
+		HttpOnly: true,
+		// the provider redirects back with a top-level navigation, so Lax cookies are sent
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func readFederatedLoginCookie(r *http.Request) (pendingLogin, bool) {
+	cookie, err := r.Cookie(federatedLoginCookie)
+	if err != nil {
+		return pendingLogin{}, false
+	}
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return pendingLogin{}, false
+	}
+
+	var pending pendingLogin
+	if err := json.Unmarshal(value, &pending); err != nil || pending.Login.State == "" {
+		return pendingLogin{}, false
+	}
+	return pending, true
+}
@@ -0,0 +1,230 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	testRedirectURI      = "https://client.example.com/callback"
+	testIdentityProvider = "https://idp.example.com/authorize"
+	testCodeChallenge    = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	// mfaUserID is the user with enrolled second factor
+	mfaUserID = 2
+)
+
+// fakeFederation is an upstream identity provider redirecting back with the code of the user it's given.
+type fakeFederation struct {
+	userID int64
+}
+
+func (fakeFederation) ProviderNames() []string {
+	return []string{"idp"}
+}
+
+func (fakeFederation) StartLogin(_ context.Context, provider string) (string, entity.FederatedLogin, error) {
+	if provider != "idp" {
+		return "", entity.FederatedLogin{}, fmt.Errorf("%w: unknown identity provider '%s'", entity.ErrValidation, provider)
+	}
+	login := entity.FederatedLogin{Provider: provider, State: "idp-state", Nonce: "nonce", CodeVerifier: "verifier"}
+	return testIdentityProvider + "?state=" + login.State, login, nil
+}
+
+func (f fakeFederation) CompleteLogin(_ context.Context, login entity.FederatedLogin, code string) (entity.User, error) {
+	if login.Provider != "idp" || code != "idp-code" {
+		return entity.User{}, fmt.Errorf("%w: invalid code", entity.ErrInvalidCredentials)
+	}
+	return entity.User{ID: f.userID}, nil
+}
+
+// fakeAuthorization authorizes any request of the client, other methods of the usecase are not expected to be called.
+type fakeAuthorization struct {
+	OAuth2Usecase
+}
+
+func (fakeAuthorization) ResolveAuthorizationClient(_ context.Context, request entity.AuthorizationRequest) (entity.Client, string, error) {
+	if request.ClientID != "client" || request.RedirectURI != testRedirectURI {
+		return entity.Client{}, "", fmt.Errorf("%w: unknown client", entity.ErrValidation)
+	}
+	return entity.Client{ID: request.ClientID, Name: "Client"}, request.RedirectURI, nil
+}
+
+func (fakeAuthorization) ValidateAuthorizationRequest(entity.Client, entity.AuthorizationRequest) error {
+	return nil
+}
+
+func (fakeAuthorization) AuthorizeUser(_ context.Context, _ entity.AuthorizationRequest, userID int64) (string, string, error) {
+	if userID == mfaUserID {
+		return "", "mfa-token", nil
+	}
+	return fmt.Sprintf("code-of-%d", userID), "", nil
+}
+
+func TestFederatedCallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int64
+		// callback is the query the provider redirects back with
+		callback url.Values
+		noCookie bool
+		// wantStatus is the response status, wantRedirect the query of redirect to the client if it's 302
+		wantStatus   int
+		wantRedirect url.Values
+		wantMFAToken bool
+	}{
+		{
+			name:         "signed in",
+			userID:       1,
+			callback:     url.Values{"state": {"idp-state"}, "code": {"idp-code"}},
+			wantStatus:   http.StatusFound,
+			wantRedirect: url.Values{"code": {"code-of-1"}, "state": {"client-state"}},
+		},
+		{
+			name:         "second factor is asked",
+			userID:       mfaUserID,
+			callback:     url.Values{"state": {"idp-state"}, "code": {"idp-code"}},
+			wantStatus:   http.StatusOK,
+			wantMFAToken: true,
+		},
+		{
+			name:       "another state",
+			userID:     1,
+			callback:   url.Values{"state": {"other"}, "code": {"idp-code"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "callback in another browser",
+			userID:     1,
+			callback:   url.Values{"state": {"idp-state"}, "code": {"idp-code"}},
+			noCookie:   true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "provider error",
+			userID:       1,
+			callback:     url.Values{"state": {"idp-state"}, "error": {"access_denied"}},
+			wantStatus:   http.StatusFound,
+			wantRedirect: url.Values{"error": {errAccessDenied}, "state": {"client-state"}},
+		},
+		{
+			name:         "invalid code",
+			userID:       1,
+			callback:     url.Values{"state": {"idp-state"}, "code": {"other"}},
+			wantStatus:   http.StatusFound,
+			wantRedirect: url.Values{"error": {errAccessDenied}, "state": {"client-state"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			federation := fakeFederation{userID: tt.userID}
+			loginHandler := NewFederatedLoginHandler(fakeAuthorization{}, federation, "https://auth.example.com")
+			callbackHandler := NewFederatedCallbackHandler(fakeAuthorization{}, federation, "https://auth.example.com")
+
+			query := url.Values{
+				"provider":              {"idp"},
+				"response_type":         {"code"},
+				"client_id":             {"client"},
+				"redirect_uri":          {testRedirectURI},
+				"state":                 {"client-state"},
+				"code_challenge":        {testCodeChallenge},
+				"code_challenge_method": {entity.CodeChallengeMethodS256},
+			}
+			w := httptest.NewRecorder()
+			loginHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, FederatedLoginPath+"?"+query.Encode(), nil))
+			if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), testIdentityProvider) {
+				t.Fatalf("got status %d and location %q, want redirect to the provider", w.Code, w.Header().Get("Location"))
+			}
+			loginCookie := findCookie(w.Result().Cookies(), federatedLoginCookie)
+			if loginCookie == nil || !loginCookie.Secure || !loginCookie.HttpOnly || loginCookie.Path != FederatedCallbackPath {
+				t.Fatalf("got sign-in cookie %+v, want secure http only cookie of the callback", loginCookie)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, FederatedCallbackPath+"?"+tt.callback.Encode(), nil)
+			if !tt.noCookie {
+				r.AddCookie(loginCookie)
+			}
+			w = httptest.NewRecorder()
+			callbackHandler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !tt.noCookie {
+				if cookie := findCookie(w.Result().Cookies(), federatedLoginCookie); cookie == nil || cookie.MaxAge >= 0 {
+					t.Errorf("sign-in cookie is not removed, got %+v", cookie)
+				}
+			}
+			if tt.wantMFAToken && !strings.Contains(w.Body.String(), `name="mfa_token" value="mfa-token"`) {
+				t.Errorf("login page doesn't ask for the second factor: %s", w.Body)
+			}
+			if tt.wantRedirect == nil {
+				return
+			}
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("unable to parse location: %v", err)
+			}
+			if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURI {
+				t.Errorf("got redirect to %q, want %q", got, testRedirectURI)
+			}
+			for name, values := range tt.wantRedirect {
+				if got := location.Query().Get(name); got != values[0] {
+					t.Errorf("got %s %q, want %q", name, got, values[0])
+				}
+			}
+		})
+	}
+}
+
+func TestFederatedLoginCookieSecure(t *testing.T) {
+	tests := []struct {
+		issuer     string
+		wantSecure bool
+	}{
+		{issuer: "https://auth.example.com", wantSecure: true},
+		{issuer: "http://localhost:8080", wantSecure: false},
+		{issuer: "", wantSecure: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.issuer, func(t *testing.T) {
+			handler := NewFederatedLoginHandler(fakeAuthorization{}, fakeFederation{}, tt.issuer)
+			query := url.Values{
+				"provider":              {"idp"},
+				"response_type":         {"code"},
+				"client_id":             {"client"},
+				"redirect_uri":          {testRedirectURI},
+				"code_challenge":        {testCodeChallenge},
+				"code_challenge_method": {entity.CodeChallengeMethodS256},
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, FederatedLoginPath+"?"+query.Encode(), nil))
+
+			cookie := findCookie(w.Result().Cookies(), federatedLoginCookie)
+			if cookie == nil {
+				t.Fatalf("no sign-in cookie, got status %d", w.Code)
+			}
+			if cookie.Secure != tt.wantSecure {
+				t.Errorf("got secure %t, want %t", cookie.Secure, tt.wantSecure)
+			}
+		})
+	}
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ziyadovea/task_manager/users/internal/authentication/jwt"
+)
+
+// JWKSPath is a well-known location of the public keys that verify access tokens.
+const JWKSPath = "/.well-known/jwks.json"
+
+type JWKSProvider interface {
+	JWKS() jwt.JSONWebKeySet
+}
+
+// NewJWKSHandler serves public keys, so other services verify access tokens locally.
+func NewJWKSHandler(provider JWKSProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(provider.JWKS()); err != nil {
+			log.Printf("unable to write jwks response: %v", err)
+		}
+	})
+}
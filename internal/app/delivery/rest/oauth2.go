@@ -0,0 +1,261 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	TokenPath         = "/oauth2/token"
+	IntrospectionPath = "/oauth2/introspect"
+	RevocationPath    = "/oauth2/revoke"
+)
+
+// Grant types supported by the token endpoint.
+const (
+	authorizationCodeGrantType = "authorization_code"
+	refreshTokenGrantType      = "refresh_token"
+	clientCredentialsGrantType = "client_credentials"
+)
+
+// OAuth2 error codes, RFC 6749 section 5.2.
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errUnauthorizedClient   = "unauthorized_client"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errInvalidScope         = "invalid_scope"
+	errServerError          = "server_error"
+)
+
+type OAuth2Usecase interface {
+	AuthenticateClient(ctx context.Context, clientID, clientSecret string) (entity.Client, error)
+	ResolveAuthorizationClient(ctx context.Context, request entity.AuthorizationRequest) (client entity.Client, redirectURI string, err error)
+	ValidateAuthorizationRequest(client entity.Client, request entity.AuthorizationRequest) error
+	Authorize(ctx context.Context, request entity.AuthorizationRequest, user entity.User) (code, mfaToken string, err error)
+	AuthorizeMFA(ctx context.Context, request entity.AuthorizationRequest, mfaToken, mfaCode string) (code string, err error)
+	AuthorizeUser(ctx context.Context, request entity.AuthorizationRequest, userID int64) (code, mfaToken string, err error)
+	ExchangeAuthorizationCode(ctx context.Context, client entity.Client, code, redirectURI, codeVerifier string) (entity.TokenSet, error)
+	RefreshClientToken(ctx context.Context, client entity.Client, refreshToken string) (entity.TokenSet, error)
+	IssueClientToken(ctx context.Context, client entity.Client, scopes []string) (string, entity.TokenClaims, error)
+	IntrospectToken(ctx context.Context, client entity.Client, token, tokenTypeHint string) (entity.TokenIntrospection, error)
+	RevokeToken(ctx context.Context, client entity.Client, token, tokenTypeHint string) error
+	GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error)
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type introspectionResponse struct {
+	Active   bool   `json:"active"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Sub      string `json:"sub,omitempty"`
+	Exp      int64  `json:"exp,omitempty"`
+	Iat      int64  `json:"iat,omitempty"`
+	Jti      string `json:"jti,omitempty"`
+	// PrincipalType tells humans and machines apart, it's an extension of RFC 7662.
+	PrincipalType string `json:"principal_type,omitempty"`
+}
+
+type oauth2ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewTokenHandler serves the token endpoint of RFC 6749,
+// authorization_code (with PKCE), refresh_token and client_credentials grants are supported.
+func NewTokenHandler(uc OAuth2Usecase) http.Handler {
+	return clientAuthHandler(uc, func(w http.ResponseWriter, r *http.Request, client entity.Client) {
+		var (
+			tokens entity.TokenSet
+			err    error
+		)
+		switch r.PostForm.Get("grant_type") {
+		case authorizationCodeGrantType:
+			tokens, err = uc.ExchangeAuthorizationCode(
+				r.Context(), client, r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"),
+			)
+		case refreshTokenGrantType:
+			tokens, err = uc.RefreshClientToken(r.Context(), client, r.PostForm.Get("refresh_token"))
+		case clientCredentialsGrantType:
+			tokens.AccessToken, tokens.Claims, err = uc.IssueClientToken(r.Context(), client, strings.Fields(r.PostForm.Get("scope")))
+		default:
+			writeOAuth2Error(w, http.StatusBadRequest, errUnsupportedGrantType, "unsupported grant type")
+			return
+		}
+
+		switch {
+		case errors.Is(err, entity.ErrInvalidCredentials), errors.Is(err, entity.ErrEmailNotVerified):
+			writeOAuth2Error(w, http.StatusBadRequest, errInvalidGrant, err.Error())
+			return
+		case errors.Is(err, entity.ErrInvalidScope):
+			writeOAuth2Error(w, http.StatusBadRequest, errInvalidScope, err.Error())
+			return
+		case errors.Is(err, entity.ErrPermissionDenied):
+			writeOAuth2Error(w, http.StatusBadRequest, errUnauthorizedClient, err.Error())
+			return
+		case err != nil:
+			log.Printf("unable to issue token: %v", err)
+			writeOAuth2Error(w, http.StatusInternalServerError, errServerError, "unable to issue token")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, tokenResponse{
+			AccessToken:  tokens.AccessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int64(tokens.Claims.ExpiresAt.Sub(tokens.Claims.IssuedAt).Seconds()),
+			RefreshToken: tokens.RefreshToken,
+			IDToken:      tokens.IDToken,
+			Scope:        strings.Join(tokens.Claims.Scopes, " "),
+		})
+	})
+}
+
+// NewIntrospectionHandler serves token introspection by RFC 7662 for confidential clients.
+func NewIntrospectionHandler(uc OAuth2Usecase) http.Handler {
+	return tokenRequestHandler(uc, func(w http.ResponseWriter, r *http.Request, client entity.Client, token, tokenTypeHint string) {
+		introspection, err := uc.IntrospectToken(r.Context(), client, token, tokenTypeHint)
+		if errors.Is(err, entity.ErrPermissionDenied) {
+			writeOAuth2Error(w, http.StatusBadRequest, errUnauthorizedClient, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("unable to introspect token: %v", err)
+			writeOAuth2Error(w, http.StatusInternalServerError, errServerError, "unable to introspect token")
+			return
+		}
+
+		resp := introspectionResponse{Active: introspection.Active}
+		if introspection.Active {
+			claims := introspection.Claims
+			resp.Scope = strings.Join(claims.Scopes, " ")
+			resp.ClientID = claims.ClientID
+			switch {
+			case claims.ServiceAccountID != 0:
+				// ids of service accounts are apart from user ids, as in the token subject
+				resp.Sub = "sa:" + strconv.FormatInt(claims.ServiceAccountID, 10)
+			case claims.UserID == 0 && claims.ClientID != "":
+				// token is issued to the client itself
+				resp.Sub = claims.ClientID
+			default:
+				resp.Sub = strconv.FormatInt(claims.UserID, 10)
+			}
+			resp.Exp = claims.ExpiresAt.Unix()
+			resp.Iat = claims.IssuedAt.Unix()
+			resp.Jti = claims.ID
+			resp.PrincipalType = claims.PrincipalType
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// NewRevocationHandler serves token revocation by RFC 7009.
+func NewRevocationHandler(uc OAuth2Usecase) http.Handler {
+	return tokenRequestHandler(uc, func(w http.ResponseWriter, r *http.Request, client entity.Client, token, tokenTypeHint string) {
+		err := uc.RevokeToken(r.Context(), client, token, tokenTypeHint)
+		if errors.Is(err, entity.ErrPermissionDenied) {
+			writeOAuth2Error(w, http.StatusBadRequest, errUnauthorizedClient, "token is issued to another client")
+			return
+		}
+		if err != nil {
+			log.Printf("unable to revoke token: %v", err)
+			writeOAuth2Error(w, http.StatusServiceUnavailable, errServerError, "unable to revoke token")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+type clientHandlerFunc func(w http.ResponseWriter, r *http.Request, client entity.Client)
+
+type tokenRequestHandlerFunc func(w http.ResponseWriter, r *http.Request, client entity.Client, token, tokenTypeHint string)
+
+// clientAuthHandler accepts form encoded POST requests,
+// authenticates the client and passes the request to next.
+// Confidential clients authenticate with basic auth or client_secret form parameter,
+// public clients send client_id form parameter only.
+func clientAuthHandler(uc OAuth2Usecase, next clientHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			writeOAuth2Error(w, http.StatusBadRequest, errInvalidRequest, "invalid form")
+			return
+		}
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if clientID == "" {
+			writeInvalidClient(w)
+			return
+		}
+		client, err := uc.AuthenticateClient(r.Context(), clientID, clientSecret)
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			writeInvalidClient(w)
+			return
+		}
+		if err != nil {
+			log.Printf("unable to authenticate client: %v", err)
+			writeOAuth2Error(w, http.StatusInternalServerError, errServerError, "unable to authenticate client")
+			return
+		}
+
+		next(w, r, client)
+	})
+}
+
+// tokenRequestHandler passes the token and the token type hint of introspection
+// and revocation requests to next.
+func tokenRequestHandler(uc OAuth2Usecase, next tokenRequestHandlerFunc) http.Handler {
+	return clientAuthHandler(uc, func(w http.ResponseWriter, r *http.Request, client entity.Client) {
+		token := r.PostForm.Get("token")
+		if token == "" {
+			writeOAuth2Error(w, http.StatusBadRequest, errInvalidRequest, "empty token")
+			return
+		}
+
+		next(w, r, client, token, r.PostForm.Get("token_type_hint"))
+	})
+}
+
+func writeInvalidClient(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	writeOAuth2Error(w, http.StatusUnauthorized, errInvalidClient, "client authentication failed")
+}
+
+func writeOAuth2Error(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, oauth2ErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("unable to write response: %v", err)
+	}
+}
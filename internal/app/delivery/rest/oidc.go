@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	// DiscoveryPath is relative to the issuer, so OpenID Connect libraries find the provider by its issuer.
+	DiscoveryPath = "/.well-known/openid-configuration"
+	UserInfoPath  = "/userinfo"
+)
+
+type IDTokenSigningAlgorithmsProvider interface {
+	IDTokenSigningAlgorithms() []string
+}
+
+// discoveryDocument is OpenID Provider Metadata of OpenID Connect Discovery 1.0.
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+type userInfoResponse struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// NewDiscoveryHandler serves the discovery document, endpoints are located under the issuer URL.
+func NewDiscoveryHandler(issuer string, algorithms IDTokenSigningAlgorithmsProvider) http.Handler {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, discoveryDocument{
+			Issuer:                            issuer,
+			AuthorizationEndpoint:             issuer + AuthorizePath,
+			TokenEndpoint:                     issuer + TokenPath,
+			UserInfoEndpoint:                  issuer + UserInfoPath,
+			JWKSURI:                           issuer + JWKSPath,
+			IntrospectionEndpoint:             issuer + IntrospectionPath,
+			RevocationEndpoint:                issuer + RevocationPath,
+			ScopesSupported:                   []string{entity.ScopeOpenID, entity.ScopeProfile, entity.ScopeEmail},
+			ResponseTypesSupported:            []string{codeResponseType},
+			GrantTypesSupported:               []string{authorizationCodeGrantType, refreshTokenGrantType, clientCredentialsGrantType},
+			SubjectTypesSupported:             []string{"public"},
+			IDTokenSigningAlgValuesSupported:  algorithms.IDTokenSigningAlgorithms(),
+			TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:     []string{entity.CodeChallengeMethodS256},
+			ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email", "email_verified"},
+		})
+	})
+}
+
+// NewUserInfoHandler serves the userinfo endpoint, the access token is passed as a bearer token (RFC 6750).
+func NewUserInfoHandler(uc OAuth2Usecase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		info, err := uc.GetUserInfo(r.Context(), token)
+		switch {
+		case errors.Is(err, entity.ErrInvalidCredentials):
+			writeBearerError(w, http.StatusUnauthorized, "invalid_token", "invalid access token")
+			return
+		case errors.Is(err, entity.ErrInvalidScope):
+			writeBearerError(w, http.StatusForbidden, "insufficient_scope", "openid scope is required")
+			return
+		case err != nil:
+			log.Printf("unable to get user info: %v", err)
+			writeOAuth2Error(w, http.StatusInternalServerError, errServerError, "unable to get user info")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, userInfoResponse{
+			Sub:           info.Subject,
+			Name:          info.Name,
+			Email:         info.Email,
+			EmailVerified: info.EmailVerified,
+		})
+	})
+}
+
+func writeBearerError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="`+code+`", error_description="`+description+`"`)
+	writeOAuth2Error(w, status, code, description)
+}
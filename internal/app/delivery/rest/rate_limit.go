@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimiter takes a token from the bucket of the caller to the method,
+// it returns the delay until a token is available if the bucket is empty.
+type RateLimiter interface {
+	Reserve(method, caller string) time.Duration
+}
+
+// WithRateLimit limits request rates of each client IP to the handler as gRPC methods are limited,
+// path names the handler in the limits. Rejected requests get 429 with Retry-After.
+func WithRateLimit(limiter RateLimiter, path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay := limiter.Reserve(path, "ip:"+clientIP(r)); delay > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			http.Error(w, fmt.Sprintf("rate limit of %s is exceeded", path), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
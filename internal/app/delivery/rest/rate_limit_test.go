@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fixedRateLimiter returns the same delay for any request and records callers.
+type fixedRateLimiter struct {
+	delay   time.Duration
+	callers []string
+}
+
+func (l *fixedRateLimiter) Reserve(_, caller string) time.Duration {
+	l.callers = append(l.callers, caller)
+	return l.delay
+}
+
+func TestWithRateLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		delay          time.Duration
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "passed", delay: 0, wantStatus: http.StatusOK},
+		{name: "rejected for whole seconds", delay: 2 * time.Second, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "2"},
+		{name: "retry after is rounded up", delay: 1500 * time.Millisecond, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "2"},
+		{name: "retry after is at least a second", delay: time.Millisecond, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fixedRateLimiter{delay: tt.delay}
+			var called bool
+			handler := WithRateLimit(limiter, "/oauth2/token", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/oauth2/token", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("got Retry-After %q, want %q", got, tt.wantRetryAfter)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler is called %t, want %t", called, tt.wantStatus == http.StatusOK)
+			}
+			if len(limiter.callers) != 1 || limiter.callers[0] != "ip:192.0.2.1" {
+				t.Errorf("got callers %v, want the client ip", limiter.callers)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// APIKeyPrefix starts API keys, so they are told apart from JWTs and found by secret scanners.
+const APIKeyPrefix = "tm_"
+
+const maxAPIKeyNameLength = 64
+
+// APIKey is a long-lived credential of the user for scripts and CI, such as 'tm_<id>_<secret>'.
+// The key is shown once on creation, only its hash is stored and the id is its lookup prefix.
+type APIKey struct {
+	ID      string
+	UserID  int64
+	Name    string
+	KeyHash string
+	// Scopes are permissions the key is limited to, the user must have them too.
+	Scopes    []string
+	CreatedAt time.Time
+	// ExpiresAt is nil for keys that never expire.
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (k APIKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("%w: empty api key name", ErrValidation)
+	}
+	if utf8.RuneCountInString(k.Name) > maxAPIKeyNameLength {
+		return fmt.Errorf("%w: api key name is longer than %d characters", ErrValidation, maxAPIKeyNameLength)
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("%w: empty api key scopes", ErrValidation)
+	}
+	return nil
+}
+
+// Expired reports whether the key is expired at the given time.
+func (k APIKey) Expired(at time.Time) bool {
+	return k.ExpiresAt != nil && !at.Before(*k.ExpiresAt)
+}
+
+// FormatAPIKey returns the key given to the user.
+func FormatAPIKey(id, secret string) string {
+	return APIKeyPrefix + id + "_" + secret
+}
+
+// ParseAPIKey returns the id of the key to look it up by, ok is false for malformed keys.
+func ParseAPIKey(key string) (id string, ok bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return id, true
+}
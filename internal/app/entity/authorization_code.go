@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// CodeChallengeMethodS256 is the only supported PKCE code challenge method.
+const CodeChallengeMethodS256 = "S256"
+
+const maxNonceLength = 255
+
+// codeVerifierRegexp matches code_verifier of RFC 7636 section 4.1.
+var codeVerifierRegexp = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// AuthorizationRequest is a request of the authorization code flow with PKCE (RFC 6749 section 4.1, RFC 7636).
+type AuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce of OpenID Connect request, it's copied to id_token.
+	Nonce string
+}
+
+// ValidateNonce checks the nonce fits the storage, it's opaque otherwise.
+func (r AuthorizationRequest) ValidateNonce() error {
+	if len(r.Nonce) > maxNonceLength {
+		return fmt.Errorf("%w: nonce is longer than %d characters", ErrValidation, maxNonceLength)
+	}
+	return nil
+}
+
+func (r AuthorizationRequest) ValidateCodeChallenge() error {
+	if r.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return fmt.Errorf("%w: code challenge method must be %s", ErrValidation, CodeChallengeMethodS256)
+	}
+	// S256 challenge is base64url encoded SHA-256 digest without padding
+	if len(r.CodeChallenge) != base64.RawURLEncoding.EncodedLen(sha256.Size) {
+		return fmt.Errorf("%w: invalid code challenge", ErrValidation)
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(r.CodeChallenge); err != nil {
+		return fmt.Errorf("%w: invalid code challenge", ErrValidation)
+	}
+	return nil
+}
+
+// AuthorizationCode is a server-side record of issued authorization code,
+// only the hash of the code is stored.
+type AuthorizationCode struct {
+	CodeHash      string    `db:"code_hash"`
+	ClientID      string    `db:"client_id"`
+	UserID        int64     `db:"user_id"`
+	RedirectURI   string    `db:"redirect_uri"`
+	Scopes        []string  `db:"-"`
+	CodeChallenge string    `db:"code_challenge"`
+	Nonce         string    `db:"nonce"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+// VerifyCodeVerifier checks PKCE code verifier against the S256 code challenge.
+func (c AuthorizationCode) VerifyCodeVerifier(verifier string) error {
+	if !codeVerifierRegexp.MatchString(verifier) {
+		return fmt.Errorf("%w: invalid code verifier", ErrInvalidCredentials)
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(c.CodeChallenge)) != 1 {
+		return fmt.Errorf("%w: code verifier doesn't match code challenge", ErrInvalidCredentials)
+	}
+	return nil
+}
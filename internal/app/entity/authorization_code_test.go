@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// verifier and challenge of RFC 7636 appendix B
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestAuthorizationRequestValidateCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		method    string
+		wantErr   error
+	}{
+		{name: "valid", challenge: testCodeChallenge, method: CodeChallengeMethodS256},
+		{name: "plain method", challenge: testCodeVerifier, method: "plain", wantErr: ErrValidation},
+		{name: "empty method", challenge: testCodeChallenge, method: "", wantErr: ErrValidation},
+		{name: "empty challenge", challenge: "", method: CodeChallengeMethodS256, wantErr: ErrValidation},
+		{name: "short challenge", challenge: testCodeChallenge[1:], method: CodeChallengeMethodS256, wantErr: ErrValidation},
+		{name: "padded challenge", challenge: testCodeChallenge + "=", method: CodeChallengeMethodS256, wantErr: ErrValidation},
+		{name: "standard base64 challenge", challenge: strings.ReplaceAll(testCodeChallenge, "-", "+"), method: CodeChallengeMethodS256, wantErr: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := AuthorizationRequest{CodeChallenge: tt.challenge, CodeChallengeMethod: tt.method}
+			if err := r.ValidateCodeChallenge(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeVerifyCodeVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		wantErr  error
+	}{
+		{name: "matching verifier", verifier: testCodeVerifier},
+		{name: "another verifier", verifier: strings.Repeat("a", 43), wantErr: ErrInvalidCredentials},
+		{name: "challenge as verifier", verifier: testCodeChallenge, wantErr: ErrInvalidCredentials},
+		{name: "empty verifier", verifier: "", wantErr: ErrInvalidCredentials},
+		{name: "short verifier", verifier: strings.Repeat("a", 42), wantErr: ErrInvalidCredentials},
+		{name: "long verifier", verifier: strings.Repeat("a", 129), wantErr: ErrInvalidCredentials},
+		{name: "invalid characters", verifier: testCodeVerifier[1:] + "+", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := AuthorizationCode{CodeChallenge: testCodeChallenge}
+			if err := code.VerifyCodeVerifier(tt.verifier); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
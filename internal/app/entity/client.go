@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// scopeRegexp matches scope-token of RFC 6749 section 3.3.
+var scopeRegexp = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// Client is an OAuth2 client, it authenticates with the client id and secret.
+// Public clients, such as SPA and mobile apps, can't keep a secret:
+// they authenticate with the client id only and may use the authorization code flow with PKCE only.
+type Client struct {
+	ID     string
+	Name   string
+	Secret string
+	Public bool
+	// Scopes the client is allowed to request.
+	Scopes       []string
+	RedirectURIs []string
+}
+
+func (c *Client) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: empty client name", ErrValidation)
+	}
+	for _, scope := range c.Scopes {
+		if !scopeRegexp.MatchString(scope) {
+			return fmt.Errorf("%w: invalid scope '%s'", ErrValidation, scope)
+		}
+	}
+	for _, redirectURI := range c.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return fmt.Errorf("%w: redirect uri '%s' must be absolute without fragment", ErrValidation, redirectURI)
+		}
+	}
+	return nil
+}
+
+// ResolveRedirectURI returns the registered redirect URI matching the requested one exactly,
+// the only registered URI is used when nothing is requested.
+func (c Client) ResolveRedirectURI(requested string) (string, error) {
+	if requested == "" {
+		if len(c.RedirectURIs) != 1 {
+			return "", fmt.Errorf("%w: redirect uri is required", ErrValidation)
+		}
+		return c.RedirectURIs[0], nil
+	}
+	if !slices.Contains(c.RedirectURIs, requested) {
+		return "", fmt.Errorf("%w: redirect uri '%s' is not registered", ErrValidation, requested)
+	}
+	return requested, nil
+}
+
+// GrantScopes returns scopes granted to the client for the requested ones,
+// all allowed scopes are granted when nothing is requested.
+func (c Client) GrantScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return c.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !slices.Contains(c.Scopes, scope) {
+			return nil, fmt.Errorf("%w: scope '%s' is not allowed", ErrInvalidScope, scope)
+		}
+	}
+	return requested, nil
+}
+
+func (c *Client) HashSecret() error {
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(c.Secret), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("unable to generate hash from secret: %w", err)
+	}
+	c.Secret = string(hashedSecret)
+	return nil
+}
+
+// CompareSecret checks the client secret, public clients have no secret.
+func (c Client) CompareSecret(secret string) error {
+	if c.Public {
+		if secret != "" {
+			return errors.New("public client has no secret")
+		}
+		return nil
+	}
+	return bcrypt.CompareHashAndPassword([]byte(c.Secret), []byte(secret))
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "context"
+
+type clientIPCtxKey struct{}
+
+// ContextWithClientIP returns a copy of ctx that carries the IP address of the caller.
+func ContextWithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPCtxKey{}, ip)
+}
+
+// ClientIPFromContext returns the IP address of the caller stored in ctx, if any.
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPCtxKey{}).(string)
+	return ip, ok && ip != ""
+}
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Domain errors, shared by all layers.
+// Usecase and repository wrap them with details, delivery maps them to transport codes.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrValidation         = errors.New("validation error")
+	ErrPermissionDenied   = errors.New("permission denied")
+	// ErrInvalidScope means the client requested a scope it's not allowed to.
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrEmailNotVerified means sign-in is blocked until the user verifies the email.
+	ErrEmailNotVerified = errors.New("email is not verified")
+	// ErrTooManyRequests means the request is rejected for a while, such as sign-in after failed attempts.
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// FieldViolation describes why a field of the request is invalid.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError lists violations of request fields, it matches ErrValidation.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e ValidationError) Error() string {
+	descriptions := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		descriptions[i] = violation.Field + ": " + violation.Description
+	}
+	return ErrValidation.Error() + ": " + strings.Join(descriptions, "; ")
+}
+
+func (e ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// TooManyRequestsError rejects requests until RetryAfter passes, it matches ErrTooManyRequests.
+type TooManyRequestsError struct {
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e TooManyRequestsError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrTooManyRequests, e.Description, e.RetryAfter)
+}
+
+func (e TooManyRequestsError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// ExternalIdentity is a user identity asserted by an upstream OpenID Connect provider in its id_token.
+type ExternalIdentity struct {
+	Provider          string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// Identity links the subject of an upstream provider to the local user.
+type Identity struct {
+	Provider  string    `db:"provider"`
+	Subject   string    `db:"subject"`
+	UserID    int64     `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// FederatedLogin is a pending sign-in with an upstream provider,
+// it's kept by the browser until the provider redirects back.
+type FederatedLogin struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
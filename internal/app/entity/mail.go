@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Email is a plain text message sent to the user.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailVerificationClaims are claims of the signed token proving the user owns the email.
+// The token is bound to the email, so it's invalid once the email changes.
+type EmailVerificationClaims struct {
+	ID        string
+	UserID    int64
+	Email     string
+	ExpiresAt time.Time
+}
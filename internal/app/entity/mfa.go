@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	// totpPeriod is the time step of TOTP codes, RFC 6238 default.
+	totpPeriod = 30
+	// totpSkew is the number of adjacent steps accepted to tolerate clock drift.
+	totpSkew = 1
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TOTP is the time-based one-time password (RFC 6238) second factor of the user.
+// It's enforced on sign-in only after the user confirms enrollment with a first code.
+type TOTP struct {
+	UserID      int64      `db:"user_id"`
+	Secret      string     `db:"secret"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code, so a code is never accepted twice.
+	LastUsedStep int64 `db:"last_used_step"`
+}
+
+func (t TOTP) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// Verify returns the time step of the code if it's valid at the moment.
+// The caller must reject steps not after LastUsedStep.
+func (t TOTP) Verify(code string, now time.Time) (int64, error) {
+	code = strings.TrimSpace(code)
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totp.GenerateCodeCustom(t.Secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, fmt.Errorf("unable to generate totp code: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid one-time password", ErrInvalidCredentials)
+}
+
+// RecoveryCode is a single use code signing the user in when the TOTP device is lost,
+// only its hash is stored.
+type RecoveryCode struct {
+	UserID   int64      `db:"user_id"`
+	CodeHash string     `db:"code_hash"`
+	UsedAt   *time.Time `db:"used_at"`
+}
+
+// NormalizeRecoveryCode removes separators, so codes are accepted as typed by the user.
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// FormatRecoveryCode groups the code by 4 characters for readability.
+func FormatRecoveryCode(code string) string {
+	var groups []string
+	for len(code) > 4 {
+		groups = append(groups, code[:4])
+		code = code[4:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
+
+// MFAChallenge is issued on sign-in of the user with enrolled second factor
+// instead of tokens, tokens are issued once the challenge is completed.
+type MFAChallenge struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int64     `db:"user_id"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func TestTOTPVerify(t *testing.T) {
+	// now is in the middle of the step, so adjacent steps are exactly one period away
+	now := time.Unix(1792321545, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name string
+		// codeAt is the moment the code is generated at, code is used as is if it's zero
+		codeAt time.Time
+		code   string
+		// spaced surrounds the generated code with spaces
+		spaced   bool
+		wantStep int64
+		wantErr  error
+	}{
+		{name: "current step", codeAt: now, wantStep: current},
+		{name: "previous step", codeAt: now.Add(-totpPeriod * time.Second), wantStep: current - 1},
+		{name: "next step", codeAt: now.Add(totpPeriod * time.Second), wantStep: current + 1},
+		{name: "two steps behind", codeAt: now.Add(-2 * totpPeriod * time.Second), wantErr: ErrInvalidCredentials},
+		{name: "two steps ahead", codeAt: now.Add(2 * totpPeriod * time.Second), wantErr: ErrInvalidCredentials},
+		{name: "surrounding spaces", codeAt: now, spaced: true, wantStep: current},
+		{name: "wrong code", code: "000000", wantErr: ErrInvalidCredentials},
+		{name: "empty code", code: "", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := tt.code
+			if !tt.codeAt.IsZero() {
+				generated, err := totp.GenerateCodeCustom(testTOTPSecret, tt.codeAt, totpOpts)
+				if err != nil {
+					t.Fatalf("unable to generate code: %v", err)
+				}
+				code = generated
+				if tt.spaced {
+					code = " " + generated + " "
+				}
+			}
+
+			step, err := TOTP{Secret: testTOTPSecret}.Verify(code, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if step != tt.wantStep {
+				t.Errorf("got step %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
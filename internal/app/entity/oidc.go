@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"slices"
+	"strconv"
+)
+
+// OpenID Connect scopes, they decide which user claims are exposed to the client.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// UserInfo is a set of standard OpenID Connect claims of the user,
+// it's returned by the userinfo endpoint and put in id_token.
+type UserInfo struct {
+	Subject       string
+	Name          string
+	Email         string
+	EmailVerified *bool
+}
+
+// NewUserInfo exposes user fields allowed by the granted scopes:
+// profile exposes name, email exposes email and email_verified.
+func NewUserInfo(user User, scopes []string) UserInfo {
+	info := UserInfo{Subject: strconv.FormatInt(user.ID, 10)}
+	if slices.Contains(scopes, ScopeProfile) {
+		info.Name = user.Name
+	}
+	if slices.Contains(scopes, ScopeEmail) {
+		info.Email = user.Email
+		info.EmailVerified = &user.EmailVerified
+	}
+	return info
+}
+
+// IDTokenClaims are claims of OpenID Connect id_token issued to the client.
+type IDTokenClaims struct {
+	UserInfo
+	ClientID string
+	// Nonce is passed by the client in the authorization request to bind id_token to its session.
+	Nonce string
+}
+
+// HasOpenIDScope reports whether the scopes request OpenID Connect.
+func HasOpenIDScope(scopes []string) bool {
+	return slices.Contains(scopes, ScopeOpenID)
+}
+
+// scopePermissions are permissions OpenID Connect scopes grant to tokens issued to clients,
+// so the client signing the user in may read and update the profile of that user.
+// Access to other users still requires users.manage, which no OpenID Connect scope grants.
+var scopePermissions = map[string][]string{
+	ScopeProfile: {PermissionUsersRead, PermissionUsersUpdate},
+	ScopeEmail:   {PermissionUsersRead},
+}
+
+// ScopesAllow reports whether the scopes granted to the client allow the permission:
+// either the permission itself is granted as a scope or an OpenID Connect scope implies it.
+func ScopesAllow(scopes []string, permission string) bool {
+	for _, scope := range scopes {
+		if scope == permission || slices.Contains(scopePermissions[scope], permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// WebAuthn ceremonies a session is started for.
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+const maxPasskeyNameLength = 64
+
+// Passkey is a WebAuthn credential of the user, it signs the user in without a password.
+type Passkey struct {
+	ID     []byte
+	UserID int64
+	// Name helps the user tell passkeys apart, such as "laptop".
+	Name            string
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	// SignCount is the signature counter of the authenticator, it only grows unless the authenticator is cloned.
+	SignCount      int64
+	AAGUID         []byte
+	BackupEligible bool
+	BackupState    bool
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
+}
+
+func ValidatePasskeyName(name string) error {
+	if utf8.RuneCountInString(name) > maxPasskeyNameLength {
+		return fmt.Errorf("%w: passkey name is longer than %d characters", ErrValidation, maxPasskeyNameLength)
+	}
+	return nil
+}
+
+// WebAuthnSession keeps the challenge of a registration or login ceremony until it's finished,
+// only the hash of the session id is stored.
+type WebAuthnSession struct {
+	IDHash string `db:"id_hash"`
+	// UserID is the user registering a passkey, login sessions have no user.
+	UserID   *int64 `db:"user_id"`
+	Ceremony string `db:"ceremony"`
+	// Data is opaque session data of the relying party.
+	Data      []byte    `db:"data"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
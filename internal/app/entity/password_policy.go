@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minUserInfoLength is the shortest name or email part looked for in passwords,
+// shorter ones match too many passwords by chance.
+const minUserInfoLength = 3
+
+// PasswordPolicy is a set of rules new passwords must follow.
+// The zero policy only rejects empty passwords.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength is in bytes, zero means no limit.
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// DisallowUserInfo rejects passwords containing the name or the email of the user.
+	DisallowUserInfo bool
+}
+
+// Violations returns descriptions of rules the password of the user breaks, none for a valid password.
+func (p PasswordPolicy) Violations(password string, user User) []string {
+	if password == "" {
+		return []string{"password is empty"}
+	}
+
+	var violations []string
+	if length := utf8.RuneCountInString(password); length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password is shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("password is longer than %d bytes", p.MaxLength))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	for _, class := range []struct {
+		required bool
+		present  bool
+		name     string
+	}{
+		{required: p.RequireLowercase, present: hasLower, name: "a lowercase letter"},
+		{required: p.RequireUppercase, present: hasUpper, name: "an uppercase letter"},
+		{required: p.RequireDigit, present: hasDigit, name: "a digit"},
+		{required: p.RequireSymbol, present: hasSymbol, name: "a symbol"},
+	} {
+		if class.required && !class.present {
+			violations = append(violations, "password must contain "+class.name)
+		}
+	}
+
+	if p.DisallowUserInfo && containsUserInfo(password, user) {
+		violations = append(violations, "password must not contain the name or email")
+	}
+
+	return violations
+}
+
+// containsUserInfo reports whether the password contains the name, the email or its local part, ignoring case.
+func containsUserInfo(password string, user User) bool {
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(user.Email, "@")
+	for _, info := range []string{user.Name, user.Email, localPart} {
+		info = strings.ToLower(info)
+		if utf8.RuneCountInString(info) >= minUserInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+	return false
+}
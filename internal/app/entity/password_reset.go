@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// PasswordResetToken is a server-side record of the token sent to the user who forgot the password,
+// only the hash of the token is stored.
+type PasswordResetToken struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int64     `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
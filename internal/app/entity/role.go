@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Built-in roles, created by migrations.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	// DefaultRole is granted to every registered user.
+	DefaultRole = RoleUser
+)
+
+// Built-in permissions, created by migrations.
+const (
+	PermissionUsersRead   = "users.read"
+	PermissionUsersList   = "users.list"
+	PermissionUsersUpdate = "users.update"
+	PermissionUsersDelete = "users.delete"
+	// PermissionUsersManage allows to access any user, not only the caller itself.
+	PermissionUsersManage   = "users.manage"
+	PermissionRolesManage   = "roles.manage"
+	PermissionKeysManage    = "keys.manage"
+	PermissionClientsManage = "clients.manage"
+	// PermissionMFAReset allows to remove the second factor of any user who lost it.
+	PermissionMFAReset = "users.mfa.reset"
+	// PermissionUsersUnlock allows to lift sign-in lockout of any user.
+	PermissionUsersUnlock = "users.unlock"
+	// PermissionServiceAccountsManage allows to create service accounts and manage their credentials.
+	PermissionServiceAccountsManage = "service_accounts.manage"
+)
+
+type Role struct {
+	ID          int64    `db:"id"`
+	Name        string   `db:"name"`
+	Description string   `db:"description"`
+	Permissions []string `db:"-"`
+}
+
+func (r *Role) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: empty role name", ErrValidation)
+	}
+	if slices.Contains(r.Permissions, "") {
+		return fmt.Errorf("%w: empty permission name", ErrValidation)
+	}
+	return nil
+}
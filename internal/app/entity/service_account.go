@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxServiceAccountNameLength        = 100
+	maxServiceAccountDescriptionLength = 255
+)
+
+// ServiceAccount is a non-human principal of other services, it has no email and no password.
+// Its id and name are apart from users, roles are granted to it on its own,
+// and it signs in with the client secret or an assertion signed by its own key pair.
+type ServiceAccount struct {
+	ID          int64
+	Name        string
+	Description string
+	// OwnerID is the admin who created the account, zero once the owner is removed.
+	OwnerID int64
+	// SecretHash is empty if the account authenticates with the key pair only.
+	SecretHash string
+	// PublicKey is PEM encoded public key verifying assertions of the account, empty if there is none.
+	PublicKey string
+	Roles     []string
+	CreatedAt time.Time
+}
+
+func (a ServiceAccount) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("%w: empty service account name", ErrValidation)
+	}
+	if utf8.RuneCountInString(a.Name) > maxServiceAccountNameLength {
+		return fmt.Errorf("%w: service account name is longer than %d characters", ErrValidation, maxServiceAccountNameLength)
+	}
+	if utf8.RuneCountInString(a.Description) > maxServiceAccountDescriptionLength {
+		return fmt.Errorf("%w: service account description is longer than %d characters", ErrValidation, maxServiceAccountDescriptionLength)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Token type hints of OAuth2 introspection and revocation requests.
+const (
+	AccessTokenTypeHint  = "access_token"
+	RefreshTokenTypeHint = "refresh_token"
+)
+
+// TokenClaims are claims carried by access and refresh tokens.
+type TokenClaims struct {
+	ID     string
+	UserID int64
+	// PrincipalType is one of the principal types, it's derived from UserID and ServiceAccountID if empty.
+	PrincipalType string
+	// ServiceAccountID is set instead of UserID for tokens of service accounts.
+	ServiceAccountID int64
+	Roles            []string
+	// ClientID is the OAuth2 client the token is issued to, empty for tokens of the service itself.
+	ClientID  string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Extra are custom claims of access token such as email or tenant,
+	// they can not override the registered ones.
+	Extra map[string]interface{}
+}
+
+// TokenIntrospection is a token state by RFC 7662, claims are set for active tokens only.
+type TokenIntrospection struct {
+	Active bool
+	// TokenType is one of the token type hints
+	TokenType string
+	Claims    TokenClaims
+}
+
+// RefreshToken is a server-side record of an issued refresh token.
+// Tokens obtained by rotation share the family of the token issued on sign-in.
+type RefreshToken struct {
+	ID        string     `db:"id"`
+	FamilyID  string     `db:"family_id"`
+	UserID    int64      `db:"user_id"`
+	IssuedAt  time.Time  `db:"issued_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	// ClientID and Scopes are set for tokens issued to OAuth2 clients, they are kept on rotation.
+	ClientID string   `db:"client_id"`
+	Scopes   []string `db:"-"`
+}
+
+// TokenSet is issued on sign-in and refresh, Claims are claims of the access token.
+type TokenSet struct {
+	AccessToken  string
+	RefreshToken string
+	// RefreshTokenID is the id (jti) of RefreshToken, it's tracked server-side.
+	RefreshTokenID string
+	// IDToken is issued to OpenID Connect clients only.
+	IDToken string
+	Claims  TokenClaims
+}
+
+// HashToken returns SHA-256 hex digest of random high entropy token, such as authorization code,
+// only digests are stored, so leaked storage doesn't reveal usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
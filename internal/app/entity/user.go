@@ -1,10 +1,8 @@
 package entity
 
 import (
-	"errors"
 	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
+	"net/mail"
 )
 
 type User struct {
@@ -12,30 +10,33 @@ type User struct {
 	Name     string `db:"name"`
 	Email    string `db:"email"`
 	Password string `db:"password"`
+	// EmailVerified is set once the user follows the link from the verification email,
+	// it's reset when the email changes.
+	EmailVerified bool     `db:"email_verified"`
+	Roles         []string `db:"-"`
 }
 
 func (u *User) Validate() error {
 	if u.Name == "" {
-		return errors.New("empty name")
+		return fmt.Errorf("%w: empty name", ErrValidation)
 	}
 	if u.Email == "" {
-		return errors.New("empty email")
+		return fmt.Errorf("%w: empty email", ErrValidation)
+	}
+	if err := ValidateEmail(u.Email); err != nil {
+		return err
 	}
 	if u.Password == "" {
-		return errors.New("empty password")
+		return fmt.Errorf("%w: empty password", ErrValidation)
 	}
 	return nil
 }
 
-func (u *User) HashPassword() error {
-	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("unable to generate hash from password: %w", err)
+// ValidateEmail accepts a bare address such as 'user@example.com', without display name.
+func ValidateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return fmt.Errorf("%w: invalid email '%s'", ErrValidation, email)
 	}
-	u.Password = string(hashedPwd)
 	return nil
 }
-
-func (u *User) ComparePassword(pwd string) error {
-	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(pwd))
-}
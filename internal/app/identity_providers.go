@@ -0,0 +1,30 @@
+package app
+
+import (
+	"os"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/usecase"
+	"github.com/ziyadovea/task_manager/users/internal/authentication/oidc"
+	"github.com/ziyadovea/task_manager/users/internal/config"
+)
+
+// newIdentityProviders creates upstream OpenID Connect providers by their names,
+// client secrets are taken from env, public clients have none.
+func newIdentityProviders(cfg config.Config) map[string]usecase.IdentityProvider {
+	providers := make(map[string]usecase.IdentityProvider, len(cfg.IdentityProviders))
+	for _, providerCfg := range cfg.IdentityProviders {
+		var clientSecret string
+		if providerCfg.ClientSecretEnv != "" {
+			clientSecret = os.Getenv(providerCfg.ClientSecretEnv)
+		}
+
+		providers[providerCfg.Name] = oidc.NewProvider(oidc.ProviderConfig{
+			IssuerURL:    providerCfg.IssuerURL,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  providerCfg.RedirectURL,
+			Scopes:       providerCfg.Scopes,
+		})
+	}
+	return providers
+}
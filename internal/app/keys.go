@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/ziyadovea/task_manager/users/internal/authentication/jwt"
+	"github.com/ziyadovea/task_manager/users/internal/config"
+)
+
+// loadSigningKeys loads keys of the keyring, the active key is returned separately.
+func loadSigningKeys(cfg config.TokenSigningConfig) (jwt.Key, []jwt.Key, error) {
+	var (
+		active   jwt.Key
+		previous []jwt.Key
+	)
+	for _, keyCfg := range cfg.Keys {
+		var key jwt.Key
+		if keyCfg.Algorithm == jwt.HS256 {
+			secret, ok := os.LookupEnv(keyCfg.SecretEnv)
+			if !ok || secret == "" {
+				return jwt.Key{}, nil, fmt.Errorf("empty secret in env '%s' for signing key '%s'", keyCfg.SecretEnv, keyCfg.ID)
+			}
+			key = jwt.NewHMACKey(keyCfg.ID, []byte(secret))
+		} else {
+			var err error
+			key, err = jwt.LoadKey(keyCfg.ID, keyCfg.Algorithm, keyCfg.PrivateKeyPath)
+			if err != nil {
+				return jwt.Key{}, nil, fmt.Errorf("unable to load signing key '%s': %w", keyCfg.ID, err)
+			}
+		}
+
+		if keyCfg.ID == cfg.ActiveKeyID {
+			active = key
+		} else {
+			previous = append(previous, key)
+		}
+	}
+	return active, previous, nil
+}
+
+func newKeyring(cfg config.TokenSigningConfig) (*jwt.Keyring, error) {
+	active, previous, err := loadSigningKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.NewKeyring(active, previous...)
+}
+
+// keyRotator reloads signing keys from the config file into keyrings of the authenticator.
+type keyRotator struct {
+	mu          sync.Mutex
+	cfg         config.Config
+	accessKeys  *jwt.Keyring
+	refreshKeys *jwt.Keyring
+}
+
+// RotateSigningKeys re-reads the config and replaces keys in keyrings,
+// it returns id of the active access token key and ids of all access token keys.
+func (r *keyRotator) RotateSigningKeys(_ context.Context) (string, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.cfg.Reload()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to reload config: %w", err)
+	}
+
+	// load both keyrings before replacing any of them, so a broken config changes nothing
+	accessActive, accessPrevious, err := loadSigningKeys(cfg.AccessTokenSigning)
+	if err != nil {
+		return "", nil, err
+	}
+	refreshActive, refreshPrevious, err := loadSigningKeys(cfg.RefreshTokenSigning)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := r.accessKeys.Set(accessActive, accessPrevious...); err != nil {
+		return "", nil, fmt.Errorf("unable to set access token keys: %w", err)
+	}
+	if err := r.refreshKeys.Set(refreshActive, refreshPrevious...); err != nil {
+		return "", nil, fmt.Errorf("unable to set refresh token keys: %w", err)
+	}
+	r.cfg = cfg
+
+	keys := r.accessKeys.Keys()
+	keyIDs := make([]string, len(keys))
+	for i, key := range keys {
+		keyIDs[i] = key.ID
+	}
+
+	return accessActive.ID, keyIDs, nil
+}
+
+// rotateSigningKeysOnSIGHUP reloads signing keys each time the process receives SIGHUP.
+func rotateSigningKeysOnSIGHUP(ctx context.Context, rotator *keyRotator) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			activeKeyID, _, err := rotator.RotateSigningKeys(ctx)
+			if err != nil {
+				log.Printf("unable to rotate signing keys: %v", err)
+				continue
+			}
+			log.Printf("signing keys rotated, active access token key '%s'", activeKeyID)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/usecase"
+	"github.com/ziyadovea/task_manager/users/internal/config"
+	"github.com/ziyadovea/task_manager/users/internal/mail"
+)
+
+// newMailer returns the mailer of the configured type.
+func newMailer(cfg config.MailerConfig) (usecase.Mailer, error) {
+	switch cfg.Type {
+	case config.SMTPMailer:
+		var password string
+		if cfg.SMTP.PasswordEnv != "" {
+			password = os.Getenv(cfg.SMTP.PasswordEnv)
+		}
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: password,
+			From:     cfg.From,
+		}), nil
+	case config.FileMailer:
+		return mail.NewFileMailer(cfg.Dir, cfg.From)
+	case config.MemoryMailer:
+		return mail.NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer type '%s'", cfg.Type)
+	}
+}
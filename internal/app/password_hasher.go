@@ -0,0 +1,25 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/usecase"
+	"github.com/ziyadovea/task_manager/users/internal/config"
+	"github.com/ziyadovea/task_manager/users/internal/passwords"
+)
+
+// newPasswordHasher returns the hasher of the configured algorithm.
+func newPasswordHasher(cfg config.PasswordHashingConfig) (usecase.PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case config.Argon2idHashing:
+		return passwords.NewArgon2idHasher(passwords.Argon2idParams{
+			Memory:      cfg.Argon2id.Memory,
+			Iterations:  cfg.Argon2id.Iterations,
+			Parallelism: cfg.Argon2id.Parallelism,
+		})
+	case config.BcryptHashing:
+		return passwords.NewBcryptHasher(cfg.Bcrypt.Cost)
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm '%s'", cfg.Algorithm)
+	}
+}
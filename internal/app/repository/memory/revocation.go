@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// revocationStore is an in-memory token deny list with per-user watermarks.
+// It's not shared between replicas, use it for tests and single instance setups only.
+type revocationStore struct {
+	mu         sync.RWMutex
+	tokens     map[string]time.Time
+	watermarks map[int64]watermark
+}
+
+// watermark revokes tokens of the user issued before it, except the kept ones.
+type watermark struct {
+	before       time.Time
+	keptTokenIDs []string
+}
+
+func NewRevocationStore() *revocationStore {
+	return &revocationStore{
+		tokens:     make(map[string]time.Time),
+		watermarks: make(map[int64]watermark),
+	}
+}
+
+func (s *revocationStore) RevokeToken(_ context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (s *revocationStore) IsTokenRevoked(_ context.Context, tokenID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.tokens[tokenID]
+	return ok, nil
+}
+
+func (s *revocationStore) RevokeUserTokens(_ context.Context, userID int64, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !before.Before(s.watermarks[userID].before) {
+		s.watermarks[userID] = watermark{before: before}
+	}
+	return nil
+}
+
+func (s *revocationStore) KeepUserTokens(_ context.Context, userID int64, tokenIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watermarks[userID]
+	if !ok {
+		return nil
+	}
+	w.keptTokenIDs = append(w.keptTokenIDs[:len(w.keptTokenIDs):len(w.keptTokenIDs)], tokenIDs...)
+	s.watermarks[userID] = w
+	return nil
+}
+
+func (s *revocationStore) UserTokensRevokedBefore(_ context.Context, userID int64) (time.Time, []string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w := s.watermarks[userID]
+	return w.before, w.keptTokenIDs, nil
+}
+
+func (s *revocationStore) DeleteExpiredRevokedTokens(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	now := time.Now()
+	for id, expiresAt := range s.tokens {
+		if expiresAt.Before(now) {
+			delete(s.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
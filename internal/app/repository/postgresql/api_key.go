@@ -0,0 +1,142 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) apiKeyRepository {
+	return apiKeyRepository{db: db}
+}
+
+type apiKeyRow struct {
+	ID         string      `db:"id"`
+	UserID     int64       `db:"user_id"`
+	Name       string      `db:"name"`
+	KeyHash    string      `db:"key_hash"`
+	Scopes     stringArray `db:"scopes"`
+	CreatedAt  time.Time   `db:"created_at"`
+	ExpiresAt  *time.Time  `db:"expires_at"`
+	LastUsedAt *time.Time  `db:"last_used_at"`
+}
+
+func (r apiKeyRow) toEntity() entity.APIKey {
+	return entity.APIKey{
+		ID:         r.ID,
+		UserID:     r.UserID,
+		Name:       r.Name,
+		KeyHash:    r.KeyHash,
+		Scopes:     r.Scopes,
+		CreatedAt:  r.CreatedAt,
+		ExpiresAt:  r.ExpiresAt,
+		LastUsedAt: r.LastUsedAt,
+	}
+}
+
+// InsertAPIKey returns the inserted key with its creation time.
+func (r apiKeyRepository) InsertAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	const query = `
+		INSERT INTO api_keys (id, user_id, name, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+	err := r.db.GetContext(ctx, &key.CreatedAt, query,
+		key.ID, key.UserID, key.Name, key.KeyHash, textArrayParam(key.Scopes), key.ExpiresAt,
+	)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return key, nil
+}
+
+func (r apiKeyRepository) GetAPIKey(ctx context.Context, id string) (entity.APIKey, error) {
+	const query = `
+		SELECT
+			id "id",
+			user_id "user_id",
+			name "name",
+			key_hash "key_hash",
+			scopes "scopes",
+			created_at "created_at",
+			expires_at "expires_at",
+			last_used_at "last_used_at"
+		FROM
+			api_keys
+		WHERE
+			id = $1
+	`
+	var row apiKeyRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		return entity.APIKey{}, translateError(err)
+	}
+	return row.toEntity(), nil
+}
+
+func (r apiKeyRepository) ListUserAPIKeys(ctx context.Context, userID int64) ([]entity.APIKey, error) {
+	const query = `
+		SELECT
+			id "id",
+			user_id "user_id",
+			name "name",
+			key_hash "key_hash",
+			scopes "scopes",
+			created_at "created_at",
+			expires_at "expires_at",
+			last_used_at "last_used_at"
+		FROM
+			api_keys
+		WHERE
+			user_id = $1
+		ORDER BY
+			created_at
+	`
+	var rows []apiKeyRow
+	if err := r.db.SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, translateError(err)
+	}
+
+	keys := make([]entity.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = row.toEntity()
+	}
+	return keys, nil
+}
+
+// UpdateAPIKeyUsage records use of the key, at most once per interval to spare writes on every request.
+func (r apiKeyRepository) UpdateAPIKeyUsage(ctx context.Context, id string, interval time.Duration) (int64, error) {
+	const query = `
+		UPDATE
+			api_keys
+		SET
+			last_used_at = now()
+		WHERE
+			id = $1 AND (last_used_at IS NULL OR last_used_at <= now() - make_interval(secs => $2))
+	`
+	return r.exec(ctx, query, id, interval.Seconds())
+}
+
+func (r apiKeyRepository) RemoveAPIKey(ctx context.Context, userID int64, id string) (int64, error) {
+	return r.exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+}
+
+func (r apiKeyRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
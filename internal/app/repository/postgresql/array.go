@@ -0,0 +1,24 @@
+package postgresql
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// typeMap decodes PostgreSQL types not supported by database/sql, such as arrays.
+var typeMap = pgtype.NewMap()
+
+// stringArray scans text[] column.
+type stringArray []string
+
+func (a *stringArray) Scan(src any) error {
+	return typeMap.SQLScanner((*[]string)(a)).Scan(src)
+}
+
+// textArrayParam returns query parameter for NOT NULL text[] column,
+// nil slice would be encoded as NULL.
+func textArrayParam(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type authorizationCodeRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuthorizationCodeRepository(db *sqlx.DB) authorizationCodeRepository {
+	return authorizationCodeRepository{db: db}
+}
+
+type authorizationCodeRow struct {
+	entity.AuthorizationCode
+	Scopes stringArray `db:"scopes"`
+}
+
+func (r authorizationCodeRepository) InsertAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error {
+	const query = `
+		INSERT INTO authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, textArrayParam(code.Scopes), code.CodeChallenge, code.Nonce, code.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+// UseAuthorizationCode atomically removes the code, so it's exchanged at most once.
+// It returns entity.ErrNotFound if the code is unknown, already used or expired.
+func (r authorizationCodeRepository) UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error) {
+	const query = `
+		DELETE FROM
+			authorization_codes
+		WHERE
+			code_hash = $1
+		RETURNING
+			code_hash "code_hash",
+			client_id "client_id",
+			user_id "user_id",
+			redirect_uri "redirect_uri",
+			scopes "scopes",
+			code_challenge "code_challenge",
+			nonce "nonce",
+			expires_at "expires_at"
+	`
+	var row authorizationCodeRow
+	if err := r.db.GetContext(ctx, &row, query, codeHash); err != nil {
+		return entity.AuthorizationCode{}, translateError(err)
+	}
+
+	// expired codes are removed too, they are useless anyway
+	if !row.ExpiresAt.After(time.Now()) {
+		return entity.AuthorizationCode{}, fmt.Errorf("%w: authorization code is expired", entity.ErrNotFound)
+	}
+
+	code := row.AuthorizationCode
+	code.Scopes = row.Scopes
+	return code, nil
+}
+
+// DeleteExpiredAuthorizationCodes removes codes that were never exchanged.
+func (r authorizationCodeRepository) DeleteExpiredAuthorizationCodes(ctx context.Context) (int64, error) {
+	const query = `DELETE FROM authorization_codes WHERE expires_at <= now()`
+
+	res, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsDeleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsDeleted, nil
+}
@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type clientRepository struct {
+	db *sqlx.DB
+}
+
+func NewClientRepository(db *sqlx.DB) clientRepository {
+	return clientRepository{db: db}
+}
+
+type clientRow struct {
+	ID           string      `db:"id"`
+	Name         string      `db:"name"`
+	SecretHash   string      `db:"secret_hash"`
+	Public       bool        `db:"public"`
+	Scopes       stringArray `db:"scopes"`
+	RedirectURIs stringArray `db:"redirect_uris"`
+}
+
+func (r clientRow) toEntity() entity.Client {
+	return entity.Client{
+		ID:           r.ID,
+		Name:         r.Name,
+		Secret:       r.SecretHash,
+		Public:       r.Public,
+		Scopes:       r.Scopes,
+		RedirectURIs: r.RedirectURIs,
+	}
+}
+
+// InsertClient inserts client, its secret must already be hashed.
+func (r clientRepository) InsertClient(ctx context.Context, client entity.Client) error {
+	const query = `
+		INSERT INTO oauth2_clients (id, name, secret_hash, public, scopes, redirect_uris)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		client.ID, client.Name, client.Secret, client.Public, textArrayParam(client.Scopes), textArrayParam(client.RedirectURIs),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r clientRepository) GetClient(ctx context.Context, id string) (entity.Client, error) {
+	const query = `
+		SELECT
+			id "id",
+			name "name",
+			secret_hash "secret_hash",
+			public "public",
+			scopes "scopes",
+			redirect_uris "redirect_uris"
+		FROM
+			oauth2_clients
+		WHERE
+			id = $1
+	`
+	var row clientRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		return entity.Client{}, translateError(err)
+	}
+	return row.toEntity(), nil
+}
+
+// ListClients returns clients without secrets.
+func (r clientRepository) ListClients(ctx context.Context) ([]entity.Client, error) {
+	const query = `
+		SELECT
+			id "id",
+			name "name",
+			public "public",
+			scopes "scopes",
+			redirect_uris "redirect_uris"
+		FROM
+			oauth2_clients
+		ORDER BY
+			name
+	`
+	var rows []clientRow
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, translateError(err)
+	}
+
+	clients := make([]entity.Client, 0, len(rows))
+	for _, row := range rows {
+		clients = append(clients, row.toEntity())
+	}
+	return clients, nil
+}
+
+// UpdateClient updates client fields except the secret and the client type.
+func (r clientRepository) UpdateClient(ctx context.Context, client entity.Client) (int64, error) {
+	const query = `
+		UPDATE oauth2_clients
+		SET name = $2, scopes = $3, redirect_uris = $4
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		client.ID, client.Name, textArrayParam(client.Scopes), textArrayParam(client.RedirectURIs),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsUpdated, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsUpdated, nil
+}
+
+func (r clientRepository) UpdateClientSecret(ctx context.Context, id string, secretHash string) (int64, error) {
+	const query = `UPDATE oauth2_clients SET secret_hash = $2 WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id, secretHash)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsUpdated, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsUpdated, nil
+}
+
+func (r clientRepository) RemoveClient(ctx context.Context, id string) (int64, error) {
+	const query = `DELETE FROM oauth2_clients WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsRemoved, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsRemoved, nil
+}
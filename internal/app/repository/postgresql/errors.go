@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
+// translateError converts driver errors to domain errors,
+// other errors are returned as is.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", entity.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolationCode:
+			return fmt.Errorf("%w: constraint %s", entity.ErrAlreadyExists, pgErr.ConstraintName)
+		case foreignKeyViolationCode:
+			return fmt.Errorf("%w: constraint %s", entity.ErrNotFound, pgErr.ConstraintName)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type identityRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdentityRepository(db *sqlx.DB) identityRepository {
+	return identityRepository{db: db}
+}
+
+func (r identityRepository) InsertIdentity(ctx context.Context, identity entity.Identity) error {
+	const query = `INSERT INTO identities (provider, subject, user_id) VALUES ($1, $2, $3)`
+
+	if _, err := r.db.ExecContext(ctx, query, identity.Provider, identity.Subject, identity.UserID); err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r identityRepository) GetIdentity(ctx context.Context, provider, subject string) (entity.Identity, error) {
+	const query = `
+		SELECT
+			provider "provider",
+			subject "subject",
+			user_id "user_id",
+			created_at "created_at"
+		FROM
+			identities
+		WHERE
+			provider = $1 AND subject = $2
+	`
+	var identity entity.Identity
+	if err := r.db.GetContext(ctx, &identity, query, provider, subject); err != nil {
+		return entity.Identity{}, translateError(err)
+	}
+	return identity, nil
+}
@@ -0,0 +1,84 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type loginThrottleRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginThrottleRepository(db *sqlx.DB) loginThrottleRepository {
+	return loginThrottleRepository{db: db}
+}
+
+// GetLoginBlockedUntil returns the latest time sign-in is blocked until by any of the keys,
+// zero time if none of them is blocked.
+func (r loginThrottleRepository) GetLoginBlockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	const query = `SELECT max(blocked_until) FROM login_throttles WHERE key = ANY($1) AND blocked_until > now()`
+	var blockedUntil sql.NullTime
+	if err := r.db.GetContext(ctx, &blockedUntil, query, textArrayParam(keys)); err != nil {
+		return time.Time{}, translateError(err)
+	}
+	return blockedUntil.Time, nil
+}
+
+// RecordLoginFailure counts the failed attempt of the key and returns the count,
+// failures are counted again from one if the previous one is older than failureWindow.
+func (r loginThrottleRepository) RecordLoginFailure(ctx context.Context, key string, failureWindow time.Duration) (int, error) {
+	const query = `
+		INSERT INTO login_throttles (key, failures)
+		VALUES ($1, 1)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at <= now() - make_interval(secs => $2) THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = now()
+		RETURNING failures
+	`
+	var failures int
+	if err := r.db.GetContext(ctx, &failures, query, key, failureWindow.Seconds()); err != nil {
+		return 0, translateError(err)
+	}
+	return failures, nil
+}
+
+// BlockLogin blocks sign-in by the key until the given time, a longer block is kept.
+func (r loginThrottleRepository) BlockLogin(ctx context.Context, key string, until time.Time) (int64, error) {
+	const query = `UPDATE login_throttles SET blocked_until = GREATEST(blocked_until, $2) WHERE key = $1`
+	return r.exec(ctx, query, key, until)
+}
+
+// RemoveLoginFailures forgets failed attempts of the key and lifts its block.
+func (r loginThrottleRepository) RemoveLoginFailures(ctx context.Context, key string) (int64, error) {
+	const query = `DELETE FROM login_throttles WHERE key = $1`
+	return r.exec(ctx, query, key)
+}
+
+// DeleteExpiredLoginThrottles deletes keys neither blocked nor failed within failureWindow.
+func (r loginThrottleRepository) DeleteExpiredLoginThrottles(ctx context.Context, failureWindow time.Duration) (int64, error) {
+	const query = `
+		DELETE FROM login_throttles
+		WHERE blocked_until <= now() AND last_failure_at <= now() - make_interval(secs => $1)
+	`
+	return r.exec(ctx, query, failureWindow.Seconds())
+}
+
+func (r loginThrottleRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
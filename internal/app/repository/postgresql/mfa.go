@@ -0,0 +1,174 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type mfaRepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) mfaRepository {
+	return mfaRepository{db: db}
+}
+
+// UpsertTOTP stores a new unconfirmed secret of the user, replacing an unconfirmed one.
+// It returns entity.ErrAlreadyExists if the user has confirmed TOTP.
+func (r mfaRepository) UpsertTOTP(ctx context.Context, userID int64, secret string) error {
+	const query = `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret = EXCLUDED.secret, last_used_step = 0
+			WHERE user_totp.confirmed_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: totp is already confirmed", entity.ErrAlreadyExists)
+	}
+	return nil
+}
+
+func (r mfaRepository) GetTOTP(ctx context.Context, userID int64) (entity.TOTP, error) {
+	const query = `
+		SELECT
+			user_id "user_id",
+			secret "secret",
+			confirmed_at "confirmed_at",
+			last_used_step "last_used_step"
+		FROM
+			user_totp
+		WHERE
+			user_id = $1
+	`
+	var totp entity.TOTP
+	if err := r.db.GetContext(ctx, &totp, query, userID); err != nil {
+		return entity.TOTP{}, translateError(err)
+	}
+	return totp, nil
+}
+
+// UseTOTPStep records the step of the accepted code, it returns 0 if the step is already used,
+// so concurrent requests with the same code succeed at most once.
+func (r mfaRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) (int64, error) {
+	const query = `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+	return r.exec(ctx, query, userID, step)
+}
+
+// ConfirmTOTP enables TOTP of the user and replaces recovery codes in a single transaction.
+func (r mfaRepository) ConfirmTOTP(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const confirmQuery = `UPDATE user_totp SET confirmed_at = now() WHERE user_id = $1 AND confirmed_at IS NULL`
+	res, err := tx.ExecContext(ctx, confirmQuery, userID)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: totp is already confirmed", entity.ErrAlreadyExists)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		const insertQuery = `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, insertQuery, userID, codeHash); err != nil {
+			return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveTOTP removes TOTP and recovery codes of the user.
+func (r mfaRepository) RemoveTOTP(ctx context.Context, userID int64) (int64, error) {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return r.exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+}
+
+// UseRecoveryCode marks the code used, it returns 0 if the code is unknown or already used.
+func (r mfaRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (int64, error) {
+	const query = `UPDATE recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	return r.exec(ctx, query, userID, codeHash)
+}
+
+func (r mfaRepository) InsertMFAChallenge(ctx context.Context, challenge entity.MFAChallenge) error {
+	const query = `INSERT INTO mfa_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+
+	if _, err := r.db.ExecContext(ctx, query, challenge.TokenHash, challenge.UserID, challenge.ExpiresAt); err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+// AttemptMFAChallenge counts an attempt to complete the challenge and returns it.
+// It returns entity.ErrNotFound if the challenge is unknown, expired or out of attempts.
+func (r mfaRepository) AttemptMFAChallenge(ctx context.Context, tokenHash string, maxAttempts int) (entity.MFAChallenge, error) {
+	const query = `
+		UPDATE
+			mfa_challenges
+		SET
+			attempts = attempts + 1
+		WHERE
+			token_hash = $1 AND expires_at > now() AND attempts < $2
+		RETURNING
+			token_hash "token_hash",
+			user_id "user_id",
+			attempts "attempts",
+			expires_at "expires_at"
+	`
+	var challenge entity.MFAChallenge
+	if err := r.db.GetContext(ctx, &challenge, query, tokenHash, maxAttempts); err != nil {
+		return entity.MFAChallenge{}, translateError(err)
+	}
+	return challenge, nil
+}
+
+func (r mfaRepository) RemoveMFAChallenge(ctx context.Context, tokenHash string) (int64, error) {
+	return r.exec(ctx, `DELETE FROM mfa_challenges WHERE token_hash = $1`, tokenHash)
+}
+
+// DeleteExpiredMFAChallenges removes challenges that were never completed.
+func (r mfaRepository) DeleteExpiredMFAChallenges(ctx context.Context) (int64, error) {
+	return r.exec(ctx, `DELETE FROM mfa_challenges WHERE expires_at <= now()`)
+}
+
+func (r mfaRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
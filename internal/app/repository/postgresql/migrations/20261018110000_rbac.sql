@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles
+(
+    id BIGINT GENERATED ALWAYS AS IDENTITY,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (id),
+    UNIQUE (name)
+);
+
+CREATE TABLE permissions
+(
+    id BIGINT GENERATED ALWAYS AS IDENTITY,
+    name VARCHAR(100) NOT NULL,
+
+    PRIMARY KEY (id),
+    UNIQUE (name)
+);
+
+CREATE TABLE role_permissions
+(
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id BIGINT NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles
+(
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO permissions (name)
+VALUES ('users.read'),
+       ('users.list'),
+       ('users.update'),
+       ('users.delete'),
+       ('users.manage'),
+       ('roles.manage');
+
+INSERT INTO roles (name, description)
+VALUES ('user', 'Regular user, may access only own account'),
+       ('admin', 'Administrator, may access any account and manage roles');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         JOIN permissions p ON r.name = 'admin'
+    OR p.name IN ('users.read', 'users.list', 'users.update', 'users.delete');
+
+-- move roles from the users.role column, user_roles is the only source of roles from now on,
+-- values other than the predefined roles are kept as roles without permissions
+INSERT INTO roles (name)
+SELECT DISTINCT u.role
+FROM users u
+WHERE u.role NOT IN (SELECT name FROM roles);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+         JOIN roles r ON r.name = u.role OR r.name = 'user';
+
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+-- the column keeps a single role, admin is preferred to other roles granted besides user
+UPDATE users u
+SET role = COALESCE((SELECT r.name
+                     FROM user_roles ur
+                              JOIN roles r ON r.id = ur.role_id
+                     WHERE ur.user_id = u.id
+                       AND r.name <> 'user'
+                       AND length(r.name) <= 20
+                     ORDER BY r.name <> 'admin', r.name
+                     LIMIT 1), 'user');
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
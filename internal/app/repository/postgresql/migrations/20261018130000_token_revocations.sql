@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE revoked_tokens
+(
+    id VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (id)
+);
+
+-- no foreign key to users: watermark must outlive removed users
+CREATE TABLE user_token_watermarks
+(
+    user_id BIGINT NOT NULL,
+    revoked_before TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_token_watermarks;
+DROP TABLE IF EXISTS revoked_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO permissions (name) VALUES ('keys.manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'keys.manage';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'keys.manage';
+-- +goose StatementEnd
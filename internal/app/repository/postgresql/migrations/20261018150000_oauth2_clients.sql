@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE oauth2_clients
+(
+    id VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(100) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+
+    PRIMARY KEY (id)
+);
+
+INSERT INTO permissions (name) VALUES ('clients.manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'clients.manage';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'clients.manage';
+
+DROP TABLE IF EXISTS oauth2_clients;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE oauth2_clients ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE authorization_codes
+(
+    code_hash VARCHAR(64) NOT NULL,
+    client_id VARCHAR(64) NOT NULL REFERENCES oauth2_clients (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    code_challenge VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (code_hash)
+);
+
+ALTER TABLE refresh_tokens ADD COLUMN client_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN scopes;
+ALTER TABLE refresh_tokens DROP COLUMN client_id;
+
+DROP TABLE IF EXISTS authorization_codes;
+
+ALTER TABLE oauth2_clients DROP COLUMN public;
+-- +goose StatementEnd
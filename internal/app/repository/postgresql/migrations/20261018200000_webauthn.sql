@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webauthn_credentials
+(
+    id BYTEA NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL DEFAULT '',
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+    transports TEXT[] NOT NULL DEFAULT '{}',
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid BYTEA,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_sessions
+(
+    id_hash VARCHAR(64) NOT NULL,
+    user_id BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    ceremony VARCHAR(16) NOT NULL,
+    data BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (id_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webauthn_sessions;
+DROP TABLE IF EXISTS webauthn_credentials;
+-- +goose StatementEnd
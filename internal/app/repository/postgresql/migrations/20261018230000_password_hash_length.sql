@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- argon2id hashes in PHC format grow with their parameters
+ALTER TABLE users
+    ALTER COLUMN password TYPE VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    ALTER COLUMN password TYPE VARCHAR(100);
+-- +goose StatementEnd
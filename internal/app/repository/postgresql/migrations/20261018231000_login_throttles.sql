@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- failed sign-in attempts per account or client IP, the key is prefixed with its kind
+CREATE TABLE login_throttles
+(
+    key VARCHAR(255) NOT NULL,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    blocked_until TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (key)
+);
+
+INSERT INTO permissions (name) VALUES ('users.unlock');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users.unlock';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'users.unlock';
+
+DROP TABLE IF EXISTS login_throttles;
+-- +goose StatementEnd
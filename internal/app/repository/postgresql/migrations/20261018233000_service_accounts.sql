@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- service accounts share the users table, so they have ids and roles of their own,
+-- but no email and no password
+ALTER TABLE users ADD COLUMN principal_type VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE service_accounts
+(
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    owner_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    secret_hash VARCHAR(64),
+    public_key TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (user_id)
+);
+
+INSERT INTO permissions (name) VALUES ('service_accounts.manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'service_accounts.manage';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'service_accounts.manage';
+
+DROP TABLE IF EXISTS service_accounts;
+
+DELETE FROM users WHERE principal_type <> 'user';
+
+ALTER TABLE users DROP COLUMN IF EXISTS principal_type;
+-- +goose StatementEnd
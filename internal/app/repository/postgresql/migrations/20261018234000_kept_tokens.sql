@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tokens issued within the second of the watermark, right after the revocation, are kept by their ids
+ALTER TABLE user_token_watermarks ADD COLUMN kept_token_ids TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_token_watermarks DROP COLUMN IF EXISTS kept_token_ids;
+-- +goose StatementEnd
@@ -0,0 +1,91 @@
+-- +goose Up
+-- +goose StatementBegin
+-- service accounts move out of the users table to a table of their own, with their own ids and roles.
+-- Ids of moved service accounts are kept, new ones continue after them.
+ALTER TABLE service_accounts RENAME TO user_service_accounts;
+ALTER INDEX service_accounts_pkey RENAME TO user_service_accounts_pkey;
+
+CREATE TABLE service_accounts
+(
+    id BIGINT GENERATED ALWAYS AS IDENTITY,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    owner_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    secret_hash VARCHAR(64),
+    public_key TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (id),
+    UNIQUE (name)
+);
+
+CREATE TABLE service_account_roles
+(
+    service_account_id BIGINT NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+
+    PRIMARY KEY (service_account_id, role_id)
+);
+
+INSERT INTO service_accounts (id, name, description, owner_id, secret_hash, public_key, created_at)
+OVERRIDING SYSTEM VALUE
+SELECT u.id, COALESCE(u.name, 'service-account-' || u.id), usa.description, usa.owner_id, usa.secret_hash, usa.public_key, usa.created_at
+FROM user_service_accounts usa
+         JOIN users u ON u.id = usa.user_id;
+
+INSERT INTO service_account_roles (service_account_id, role_id)
+SELECT ur.user_id, ur.role_id
+FROM user_roles ur
+         JOIN user_service_accounts usa ON usa.user_id = ur.user_id;
+
+SELECT setval(pg_get_serial_sequence('service_accounts', 'id'), COALESCE(MAX(id), 0) + 1, false)
+FROM service_accounts;
+
+DROP TABLE user_service_accounts;
+
+DELETE FROM users WHERE principal_type <> 'user';
+
+ALTER TABLE users DROP COLUMN principal_type;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- service accounts get new user ids, names taken by users since then fail the migration
+ALTER TABLE users ADD COLUMN principal_type VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE user_service_accounts
+(
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    owner_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    secret_hash VARCHAR(64),
+    public_key TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT user_service_accounts_pkey PRIMARY KEY (user_id)
+);
+
+WITH inserted AS (
+    INSERT INTO users (name, principal_type)
+    SELECT name, 'service_account'
+    FROM service_accounts
+    RETURNING id, name
+)
+INSERT INTO user_service_accounts (user_id, owner_id, description, secret_hash, public_key, created_at)
+SELECT i.id, sa.owner_id, sa.description, sa.secret_hash, sa.public_key, sa.created_at
+FROM inserted i
+         JOIN service_accounts sa ON sa.name = i.name;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, sar.role_id
+FROM service_account_roles sar
+         JOIN service_accounts sa ON sa.id = sar.service_account_id
+         JOIN users u ON u.name = sa.name AND u.principal_type = 'service_account';
+
+DROP TABLE IF EXISTS service_account_roles;
+
+DROP TABLE IF EXISTS service_accounts;
+
+ALTER TABLE user_service_accounts RENAME TO service_accounts;
+ALTER INDEX user_service_accounts_pkey RENAME TO service_accounts_pkey;
+-- +goose StatementEnd
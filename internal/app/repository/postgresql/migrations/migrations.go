@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// SourceDir is the location of migration files relative to the repository root,
+// used when creating new migrations.
+const SourceDir = "internal/app/repository/postgresql/migrations"
+
+//go:embed *.sql
+var embedMigrations embed.FS
+
+func init() {
+	goose.SetBaseFS(embedMigrations)
+}
+
+// Up applies all pending migrations embedded in the binary.
+func Up(db *sql.DB) error {
+	if err := setDialect(); err != nil {
+		return err
+	}
+	if err := goose.Up(db, "."); err != nil {
+		return fmt.Errorf("unable to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the latest applied migration.
+func Down(db *sql.DB) error {
+	if err := setDialect(); err != nil {
+		return err
+	}
+	if err := goose.Down(db, "."); err != nil {
+		return fmt.Errorf("unable to roll back migration: %w", err)
+	}
+	return nil
+}
+
+// Status prints the status of all embedded migrations.
+func Status(db *sql.DB) error {
+	if err := setDialect(); err != nil {
+		return err
+	}
+	if err := goose.Status(db, "."); err != nil {
+		return fmt.Errorf("unable to get migrations status: %w", err)
+	}
+	return nil
+}
+
+// Create generates a new empty sql migration in dir.
+// The new file is picked up by the binary on the next build.
+func Create(dir, name string) error {
+	if err := goose.Create(nil, dir, name, "sql"); err != nil {
+		return fmt.Errorf("unable to create migration: %w", err)
+	}
+	return nil
+}
+
+func setDialect() error {
+	if err := goose.SetDialect("postgres"); err != nil {
+		return fmt.Errorf("unable to set migrations dialect: %w", err)
+	}
+	return nil
+}
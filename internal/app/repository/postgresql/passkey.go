@@ -0,0 +1,172 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type passkeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasskeyRepository(db *sqlx.DB) passkeyRepository {
+	return passkeyRepository{db: db}
+}
+
+type passkeyRow struct {
+	ID              []byte      `db:"id"`
+	UserID          int64       `db:"user_id"`
+	Name            string      `db:"name"`
+	PublicKey       []byte      `db:"public_key"`
+	AttestationType string      `db:"attestation_type"`
+	Transports      stringArray `db:"transports"`
+	SignCount       int64       `db:"sign_count"`
+	AAGUID          []byte      `db:"aaguid"`
+	BackupEligible  bool        `db:"backup_eligible"`
+	BackupState     bool        `db:"backup_state"`
+	CreatedAt       time.Time   `db:"created_at"`
+	LastUsedAt      *time.Time  `db:"last_used_at"`
+}
+
+func (r passkeyRow) toEntity() entity.Passkey {
+	return entity.Passkey{
+		ID:              r.ID,
+		UserID:          r.UserID,
+		Name:            r.Name,
+		PublicKey:       r.PublicKey,
+		AttestationType: r.AttestationType,
+		Transports:      r.Transports,
+		SignCount:       r.SignCount,
+		AAGUID:          r.AAGUID,
+		BackupEligible:  r.BackupEligible,
+		BackupState:     r.BackupState,
+		CreatedAt:       r.CreatedAt,
+		LastUsedAt:      r.LastUsedAt,
+	}
+}
+
+// InsertPasskey returns entity.ErrAlreadyExists if the credential is already registered.
+func (r passkeyRepository) InsertPasskey(ctx context.Context, passkey entity.Passkey) error {
+	const query = `
+		INSERT INTO webauthn_credentials (
+			id, user_id, name, public_key, attestation_type, transports, sign_count, aaguid, backup_eligible, backup_state
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		passkey.ID, passkey.UserID, passkey.Name, passkey.PublicKey, passkey.AttestationType, textArrayParam(passkey.Transports),
+		passkey.SignCount, passkey.AAGUID, passkey.BackupEligible, passkey.BackupState,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r passkeyRepository) ListUserPasskeys(ctx context.Context, userID int64) ([]entity.Passkey, error) {
+	const query = `
+		SELECT
+			id "id",
+			user_id "user_id",
+			name "name",
+			public_key "public_key",
+			attestation_type "attestation_type",
+			transports "transports",
+			sign_count "sign_count",
+			aaguid "aaguid",
+			backup_eligible "backup_eligible",
+			backup_state "backup_state",
+			created_at "created_at",
+			last_used_at "last_used_at"
+		FROM
+			webauthn_credentials
+		WHERE
+			user_id = $1
+		ORDER BY
+			created_at
+	`
+	var rows []passkeyRow
+	if err := r.db.SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, translateError(err)
+	}
+
+	passkeys := make([]entity.Passkey, len(rows))
+	for i, row := range rows {
+		passkeys[i] = row.toEntity()
+	}
+	return passkeys, nil
+}
+
+// UpdatePasskeyUsage records sign count and backup state of the passkey after login.
+func (r passkeyRepository) UpdatePasskeyUsage(ctx context.Context, passkey entity.Passkey) (int64, error) {
+	const query = `
+		UPDATE
+			webauthn_credentials
+		SET
+			sign_count = $3, backup_state = $4, last_used_at = now()
+		WHERE
+			id = $1 AND user_id = $2
+	`
+	return r.exec(ctx, query, passkey.ID, passkey.UserID, passkey.SignCount, passkey.BackupState)
+}
+
+func (r passkeyRepository) RemovePasskey(ctx context.Context, userID int64, id []byte) (int64, error) {
+	return r.exec(ctx, `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+}
+
+func (r passkeyRepository) InsertWebAuthnSession(ctx context.Context, session entity.WebAuthnSession) error {
+	const query = `
+		INSERT INTO webauthn_sessions (id_hash, user_id, ceremony, data, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query, session.IDHash, session.UserID, session.Ceremony, session.Data, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return nil
+}
+
+// UseWebAuthnSession removes the session of the ceremony and returns it, so every challenge is answered once.
+// It returns entity.ErrNotFound if the session is unknown, already used or expired.
+func (r passkeyRepository) UseWebAuthnSession(ctx context.Context, idHash, ceremony string) (entity.WebAuthnSession, error) {
+	const query = `
+		DELETE FROM
+			webauthn_sessions
+		WHERE
+			id_hash = $1 AND ceremony = $2 AND expires_at > now()
+		RETURNING
+			id_hash "id_hash",
+			user_id "user_id",
+			ceremony "ceremony",
+			data "data",
+			expires_at "expires_at"
+	`
+	var session entity.WebAuthnSession
+	if err := r.db.GetContext(ctx, &session, query, idHash, ceremony); err != nil {
+		return entity.WebAuthnSession{}, translateError(err)
+	}
+	return session, nil
+}
+
+// DeleteExpiredWebAuthnSessions removes sessions of ceremonies that were never finished.
+func (r passkeyRepository) DeleteExpiredWebAuthnSessions(ctx context.Context) (int64, error) {
+	return r.exec(ctx, `DELETE FROM webauthn_sessions WHERE expires_at <= now()`)
+}
+
+func (r passkeyRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,116 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type passwordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) passwordResetRepository {
+	return passwordResetRepository{db: db}
+}
+
+// InsertPasswordResetToken inserts the token unless another token of the user is created less than requestInterval ago,
+// it returns 0 then.
+func (r passwordResetRepository) InsertPasswordResetToken(
+	ctx context.Context,
+	token entity.PasswordResetToken,
+	requestInterval time.Duration,
+) (int64, error) {
+	const query = `
+		INSERT INTO password_reset_tokens (token_hash, user_id, expires_at)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM password_reset_tokens
+			WHERE user_id = $2 AND created_at > now() - make_interval(secs => $4)
+		)
+	`
+	res, err := r.db.ExecContext(ctx, query, token.TokenHash, token.UserID, token.ExpiresAt, requestInterval.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// GetPasswordResetToken returns the token unless it's expired, the token stays usable.
+func (r passwordResetRepository) GetPasswordResetToken(ctx context.Context, tokenHash string) (entity.PasswordResetToken, error) {
+	const query = `
+		SELECT
+			token_hash "token_hash",
+			user_id "user_id",
+			created_at "created_at",
+			expires_at "expires_at"
+		FROM
+			password_reset_tokens
+		WHERE
+			token_hash = $1 AND expires_at > now()
+	`
+	var token entity.PasswordResetToken
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		return entity.PasswordResetToken{}, translateError(err)
+	}
+	return token, nil
+}
+
+// UsePasswordResetToken atomically removes the token, so it resets the password at most once.
+// It returns entity.ErrNotFound if the token is unknown, already used or expired.
+func (r passwordResetRepository) UsePasswordResetToken(ctx context.Context, tokenHash string) (entity.PasswordResetToken, error) {
+	const query = `
+		DELETE FROM
+			password_reset_tokens
+		WHERE
+			token_hash = $1
+		RETURNING
+			token_hash "token_hash",
+			user_id "user_id",
+			created_at "created_at",
+			expires_at "expires_at"
+	`
+	var token entity.PasswordResetToken
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		return entity.PasswordResetToken{}, translateError(err)
+	}
+
+	// expired tokens are removed too, they are useless anyway
+	if !token.ExpiresAt.After(time.Now()) {
+		return entity.PasswordResetToken{}, fmt.Errorf("%w: password reset token is expired", entity.ErrNotFound)
+	}
+
+	return token, nil
+}
+
+// RemoveUserPasswordResetTokens removes outstanding tokens of the user once the password is reset.
+func (r passwordResetRepository) RemoveUserPasswordResetTokens(ctx context.Context, userID int64) (int64, error) {
+	return r.exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, userID)
+}
+
+// DeleteExpiredPasswordResetTokens removes tokens that were never used.
+func (r passwordResetRepository) DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error) {
+	return r.exec(ctx, `DELETE FROM password_reset_tokens WHERE expires_at <= now()`)
+}
+
+func (r passwordResetRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
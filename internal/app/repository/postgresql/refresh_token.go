@@ -0,0 +1,128 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type refreshTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewRefreshTokenRepository(db *sqlx.DB) refreshTokenRepository {
+	return refreshTokenRepository{db: db}
+}
+
+type refreshTokenRow struct {
+	entity.RefreshToken
+	Scopes stringArray `db:"scopes"`
+}
+
+func (r refreshTokenRow) toEntity() entity.RefreshToken {
+	token := r.RefreshToken
+	token.Scopes = r.Scopes
+	return token
+}
+
+func (r refreshTokenRepository) InsertRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	const query = `
+		INSERT INTO refresh_tokens (id, family_id, user_id, issued_at, expires_at, client_id, scopes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID, token.FamilyID, token.UserID, token.IssuedAt, token.ExpiresAt, token.ClientID, textArrayParam(token.Scopes),
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r refreshTokenRepository) GetRefreshToken(ctx context.Context, id string) (entity.RefreshToken, error) {
+	const query = `
+		SELECT
+			id "id",
+			family_id "family_id",
+			user_id "user_id",
+			issued_at "issued_at",
+			expires_at "expires_at",
+			used_at "used_at",
+			revoked_at "revoked_at",
+			client_id "client_id",
+			scopes "scopes"
+		FROM
+			refresh_tokens
+		WHERE
+			id = $1
+	`
+	var row refreshTokenRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		return entity.RefreshToken{}, translateError(err)
+	}
+	return row.toEntity(), nil
+}
+
+// UseRefreshToken atomically marks the token issued to the client as used, empty clientID stands for the service itself.
+// It returns entity.ErrNotFound if the token is unknown, issued to another client, already used, revoked or expired.
+func (r refreshTokenRepository) UseRefreshToken(ctx context.Context, id string, clientID string) (entity.RefreshToken, error) {
+	const query = `
+		UPDATE
+			refresh_tokens
+		SET
+			used_at = now()
+		WHERE
+			id = $1 AND client_id = $2 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+		RETURNING
+			id "id",
+			family_id "family_id",
+			user_id "user_id",
+			issued_at "issued_at",
+			expires_at "expires_at",
+			used_at "used_at",
+			revoked_at "revoked_at",
+			client_id "client_id",
+			scopes "scopes"
+	`
+	var row refreshTokenRow
+	if err := r.db.GetContext(ctx, &row, query, id, clientID); err != nil {
+		return entity.RefreshToken{}, translateError(err)
+	}
+	return row.toEntity(), nil
+}
+
+// RevokeRefreshTokenFamily revokes all not yet revoked tokens of the family.
+func (r refreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (int64, error) {
+	const query = `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsRevoked, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsRevoked, nil
+}
+
+// RevokeUserRefreshTokens revokes all not yet revoked tokens of the user.
+func (r refreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int64) (int64, error) {
+	const query = `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsRevoked, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsRevoked, nil
+}
@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// revocationRepository is a deny list of tokens, keyed by token id (jti),
+// plus per-user watermarks: all tokens of the user issued before the watermark are invalid,
+// except the tokens kept by their ids.
+type revocationRepository struct {
+	db *sqlx.DB
+}
+
+func NewRevocationRepository(db *sqlx.DB) revocationRepository {
+	return revocationRepository{db: db}
+}
+
+func (r revocationRepository) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	const query = `
+		INSERT INTO revoked_tokens (id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, query, tokenID, expiresAt); err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r revocationRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE id = $1)`
+	var revoked bool
+	if err := r.db.GetContext(ctx, &revoked, query, tokenID); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// RevokeUserTokens moves the user watermark forward, it never moves backward.
+// Tokens kept before are revoked too, unless the watermark is older than the current one.
+func (r revocationRepository) RevokeUserTokens(ctx context.Context, userID int64, before time.Time) error {
+	const query = `
+		INSERT INTO user_token_watermarks (user_id, revoked_before) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET revoked_before = GREATEST(user_token_watermarks.revoked_before, EXCLUDED.revoked_before),
+			kept_token_ids = CASE
+				WHEN EXCLUDED.revoked_before >= user_token_watermarks.revoked_before THEN '{}'
+				ELSE user_token_watermarks.kept_token_ids
+			END
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, before); err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+// KeepUserTokens exempts tokens issued before the current watermark from it.
+func (r revocationRepository) KeepUserTokens(ctx context.Context, userID int64, tokenIDs []string) error {
+	const query = `
+		UPDATE user_token_watermarks
+		SET kept_token_ids = kept_token_ids || $2::text[]
+		WHERE user_id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, textArrayParam(tokenIDs)); err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+// UserTokensRevokedBefore returns the user watermark with ids of the kept tokens, zero time if there is none.
+func (r revocationRepository) UserTokensRevokedBefore(ctx context.Context, userID int64) (time.Time, []string, error) {
+	const query = `SELECT revoked_before, kept_token_ids FROM user_token_watermarks WHERE user_id = $1`
+	var watermark struct {
+		RevokedBefore time.Time   `db:"revoked_before"`
+		KeptTokenIDs  stringArray `db:"kept_token_ids"`
+	}
+	if err := r.db.GetContext(ctx, &watermark, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil, nil
+		}
+		return time.Time{}, nil, err
+	}
+	return watermark.RevokedBefore, watermark.KeptTokenIDs, nil
+}
+
+// DeleteExpiredRevokedTokens removes deny list entries of tokens that are expired anyway.
+func (r revocationRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	const query = `DELETE FROM revoked_tokens WHERE expires_at < now()`
+
+	res, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsDeleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsDeleted, nil
+}
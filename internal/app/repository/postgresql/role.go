@@ -0,0 +1,141 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type roleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) roleRepository {
+	return roleRepository{db: db}
+}
+
+// InsertRole inserts role together with its permissions,
+// all permissions must already exist.
+func (r roleRepository) InsertRole(ctx context.Context, role entity.Role) (entity.Role, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return entity.Role{}, fmt.Errorf("unable to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertRoleQuery = `INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id`
+	if err := tx.QueryRowxContext(ctx, insertRoleQuery, role.Name, role.Description).Scan(&role.ID); err != nil {
+		return entity.Role{}, translateError(err)
+	}
+
+	if len(role.Permissions) > 0 {
+		const insertPermissionsQuery = `
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, id FROM permissions WHERE name = ANY($2)
+		`
+		res, err := tx.ExecContext(ctx, insertPermissionsQuery, role.ID, role.Permissions)
+		if err != nil {
+			return entity.Role{}, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+		}
+
+		rowsInserted, err := res.RowsAffected()
+		if err != nil {
+			return entity.Role{}, fmt.Errorf("unable to get affected rows: %w", err)
+		}
+		if rowsInserted != int64(len(role.Permissions)) {
+			return entity.Role{}, fmt.Errorf("%w: unknown permissions in %v", entity.ErrValidation, role.Permissions)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Role{}, fmt.Errorf("unable to commit tx: %w", err)
+	}
+
+	return role, nil
+}
+
+// InsertUserRole grants role to user.
+func (r roleRepository) InsertUserRole(ctx context.Context, userID int64, roleName string) error {
+	const query = `
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = $2
+	`
+
+	res, err := r.db.ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsInserted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	if rowsInserted == 0 {
+		return fmt.Errorf("%w: role %s", entity.ErrNotFound, roleName)
+	}
+
+	return nil
+}
+
+// RemoveUserRole revokes role from user.
+func (r roleRepository) RemoveUserRole(ctx context.Context, userID int64, roleName string) (int64, error) {
+	const query = `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id IN (SELECT id FROM roles WHERE name = $2)
+	`
+
+	res, err := r.db.ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsDeleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+
+	return rowsDeleted, nil
+}
+
+func (r roleRepository) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	const query = `
+		SELECT
+			r.name
+		FROM
+			user_roles ur
+			JOIN roles r ON r.id = ur.role_id
+		WHERE
+			ur.user_id = $1
+		ORDER BY
+			r.name
+	`
+	var roles []string
+	if err := r.db.SelectContext(ctx, &roles, query, userID); err != nil {
+		return nil, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return roles, nil
+}
+
+// ListRolesPermissions returns distinct permissions granted by given roles.
+func (r roleRepository) ListRolesPermissions(ctx context.Context, roles []string) ([]string, error) {
+	const query = `
+		SELECT DISTINCT
+			p.name
+		FROM
+			roles r
+			JOIN role_permissions rp ON rp.role_id = r.id
+			JOIN permissions p ON p.id = rp.permission_id
+		WHERE
+			r.name = ANY($1)
+		ORDER BY
+			p.name
+	`
+	var permissions []string
+	if err := r.db.SelectContext(ctx, &permissions, query, roles); err != nil {
+		return nil, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	return permissions, nil
+}
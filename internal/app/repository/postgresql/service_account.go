@@ -0,0 +1,153 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// serviceAccountRepository keeps service accounts apart from users, with their own ids and roles.
+type serviceAccountRepository struct {
+	db *sqlx.DB
+}
+
+func NewServiceAccountRepository(db *sqlx.DB) serviceAccountRepository {
+	return serviceAccountRepository{db: db}
+}
+
+type serviceAccountRow struct {
+	ID          int64       `db:"id"`
+	Name        string      `db:"name"`
+	Description string      `db:"description"`
+	OwnerID     int64       `db:"owner_id"`
+	SecretHash  string      `db:"secret_hash"`
+	PublicKey   string      `db:"public_key"`
+	Roles       stringArray `db:"roles"`
+	CreatedAt   time.Time   `db:"created_at"`
+}
+
+func (r serviceAccountRow) toEntity() entity.ServiceAccount {
+	return entity.ServiceAccount{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		OwnerID:     r.OwnerID,
+		SecretHash:  r.SecretHash,
+		PublicKey:   r.PublicKey,
+		Roles:       r.Roles,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+const selectServiceAccounts = `
+	SELECT
+		sa.id "id",
+		sa.name "name",
+		sa.description "description",
+		COALESCE(sa.owner_id, 0) "owner_id",
+		COALESCE(sa.secret_hash, '') "secret_hash",
+		COALESCE(sa.public_key, '') "public_key",
+		ARRAY(
+			SELECT r.name FROM service_account_roles sar JOIN roles r ON r.id = sar.role_id
+			WHERE sar.service_account_id = sa.id ORDER BY r.name
+		) "roles",
+		sa.created_at "created_at"
+	FROM
+		service_accounts sa
+`
+
+// InsertServiceAccount returns the inserted account with its id and creation time,
+// unlike users it's granted no default role.
+func (r serviceAccountRepository) InsertServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, error) {
+	const query = `
+		INSERT INTO service_accounts (name, owner_id, description, secret_hash, public_key)
+		VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, ''), NULLIF($5, ''))
+		RETURNING id, created_at
+	`
+	row := r.db.QueryRowxContext(ctx, query,
+		account.Name, account.OwnerID, account.Description, account.SecretHash, account.PublicKey,
+	)
+	if err := row.Scan(&account.ID, &account.CreatedAt); err != nil {
+		return entity.ServiceAccount{}, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+	account.Roles = nil
+	return account, nil
+}
+
+func (r serviceAccountRepository) GetServiceAccount(ctx context.Context, id int64) (entity.ServiceAccount, error) {
+	var row serviceAccountRow
+	if err := r.db.GetContext(ctx, &row, selectServiceAccounts+` WHERE sa.id = $1`, id); err != nil {
+		return entity.ServiceAccount{}, translateError(err)
+	}
+	return row.toEntity(), nil
+}
+
+func (r serviceAccountRepository) ListServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error) {
+	var rows []serviceAccountRow
+	if err := r.db.SelectContext(ctx, &rows, selectServiceAccounts+` ORDER BY sa.id`); err != nil {
+		return nil, translateError(err)
+	}
+
+	accounts := make([]entity.ServiceAccount, len(rows))
+	for i, row := range rows {
+		accounts[i] = row.toEntity()
+	}
+	return accounts, nil
+}
+
+// UpdateServiceAccount sets the description and the public key, an empty key removes it.
+func (r serviceAccountRepository) UpdateServiceAccount(ctx context.Context, account entity.ServiceAccount) (int64, error) {
+	const query = `UPDATE service_accounts SET description = $2, public_key = NULLIF($3, '') WHERE id = $1`
+	return r.exec(ctx, query, account.ID, account.Description, account.PublicKey)
+}
+
+func (r serviceAccountRepository) UpdateServiceAccountSecret(ctx context.Context, id int64, secretHash string) (int64, error) {
+	return r.exec(ctx, `UPDATE service_accounts SET secret_hash = $2 WHERE id = $1`, id, secretHash)
+}
+
+// RemoveServiceAccount removes the account with its roles and credentials.
+func (r serviceAccountRepository) RemoveServiceAccount(ctx context.Context, id int64) (int64, error) {
+	return r.exec(ctx, `DELETE FROM service_accounts WHERE id = $1`, id)
+}
+
+// InsertServiceAccountRole grants role to the account.
+func (r serviceAccountRepository) InsertServiceAccountRole(ctx context.Context, id int64, roleName string) error {
+	const query = `
+		INSERT INTO service_account_roles (service_account_id, role_id)
+		SELECT $1, id FROM roles WHERE name = $2
+	`
+	rowsInserted, err := r.exec(ctx, query, id, roleName)
+	if err != nil {
+		return err
+	}
+	if rowsInserted == 0 {
+		return fmt.Errorf("%w: role %s", entity.ErrNotFound, roleName)
+	}
+	return nil
+}
+
+// RemoveServiceAccountRole revokes role from the account.
+func (r serviceAccountRepository) RemoveServiceAccountRole(ctx context.Context, id int64, roleName string) (int64, error) {
+	const query = `
+		DELETE FROM service_account_roles
+		WHERE service_account_id = $1 AND role_id IN (SELECT id FROM roles WHERE name = $2)
+	`
+	return r.exec(ctx, query, id, roleName)
+}
+
+func (r serviceAccountRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -18,11 +19,21 @@ func NewUserRepository(db *sqlx.DB) userRepository {
 	return userRepository{db: db}
 }
 
+// InsertUser inserts user and grants it the default role.
 func (r userRepository) InsertUser(ctx context.Context, user entity.User) (entity.User, error) {
-	const query = `INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id`
-	if err := r.db.QueryRowxContext(ctx, query, user.Name, user.Email, user.Password).Scan(&user.ID); err != nil {
-		return entity.User{}, err
+	const query = `
+		WITH inserted AS (
+			INSERT INTO users (name, email, password, email_verified) VALUES ($1, $2, $3, $5) RETURNING id
+		), granted AS (
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT inserted.id, roles.id FROM inserted, roles WHERE roles.name = $4
+		)
+		SELECT id FROM inserted
+	`
+	if err := r.db.QueryRowxContext(ctx, query, user.Name, user.Email, user.Password, entity.DefaultRole, user.EmailVerified).Scan(&user.ID); err != nil {
+		return entity.User{}, translateError(err)
 	}
+	user.Roles = []string{entity.DefaultRole}
 	return user, nil
 }
 
@@ -32,7 +43,8 @@ func (r userRepository) GetUserByID(ctx context.Context, id int64) (entity.User,
 			id "id",
 			name "name",
 			email "email",
-			password "password"
+			password "password",
+			email_verified "email_verified"
 		FROM
 			users
 		WHERE
@@ -40,7 +52,7 @@ func (r userRepository) GetUserByID(ctx context.Context, id int64) (entity.User,
 	`
 	var user entity.User
 	if err := r.db.GetContext(ctx, &user, query, id); err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err)
 	}
 	return user, nil
 }
@@ -51,7 +63,8 @@ func (r userRepository) GetUserByName(ctx context.Context, name string) (entity.
 			id "id",
 			name "name",
 			email "email",
-			password "password"
+			password "password",
+			email_verified "email_verified"
 		FROM
 			users
 		WHERE
@@ -59,7 +72,7 @@ func (r userRepository) GetUserByName(ctx context.Context, name string) (entity.
 	`
 	var user entity.User
 	if err := r.db.GetContext(ctx, &user, query, name); err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err)
 	}
 	return user, nil
 }
@@ -70,7 +83,8 @@ func (r userRepository) GetUserByEmail(ctx context.Context, email string) (entit
 			id "id",
 			name "name",
 			email "email",
-			password "password"
+			password "password",
+			email_verified "email_verified"
 		FROM
 			users
 		WHERE
@@ -78,7 +92,7 @@ func (r userRepository) GetUserByEmail(ctx context.Context, email string) (entit
 	`
 	var user entity.User
 	if err := r.db.GetContext(ctx, &user, query, email); err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err)
 	}
 	return user, nil
 }
@@ -89,13 +103,14 @@ func (r userRepository) ListUsers(ctx context.Context) ([]entity.User, error) {
 		    id "id",
 			name "name",
 			email "email",
-			password "password"
+			password "password",
+			email_verified "email_verified"
 		FROM
 			users
 	`
 	var users []entity.User
 	if err := r.db.SelectContext(ctx, &users, query); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to exec sql query: %w", translateError(err))
 	}
 	return users, nil
 }
@@ -106,6 +121,8 @@ func (r userRepository) UpdateUserByID(ctx context.Context, user entity.User) (i
 		ub = ub.Set("name", user.Name)
 	}
 	if user.Email != "" {
+		// the new email must be verified again, the old value is compared here
+		ub = ub.Set("email_verified", sq.Expr("email_verified AND email = ?", user.Email))
 		ub = ub.Set("email", user.Email)
 	}
 	if user.Password != "" {
@@ -119,7 +136,7 @@ func (r userRepository) UpdateUserByID(ctx context.Context, user entity.User) (i
 
 	res, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("unable to exec sql query: %w", err)
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
 	}
 
 	rowsUpdated, err := res.RowsAffected()
@@ -130,17 +147,57 @@ func (r userRepository) UpdateUserByID(ctx context.Context, user entity.User) (i
 	return rowsUpdated, nil
 }
 
+// MarkEmailVerificationSent records sending of the verification email to the user with unverified email.
+// It returns 0 if the email is verified or the previous email is sent less than resendInterval ago.
+func (r userRepository) MarkEmailVerificationSent(ctx context.Context, id int64, resendInterval time.Duration) (int64, error) {
+	const query = `
+		UPDATE
+			users
+		SET
+			email_verification_sent_at = now()
+		WHERE
+			id = $1 AND NOT email_verified
+			AND (email_verification_sent_at IS NULL OR email_verification_sent_at <= now() - make_interval(secs => $2))
+	`
+	return r.exec(ctx, query, id, resendInterval.Seconds())
+}
+
+// VerifyUserEmail marks the email verified, it returns 0 if the user has another email or it's already verified.
+func (r userRepository) VerifyUserEmail(ctx context.Context, id int64, email string) (int64, error) {
+	const query = `UPDATE users SET email_verified = TRUE WHERE id = $1 AND email = $2 AND NOT email_verified`
+	return r.exec(ctx, query, id, email)
+}
+
+// ReplaceUserPasswordHash sets a new hash of the same password, it returns 0 if the stored hash isn't oldHash anymore.
+func (r userRepository) ReplaceUserPasswordHash(ctx context.Context, id int64, oldHash, newHash string) (int64, error) {
+	const query = `UPDATE users SET password = $3 WHERE id = $1 AND password = $2`
+	return r.exec(ctx, query, id, oldHash, newHash)
+}
+
+func (r userRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
 func (r userRepository) RemoveUserByID(ctx context.Context, id int64) (int64, error) {
 	const query = `DELETE FROM users where id = $1`
 
 	res, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("unable to exec sql query: %w", translateError(err))
 	}
 
 	rowsDeleted, err := res.RowsAffected()
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("unable to get affected rows: %w", err)
 	}
 
 	return rowsDeleted, nil
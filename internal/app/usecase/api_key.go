@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	apiKeyIDLength     = 6
+	apiKeySecretLength = 32
+	// apiKeyUsageInterval is how often the last use of the key is recorded.
+	apiKeyUsageInterval = time.Minute
+)
+
+// apiKeyUsecase manages API keys of users and authenticates callers with them.
+type apiKeyUsecase struct {
+	repo     APIKeyRepository
+	roleRepo RoleRepository
+}
+
+func NewAPIKeyUsecase(repo APIKeyRepository, roleRepo RoleRepository) apiKeyUsecase {
+	return apiKeyUsecase{
+		repo:     repo,
+		roleRepo: roleRepo,
+	}
+}
+
+// CreateAPIKey creates the key of the caller, it's returned once and only its hash is stored.
+// Scopes must be permissions the caller has, keys can't create other keys.
+func (u apiKeyUsecase) CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, string, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	principal, _ := entity.PrincipalFromContext(ctx)
+	if principal.APIKeyID != "" {
+		return entity.APIKey{}, "", fmt.Errorf("%w: api keys can't create api keys", entity.ErrPermissionDenied)
+	}
+
+	if err := key.Validate(); err != nil {
+		return entity.APIKey{}, "", err
+	}
+	for _, scope := range key.Scopes {
+		if !principal.HasPermission(scope) {
+			return entity.APIKey{}, "", fmt.Errorf("%w: missing permission %s of the scope", entity.ErrPermissionDenied, scope)
+		}
+	}
+	if key.Expired(time.Now()) {
+		return entity.APIKey{}, "", fmt.Errorf("%w: api key expiration is in the past", entity.ErrValidation)
+	}
+
+	id, err := newRandomString(apiKeyIDLength)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	secret, err := newRandomString(apiKeySecretLength)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	rawKey := entity.FormatAPIKey(id, secret)
+
+	key.ID = id
+	key.UserID = userID
+	key.KeyHash = entity.HashToken(rawKey)
+	createdKey, err := u.repo.InsertAPIKey(ctx, key)
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("unable to insert api key in repo: %w", err)
+	}
+
+	return createdKey, rawKey, nil
+}
+
+// ListAPIKeys returns keys of the caller.
+func (u apiKeyUsecase) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := u.repo.ListUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list api keys from repo: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey removes the key of the caller, requests with it are rejected right away.
+func (u apiKeyUsecase) RevokeAPIKey(ctx context.Context, id string) (int64, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount, err := u.repo.RemoveAPIKey(ctx, userID, id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove api key in repo: %w", err)
+	}
+	return removedCount, nil
+}
+
+// AuthenticateAPIKey returns the principal of the key owner,
+// its permissions are the current permissions of the user limited to the key scopes.
+func (u apiKeyUsecase) AuthenticateAPIKey(ctx context.Context, rawKey string) (entity.Principal, error) {
+	id, ok := entity.ParseAPIKey(rawKey)
+	if !ok {
+		return entity.Principal{}, fmt.Errorf("%w: malformed api key", entity.ErrInvalidCredentials)
+	}
+
+	key, err := u.repo.GetAPIKey(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.Principal{}, fmt.Errorf("%w: unknown api key", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to get api key from repo: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(entity.HashToken(rawKey)), []byte(key.KeyHash)) != 1 {
+		return entity.Principal{}, fmt.Errorf("%w: unknown api key", entity.ErrInvalidCredentials)
+	}
+	if key.Expired(time.Now()) {
+		return entity.Principal{}, fmt.Errorf("%w: api key is expired", entity.ErrInvalidCredentials)
+	}
+
+	roles, err := u.roleRepo.ListUserRoles(ctx, key.UserID)
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to list user roles from repo: %w", err)
+	}
+	var permissions []string
+	if len(roles) > 0 {
+		rolesPermissions, err := u.roleRepo.ListRolesPermissions(ctx, roles)
+		if err != nil {
+			return entity.Principal{}, fmt.Errorf("unable to list roles permissions from repo: %w", err)
+		}
+		for _, permission := range rolesPermissions {
+			if slices.Contains(key.Scopes, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	if _, err := u.repo.UpdateAPIKeyUsage(ctx, key.ID, apiKeyUsageInterval); err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to update api key usage in repo: %w", err)
+	}
+
+	return entity.Principal{
+		UserID:        key.UserID,
+		PrincipalType: entity.PrincipalTypeUser,
+		Roles:         roles,
+		Permissions:   permissions,
+		APIKeyID:      key.ID,
+	}, nil
+}
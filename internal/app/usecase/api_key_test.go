@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// apiKeyStore looks keys up by id, other methods of the repository are not expected to be called.
+type apiKeyStore struct {
+	APIKeyRepository
+
+	keys map[string]entity.APIKey
+}
+
+func (s apiKeyStore) GetAPIKey(_ context.Context, id string) (entity.APIKey, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return entity.APIKey{}, entity.ErrNotFound
+	}
+	return key, nil
+}
+
+func (s apiKeyStore) UpdateAPIKeyUsage(context.Context, string, time.Duration) (int64, error) {
+	return 1, nil
+}
+
+// roleStore grants roles of users and permissions of roles, other methods of the repository are not expected to be called.
+type roleStore struct {
+	RoleRepository
+
+	userRoles       map[int64][]string
+	rolePermissions map[string][]string
+}
+
+func (s roleStore) ListUserRoles(_ context.Context, userID int64) ([]string, error) {
+	return s.userRoles[userID], nil
+}
+
+func (s roleStore) ListRolesPermissions(_ context.Context, roles []string) ([]string, error) {
+	var permissions []string
+	for _, role := range roles {
+		for _, permission := range s.rolePermissions[role] {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	const rawKey = entity.APIKeyPrefix + "key_secret"
+	expiredAt := time.Now().Add(-time.Minute)
+	roles := roleStore{
+		userRoles: map[int64][]string{
+			1: {"admin"},
+			2: {"viewer"},
+		},
+		rolePermissions: map[string][]string{
+			"admin":  {entity.PermissionUsersRead, entity.PermissionUsersUpdate, entity.PermissionRolesManage},
+			"viewer": {entity.PermissionUsersRead},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		rawKey          string
+		key             entity.APIKey
+		wantPermissions []string
+		wantErr         error
+	}{
+		{
+			name:            "scopes the user has",
+			rawKey:          rawKey,
+			key:             entity.APIKey{UserID: 1, Scopes: []string{entity.PermissionUsersRead, entity.PermissionUsersUpdate}},
+			wantPermissions: []string{entity.PermissionUsersRead, entity.PermissionUsersUpdate},
+		},
+		{
+			name:            "scopes the user has lost",
+			rawKey:          rawKey,
+			key:             entity.APIKey{UserID: 2, Scopes: []string{entity.PermissionUsersRead, entity.PermissionUsersUpdate}},
+			wantPermissions: []string{entity.PermissionUsersRead},
+		},
+		{
+			name:   "user without roles",
+			rawKey: rawKey,
+			key:    entity.APIKey{UserID: 3, Scopes: []string{entity.PermissionUsersRead}},
+		},
+		{
+			name:    "expired key",
+			rawKey:  rawKey,
+			key:     entity.APIKey{UserID: 1, Scopes: []string{entity.PermissionUsersRead}, ExpiresAt: &expiredAt},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong secret",
+			rawKey:  entity.APIKeyPrefix + "key_other",
+			key:     entity.APIKey{UserID: 1, Scopes: []string{entity.PermissionUsersRead}},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown key",
+			rawKey:  entity.APIKeyPrefix + "other_secret",
+			key:     entity.APIKey{UserID: 1, Scopes: []string{entity.PermissionUsersRead}},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "malformed key",
+			rawKey:  "key_secret",
+			key:     entity.APIKey{UserID: 1, Scopes: []string{entity.PermissionUsersRead}},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.key
+			key.ID, key.KeyHash = "key", entity.HashToken(rawKey)
+			u := NewAPIKeyUsecase(apiKeyStore{keys: map[string]entity.APIKey{key.ID: key}}, roles)
+
+			principal, err := u.AuthenticateAPIKey(context.Background(), tt.rawKey)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if principal.UserID != key.UserID || principal.APIKeyID != key.ID {
+				t.Errorf("got principal of user %d and key %q, want user %d and key %q", principal.UserID, principal.APIKeyID, key.UserID, key.ID)
+			}
+			if !slices.Equal(principal.Permissions, tt.wantPermissions) {
+				t.Errorf("got permissions %v, want %v", principal.Permissions, tt.wantPermissions)
+			}
+		})
+	}
+}
@@ -1,8 +1,29 @@
 package usecase
 
+import (
+	"context"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// Authenticator verify methods return errors wrapping entity.ErrInvalidCredentials for invalid tokens,
+// other errors mean the token could not be checked.
 type Authenticator interface {
-	CreateAccessToken(userID int64) (accessToken string, err error)
-	CreateRefreshToken(userID int64) (refreshToken string, err error)
-	VerifyAccessToken(token string) (userID int64, err error)
-	VerifyRefreshToken(token string) (userID int64, err error)
+	CreateAccessToken(claims entity.TokenClaims) (accessToken string, issuedClaims entity.TokenClaims, err error)
+	CreateIDToken(claims entity.IDTokenClaims) (idToken string, err error)
+	CreateRefreshToken(userID int64) (refreshToken string, claims entity.TokenClaims, err error)
+	VerifyAccessToken(ctx context.Context, token string) (claims entity.TokenClaims, err error)
+	VerifyRefreshToken(ctx context.Context, token string) (claims entity.TokenClaims, err error)
+	CreateEmailVerificationToken(claims entity.EmailVerificationClaims) (token string, issuedClaims entity.EmailVerificationClaims, err error)
+	VerifyEmailVerificationToken(ctx context.Context, token string) (claims entity.EmailVerificationClaims, err error)
+}
+
+type SigningKeyRotator interface {
+	RotateSigningKeys(ctx context.Context) (activeKeyID string, keyIDs []string, err error)
+}
+
+// AssertionVerifier verifies assertions service accounts sign with their own key pairs.
+type AssertionVerifier interface {
+	ValidatePublicKey(publicKey string) error
+	VerifyAssertion(ctx context.Context, assertion string, account entity.ServiceAccount) (claims entity.TokenClaims, err error)
 }
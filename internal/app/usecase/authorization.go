@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// authorizeUserAccess checks that the principal from ctx may access user with targetID:
+// users may access only themselves, principals with users.manage permission may access anyone.
+func authorizeUserAccess(ctx context.Context, targetID int64) error {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no authenticated principal", entity.ErrInvalidCredentials)
+	}
+
+	if principal.UserID == targetID || principal.HasPermission(entity.PermissionUsersManage) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user %d can't access user %d", entity.ErrPermissionDenied, principal.UserID, targetID)
+}
+
+// requirePermission checks that the principal from ctx has the permission.
+func requirePermission(ctx context.Context, permission string) error {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no authenticated principal", entity.ErrInvalidCredentials)
+	}
+
+	if !principal.HasPermission(permission) {
+		return fmt.Errorf("%w: missing permission %s", entity.ErrPermissionDenied, permission)
+	}
+
+	return nil
+}
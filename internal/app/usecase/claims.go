@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// ClaimsHook adds extra claims to the access token of claims.UserID.
+// Hooks run on every access token issue, including refresh.
+type ClaimsHook func(ctx context.Context, claims *entity.TokenClaims) error
+
+// EmailClaimsHook puts email of the user to the "email" claim.
+func EmailClaimsHook(repo UserRepository) ClaimsHook {
+	return func(ctx context.Context, claims *entity.TokenClaims) error {
+		user, err := repo.GetUserByID(ctx, claims.UserID)
+		if err != nil {
+			return fmt.Errorf("unable to get user by id from repo: %w", err)
+		}
+		setExtraClaim(claims, "email", user.Email)
+		return nil
+	}
+}
+
+func setExtraClaim(claims *entity.TokenClaims, name string, value interface{}) {
+	if claims.Extra == nil {
+		claims.Extra = make(map[string]interface{})
+	}
+	claims.Extra[name] = value
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	clientIDLength     = 16
+	clientSecretLength = 32
+)
+
+// clientUsecase manages the OAuth2 client registry.
+type clientUsecase struct {
+	repo ClientRepository
+}
+
+func NewClientUsecase(repo ClientRepository) clientUsecase {
+	return clientUsecase{
+		repo: repo,
+	}
+}
+
+// CreateClient registers the client with generated id and secret.
+// The secret is stored hashed, so it's returned only here. Public clients get no secret.
+func (u clientUsecase) CreateClient(ctx context.Context, client entity.Client) (entity.Client, string, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return entity.Client{}, "", err
+	}
+
+	if err := client.Validate(); err != nil {
+		return entity.Client{}, "", fmt.Errorf("invalid client: %w", err)
+	}
+
+	var err error
+	client.ID, err = newRandomString(clientIDLength)
+	if err != nil {
+		return entity.Client{}, "", err
+	}
+
+	var secret string
+	client.Secret = ""
+	if !client.Public {
+		secret, err = newRandomString(clientSecretLength)
+		if err != nil {
+			return entity.Client{}, "", err
+		}
+
+		client.Secret = secret
+		if err := client.HashSecret(); err != nil {
+			return entity.Client{}, "", fmt.Errorf("unable to hash secret: %w", err)
+		}
+	}
+
+	if err := u.repo.InsertClient(ctx, client); err != nil {
+		return entity.Client{}, "", fmt.Errorf("unable to insert client in repo: %w", err)
+	}
+
+	return client, secret, nil
+}
+
+func (u clientUsecase) GetClient(ctx context.Context, id string) (entity.Client, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return entity.Client{}, err
+	}
+
+	client, err := u.repo.GetClient(ctx, id)
+	if err != nil {
+		return entity.Client{}, fmt.Errorf("unable to get client from repo: %w", err)
+	}
+
+	return client, nil
+}
+
+func (u clientUsecase) ListClients(ctx context.Context) ([]entity.Client, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return nil, err
+	}
+	return u.repo.ListClients(ctx)
+}
+
+// UpdateClient updates name, scopes and redirect URIs of the client.
+// Tokens issued before keep their scopes until expiration.
+func (u clientUsecase) UpdateClient(ctx context.Context, client entity.Client) (entity.Client, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return entity.Client{}, err
+	}
+
+	if err := client.Validate(); err != nil {
+		return entity.Client{}, fmt.Errorf("invalid client: %w", err)
+	}
+
+	updatedCount, err := u.repo.UpdateClient(ctx, client)
+	if err != nil {
+		return entity.Client{}, fmt.Errorf("unable to update client in repo: %w", err)
+	}
+	if updatedCount == 0 {
+		return entity.Client{}, fmt.Errorf("%w: client '%s'", entity.ErrNotFound, client.ID)
+	}
+
+	return client, nil
+}
+
+// RotateClientSecret replaces the client secret with a new generated one.
+func (u clientUsecase) RotateClientSecret(ctx context.Context, id string) (string, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return "", err
+	}
+
+	stored, err := u.repo.GetClient(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("unable to get client from repo: %w", err)
+	}
+	if stored.Public {
+		return "", fmt.Errorf("%w: public client '%s' has no secret", entity.ErrValidation, id)
+	}
+
+	secret, err := newRandomString(clientSecretLength)
+	if err != nil {
+		return "", err
+	}
+
+	client := entity.Client{ID: id, Secret: secret}
+	if err := client.HashSecret(); err != nil {
+		return "", fmt.Errorf("unable to hash secret: %w", err)
+	}
+
+	updatedCount, err := u.repo.UpdateClientSecret(ctx, id, client.Secret)
+	if err != nil {
+		return "", fmt.Errorf("unable to update client secret in repo: %w", err)
+	}
+	if updatedCount == 0 {
+		return "", fmt.Errorf("%w: client '%s'", entity.ErrNotFound, id)
+	}
+
+	return secret, nil
+}
+
+func (u clientUsecase) RemoveClient(ctx context.Context, id string) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionClientsManage); err != nil {
+		return 0, err
+	}
+	return u.repo.RemoveClient(ctx, id)
+}
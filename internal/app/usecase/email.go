@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// Mailer sends emails to users.
+type Mailer interface {
+	Send(ctx context.Context, email entity.Email) error
+}
+
+//go:embed templates
+var templates embed.FS
+
+// Email templates, each one defines "subject" and the body as the template itself.
+var (
+	verifyEmailTemplate   = template.Must(template.ParseFS(templates, "templates/verify_email.txt"))
+	resetPasswordTemplate = template.Must(template.ParseFS(templates, "templates/reset_password.txt"))
+)
+
+// tokenEmail is data of emails carrying a token, Link is set if the link url is configured.
+type tokenEmail struct {
+	Name      string
+	Token     string
+	Link      string
+	ExpiresAt string
+}
+
+func newTokenEmail(name, token, linkURL string, expiresAt time.Time) (tokenEmail, error) {
+	data := tokenEmail{
+		Name:      name,
+		Token:     token,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC1123),
+	}
+	if linkURL != "" {
+		link, err := url.Parse(linkURL)
+		if err != nil {
+			return tokenEmail{}, fmt.Errorf("unable to parse link url: %w", err)
+		}
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+		data.Link = link.String()
+	}
+	return data, nil
+}
+
+// WithMailer returns a copy of the usecase sending emails to users with the mailer.
+func (u userUsecase) WithMailer(mailer Mailer) userUsecase {
+	u.mailer = mailer
+	return u
+}
+
+// sendEmail renders the email from the template and sends it, it does nothing without a mailer.
+func (u userUsecase) sendEmail(ctx context.Context, to string, tmpl *template.Template, data interface{}) error {
+	if u.mailer == nil {
+		return nil
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("unable to render email subject: %w", err)
+	}
+	if err := tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf("unable to render email body: %w", err)
+	}
+
+	if err := u.mailer.Send(ctx, entity.Email{
+		To:      to,
+		Subject: subject.String(),
+		Body:    body.String(),
+	}); err != nil {
+		return fmt.Errorf("unable to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// EmailVerificationOptions configure verification of user emails.
+type EmailVerificationOptions struct {
+	// Required blocks sign-in in any way: password, passkey or identity provider, until the email is verified.
+	Required                bool
+	TokenExpirationDuration time.Duration
+	// ResendInterval is the minimal interval between verification emails to the user.
+	ResendInterval time.Duration
+	// LinkURL is the page verifying the email, the token is added as 'token' query parameter.
+	// The token itself is put to the email if it's empty.
+	LinkURL string
+}
+
+// WithEmailVerification returns a copy of the usecase verifying emails with the options,
+// verification emails are sent on registration and email change.
+func (u userUsecase) WithEmailVerification(opts EmailVerificationOptions) userUsecase {
+	u.emailVerification = opts
+	return u
+}
+
+// VerifyEmail marks the email verified with the token from the verification email, the token is single use.
+func (u userUsecase) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := u.authenticator.VerifyEmailVerificationToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("unable to verify email verification token: %w", err)
+	}
+
+	verifiedCount, err := u.repo.VerifyUserEmail(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return fmt.Errorf("unable to verify user email in repo: %w", err)
+	}
+	if verifiedCount == 0 {
+		return fmt.Errorf("%w: email is already verified or changed", entity.ErrInvalidCredentials)
+	}
+
+	if err := u.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		return fmt.Errorf("unable to revoke email verification token in repo: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail sends the verification email again. It succeeds for unknown and verified emails
+// and when the previous email is sent less than the resend interval ago,
+// so it doesn't reveal registered emails.
+func (u userUsecase) ResendVerificationEmail(ctx context.Context, email string) error {
+	if err := entity.ValidateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := u.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get user from repo: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	return u.sendVerificationEmail(ctx, user)
+}
+
+// sendVerificationEmail sends the verification email to the user with unverified email,
+// emails are sent no more often than the resend interval.
+func (u userUsecase) sendVerificationEmail(ctx context.Context, user entity.User) error {
+	if u.mailer == nil {
+		return nil
+	}
+
+	sentCount, err := u.repo.MarkEmailVerificationSent(ctx, user.ID, u.emailVerification.ResendInterval)
+	if err != nil {
+		return fmt.Errorf("unable to mark email verification sent in repo: %w", err)
+	}
+	if sentCount == 0 {
+		return nil
+	}
+
+	token, claims, err := u.authenticator.CreateEmailVerificationToken(entity.EmailVerificationClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(u.emailVerification.TokenExpirationDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create email verification token: %w", err)
+	}
+
+	data, err := newTokenEmail(user.Name, token, u.emailVerification.LinkURL, claims.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if err := u.sendEmail(ctx, user.Email, verifyEmailTemplate, data); err != nil {
+		return fmt.Errorf("unable to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// trySendVerificationEmail sends the verification email on registration and email change,
+// failures are logged only, the user asks to resend the email then.
+func (u userUsecase) trySendVerificationEmail(ctx context.Context, user entity.User) {
+	if err := u.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("unable to send verification email to user %d: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	federatedLoginSecretLength  = 32
+	provisionedPasswordLength   = 32
+	provisionedNameSuffixLength = 3
+)
+
+// IdentityProvider is an upstream OpenID Connect provider users sign in with.
+type IdentityProvider interface {
+	// AuthCodeURL returns URL of the provider authorization endpoint,
+	// PKCE code challenge is derived from the code verifier.
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	// Exchange redeems the authorization code and returns the identity from the verified id_token.
+	// Errors of invalid codes and id_tokens wrap entity.ErrInvalidCredentials.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (entity.ExternalIdentity, error)
+}
+
+type UserRegistrar interface {
+	ProvisionUser(ctx context.Context, user entity.User) (entity.User, error)
+}
+
+// federationUsecase signs users in with upstream identity providers.
+// Unknown identities are linked to the user with the same verified email
+// or provisioned as new users.
+type federationUsecase struct {
+	providers  map[string]IdentityProvider
+	identities IdentityRepository
+	users      UserRepository
+	registrar  UserRegistrar
+}
+
+func NewFederationUsecase(
+	providers map[string]IdentityProvider,
+	identities IdentityRepository,
+	users UserRepository,
+	registrar UserRegistrar,
+) federationUsecase {
+	return federationUsecase{
+		providers:  providers,
+		identities: identities,
+		users:      users,
+		registrar:  registrar,
+	}
+}
+
+// ProviderNames returns sorted names of the configured providers.
+func (u federationUsecase) ProviderNames() []string {
+	names := make([]string, 0, len(u.providers))
+	for name := range u.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// StartLogin returns URL to redirect the user to and the pending login,
+// it must be kept by the browser until the callback.
+func (u federationUsecase) StartLogin(ctx context.Context, providerName string) (string, entity.FederatedLogin, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return "", entity.FederatedLogin{}, fmt.Errorf("%w: unknown identity provider '%s'", entity.ErrValidation, providerName)
+	}
+
+	login := entity.FederatedLogin{Provider: providerName}
+	for _, secret := range []*string{&login.State, &login.Nonce, &login.CodeVerifier} {
+		var err error
+		*secret, err = newRandomString(federatedLoginSecretLength)
+		if err != nil {
+			return "", entity.FederatedLogin{}, err
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, login.State, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		return "", entity.FederatedLogin{}, fmt.Errorf("unable to build identity provider url: %w", err)
+	}
+
+	return authURL, login, nil
+}
+
+// CompleteLogin redeems the code returned by the provider and returns the local user of the identity.
+func (u federationUsecase) CompleteLogin(ctx context.Context, login entity.FederatedLogin, code string) (entity.User, error) {
+	provider, ok := u.providers[login.Provider]
+	if !ok {
+		return entity.User{}, fmt.Errorf("%w: unknown identity provider '%s'", entity.ErrValidation, login.Provider)
+	}
+
+	external, err := provider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("unable to exchange code with identity provider: %w", err)
+	}
+	external.Provider = login.Provider
+
+	identity, err := u.identities.GetIdentity(ctx, external.Provider, external.Subject)
+	if err == nil {
+		user, err := u.users.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return entity.User{}, fmt.Errorf("unable to get user from repo: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, entity.ErrNotFound) {
+		return entity.User{}, fmt.Errorf("unable to get identity from repo: %w", err)
+	}
+
+	user, err := u.linkUser(ctx, external)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if err := u.identities.InsertIdentity(ctx, entity.Identity{
+		Provider: external.Provider,
+		Subject:  external.Subject,
+		UserID:   user.ID,
+	}); err != nil {
+		return entity.User{}, fmt.Errorf("unable to insert identity in repo: %w", err)
+	}
+
+	return user, nil
+}
+
+// linkUser returns the user with the same email or provisions a new one.
+// The email must be verified both by the provider and by the local user, otherwise anyone could take over
+// a local account by registering its email at the provider, or register the email locally
+// before its owner signs in with the provider and keep signing in with own password.
+func (u federationUsecase) linkUser(ctx context.Context, external entity.ExternalIdentity) (entity.User, error) {
+	if external.Email == "" {
+		return entity.User{}, fmt.Errorf("%w: identity provider returned no email", entity.ErrPermissionDenied)
+	}
+
+	user, err := u.users.GetUserByEmail(ctx, external.Email)
+	switch {
+	case err == nil && !external.EmailVerified:
+		return entity.User{}, fmt.Errorf("%w: email '%s' is not verified by identity provider", entity.ErrPermissionDenied, external.Email)
+	case err == nil && !user.EmailVerified:
+		return entity.User{}, fmt.Errorf("%w: email '%s' of the local account is not verified", entity.ErrPermissionDenied, external.Email)
+	case err == nil:
+		return user, nil
+	case !errors.Is(err, entity.ErrNotFound):
+		return entity.User{}, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	return u.provisionUser(ctx, external)
+}
+
+// provisionUser registers the user with a random password, so it signs in with the provider only.
+// The name gets a random suffix if it's taken.
+func (u federationUsecase) provisionUser(ctx context.Context, external entity.ExternalIdentity) (entity.User, error) {
+	password, err := newRandomString(provisionedPasswordLength)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	name := external.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(external.Email, "@")
+	}
+
+	// emails verified by the provider are trusted, others are verified by the user as on registration
+	user := entity.User{Name: name, Email: external.Email, Password: password, EmailVerified: external.EmailVerified}
+	registeredUser, err := u.registrar.ProvisionUser(ctx, user)
+	if errors.Is(err, entity.ErrAlreadyExists) {
+		var suffix string
+		suffix, err = newRandomString(provisionedNameSuffixLength)
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.Name = name + "-" + suffix
+		registeredUser, err = u.registrar.ProvisionUser(ctx, user)
+	}
+	if err != nil {
+		return entity.User{}, fmt.Errorf("unable to provision user: %w", err)
+	}
+
+	return registeredUser, nil
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// fakeIdentityProvider asserts the same identity for any code.
+type fakeIdentityProvider struct {
+	identity entity.ExternalIdentity
+}
+
+func (fakeIdentityProvider) AuthCodeURL(context.Context, string, string, string) (string, error) {
+	return "https://idp.example.com/authorize", nil
+}
+
+func (p fakeIdentityProvider) Exchange(context.Context, string, string, string) (entity.ExternalIdentity, error) {
+	return p.identity, nil
+}
+
+// identityStore keeps linked identities in memory.
+type identityStore map[string]entity.Identity
+
+func (s identityStore) InsertIdentity(_ context.Context, identity entity.Identity) error {
+	s[identity.Provider+"/"+identity.Subject] = identity
+	return nil
+}
+
+func (s identityStore) GetIdentity(_ context.Context, provider, subject string) (entity.Identity, error) {
+	identity, ok := s[provider+"/"+subject]
+	if !ok {
+		return entity.Identity{}, entity.ErrNotFound
+	}
+	return identity, nil
+}
+
+// userStore keeps users in memory, other methods of the repository are not expected to be called.
+type userStore struct {
+	UserRepository
+
+	users []entity.User
+}
+
+func (s *userStore) GetUserByID(_ context.Context, id int64) (entity.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return entity.User{}, entity.ErrNotFound
+}
+
+func (s *userStore) GetUserByEmail(_ context.Context, email string) (entity.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return entity.User{}, entity.ErrNotFound
+}
+
+func (s *userStore) ProvisionUser(_ context.Context, user entity.User) (entity.User, error) {
+	user.ID = int64(len(s.users) + 1)
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func TestFederationCompleteLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		// local is the registered user
+		local    entity.User
+		identity entity.ExternalIdentity
+		// wantUserID is the user signed in, the provisioned user is the second one
+		wantUserID int64
+		wantErr    error
+	}{
+		{
+			name:       "linked to the verified local account",
+			local:      entity.User{Name: "alice", Email: "alice@example.com", EmailVerified: true},
+			identity:   entity.ExternalIdentity{Subject: "1", Email: "alice@example.com", EmailVerified: true},
+			wantUserID: 1,
+		},
+		{
+			name:     "local account with unverified email",
+			local:    entity.User{Name: "alice", Email: "alice@example.com"},
+			identity: entity.ExternalIdentity{Subject: "1", Email: "alice@example.com", EmailVerified: true},
+			wantErr:  entity.ErrPermissionDenied,
+		},
+		{
+			name:     "email unverified by the provider",
+			local:    entity.User{Name: "alice", Email: "alice@example.com", EmailVerified: true},
+			identity: entity.ExternalIdentity{Subject: "1", Email: "alice@example.com"},
+			wantErr:  entity.ErrPermissionDenied,
+		},
+		{
+			name:     "no email",
+			local:    entity.User{Name: "alice", Email: "alice@example.com", EmailVerified: true},
+			identity: entity.ExternalIdentity{Subject: "1"},
+			wantErr:  entity.ErrPermissionDenied,
+		},
+		{
+			name:       "unknown email is provisioned",
+			local:      entity.User{Name: "alice", Email: "alice@example.com", EmailVerified: true},
+			identity:   entity.ExternalIdentity{Subject: "1", Email: "bob@example.com", EmailVerified: true},
+			wantUserID: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			local := tt.local
+			local.ID = 1
+			users := &userStore{users: []entity.User{local}}
+			identities := identityStore{}
+			u := NewFederationUsecase(
+				map[string]IdentityProvider{"idp": fakeIdentityProvider{identity: tt.identity}},
+				identities,
+				users,
+				users,
+			)
+			login := entity.FederatedLogin{Provider: "idp"}
+
+			user, err := u.CompleteLogin(ctx, login, "code")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(identities) != 0 {
+					t.Errorf("identity is linked: %v", identities)
+				}
+				return
+			}
+			if user.ID != tt.wantUserID {
+				t.Fatalf("got user %d, want %d", user.ID, tt.wantUserID)
+			}
+
+			// the linked identity signs in the same user again
+			user, err = u.CompleteLogin(ctx, login, "code")
+			if err != nil || user.ID != tt.wantUserID {
+				t.Errorf("got user %d and error %v on the next sign-in, want user %d", user.ID, err, tt.wantUserID)
+			}
+		})
+	}
+}
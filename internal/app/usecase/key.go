@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type keyUsecase struct {
+	rotator SigningKeyRotator
+}
+
+func NewKeyUsecase(rotator SigningKeyRotator) keyUsecase {
+	return keyUsecase{
+		rotator: rotator,
+	}
+}
+
+func (u keyUsecase) RotateSigningKeys(ctx context.Context) (string, []string, error) {
+	if err := requirePermission(ctx, entity.PermissionKeysManage); err != nil {
+		return "", nil, err
+	}
+
+	activeKeyID, keyIDs, err := u.rotator.RotateSigningKeys(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to rotate signing keys: %w", err)
+	}
+
+	return activeKeyID, keyIDs, nil
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// prometheus metric to count sign-in lockouts
+var loginLockoutCount = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "user_usecase_login_lockouts_total",
+		Help: "Count of sign-in lockouts after too many failed attempts, by account or client ip",
+	},
+	[]string{"kind"},
+)
+
+const (
+	accountThrottleKind = "account"
+	ipThrottleKind      = "ip"
+)
+
+// LoginThrottlingOptions configure blocking of sign-in after failed attempts,
+// failures are counted per account and per client IP.
+type LoginThrottlingOptions struct {
+	// BaseDelay blocks sign-in after a failure, each next failure doubles it up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxAccountFailures and MaxIPFailures in a row lock sign-in for LockoutDuration.
+	MaxAccountFailures int
+	MaxIPFailures      int
+	LockoutDuration    time.Duration
+	// FailureWindow forgets failures once none happens within it.
+	FailureWindow time.Duration
+}
+
+// delay returns the backoff after the given count of failures.
+func (o LoginThrottlingOptions) delay(failures int) time.Duration {
+	delay := o.BaseDelay
+	for i := 1; i < failures && delay < o.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, o.MaxDelay)
+}
+
+type loginThrottleKey struct {
+	key         string
+	kind        string
+	maxFailures int
+}
+
+// WithLoginThrottling returns a copy of the usecase blocking sign-in after failed attempts.
+func (u userUsecase) WithLoginThrottling(repo LoginThrottleRepository, opts LoginThrottlingOptions) userUsecase {
+	u.loginThrottles = repo
+	u.loginThrottling = opts
+	return u
+}
+
+// UnlockUser lifts the sign-in block of the user, client IPs stay blocked.
+func (u userUsecase) UnlockUser(ctx context.Context, userID int64) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionUsersUnlock); err != nil {
+		return 0, err
+	}
+	if u.loginThrottles == nil {
+		return 0, nil
+	}
+
+	removedCount, err := u.loginThrottles.RemoveLoginFailures(ctx, accountThrottleKey(userID))
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove login failures in repo: %w", err)
+	}
+	return removedCount, nil
+}
+
+// loginThrottleKeys returns keys failures of the sign-in are counted by.
+// Unknown users are counted by the login, so they are blocked the same way as registered ones.
+func (u userUsecase) loginThrottleKeys(ctx context.Context, login entity.User, userID int64) []loginThrottleKey {
+	accountKey := accountThrottleKey(userID)
+	if userID == 0 {
+		name := login.Name
+		if name == "" {
+			name = login.Email
+		}
+		// hashed, so arbitrary input fits the key
+		accountKey = "login:" + entity.HashToken(strings.ToLower(name))
+	}
+	keys := []loginThrottleKey{{
+		key:         accountKey,
+		kind:        accountThrottleKind,
+		maxFailures: u.loginThrottling.MaxAccountFailures,
+	}}
+	if ip, ok := entity.ClientIPFromContext(ctx); ok {
+		keys = append(keys, loginThrottleKey{
+			key:         "ip:" + ip,
+			kind:        ipThrottleKind,
+			maxFailures: u.loginThrottling.MaxIPFailures,
+		})
+	}
+	return keys
+}
+
+func accountThrottleKey(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// checkLoginThrottle rejects sign-in while any of the keys is blocked.
+func (u userUsecase) checkLoginThrottle(ctx context.Context, keys []loginThrottleKey) error {
+	if u.loginThrottles == nil {
+		return nil
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.key
+	}
+	blockedUntil, err := u.loginThrottles.GetLoginBlockedUntil(ctx, names)
+	if err != nil {
+		return fmt.Errorf("unable to get login block from repo: %w", err)
+	}
+	if blockedUntil.IsZero() {
+		return nil
+	}
+
+	return entity.TooManyRequestsError{
+		Description: "sign-in is blocked after failed attempts",
+		RetryAfter:  time.Until(blockedUntil).Truncate(time.Second) + time.Second,
+	}
+}
+
+// loginFailed blocks sign-in by the keys with backoff or lockout, it returns ErrInvalidCredentials.
+func (u userUsecase) loginFailed(ctx context.Context, keys []loginThrottleKey) error {
+	if u.loginThrottles == nil {
+		return entity.ErrInvalidCredentials
+	}
+
+	for _, key := range keys {
+		failures, err := u.loginThrottles.RecordLoginFailure(ctx, key.key, u.loginThrottling.FailureWindow)
+		if err != nil {
+			return fmt.Errorf("unable to record login failure in repo: %w", err)
+		}
+
+		delay := u.loginThrottling.delay(failures)
+		if key.maxFailures > 0 && failures >= key.maxFailures {
+			// attempts are rejected while blocked, so each failure past the limit starts a new lockout
+			delay = u.loginThrottling.LockoutDuration
+			loginLockoutCount.WithLabelValues(key.kind).Inc()
+			log.Printf("sign-in by %s is locked for %s after %d failed attempts", key.key, delay, failures)
+		}
+		if _, err := u.loginThrottles.BlockLogin(ctx, key.key, time.Now().Add(delay)); err != nil {
+			return fmt.Errorf("unable to block login in repo: %w", err)
+		}
+	}
+
+	return entity.ErrInvalidCredentials
+}
+
+// resetLoginFailures forgets failures of the user after successful sign-in with all factors,
+// failures from the client IP are kept, they may be attempts on other accounts.
+func (u userUsecase) resetLoginFailures(ctx context.Context, userID int64) error {
+	if u.loginThrottles == nil {
+		return nil
+	}
+
+	if _, err := u.loginThrottles.RemoveLoginFailures(ctx, accountThrottleKey(userID)); err != nil {
+		return fmt.Errorf("unable to remove login failures in repo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// loginThrottleStore counts failures and blocks keys as the postgresql repository does,
+// except the failure window never passes.
+type loginThrottleStore struct {
+	mu           sync.Mutex
+	failures     map[string]int
+	blockedUntil map[string]time.Time
+}
+
+func newLoginThrottleStore() *loginThrottleStore {
+	return &loginThrottleStore{failures: make(map[string]int), blockedUntil: make(map[string]time.Time)}
+}
+
+func (s *loginThrottleStore) GetLoginBlockedUntil(_ context.Context, keys []string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var blockedUntil time.Time
+	for _, key := range keys {
+		if until := s.blockedUntil[key]; until.After(time.Now()) && until.After(blockedUntil) {
+			blockedUntil = until
+		}
+	}
+	return blockedUntil, nil
+}
+
+func (s *loginThrottleStore) RecordLoginFailure(_ context.Context, key string, _ time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key]++
+	return s.failures[key], nil
+}
+
+func (s *loginThrottleStore) BlockLogin(_ context.Context, key string, until time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if until.After(s.blockedUntil[key]) {
+		s.blockedUntil[key] = until
+	}
+	return 1, nil
+}
+
+func (s *loginThrottleStore) RemoveLoginFailures(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.failures[key]
+	delete(s.failures, key)
+	delete(s.blockedUntil, key)
+	if !ok {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// blockedFor returns how long the key stays blocked from now.
+func (s *loginThrottleStore) blockedFor(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max(time.Until(s.blockedUntil[key]), 0)
+}
+
+func TestLoginThrottlingOptionsDelay(t *testing.T) {
+	opts := LoginThrottlingOptions{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 3, want: 4 * time.Second},
+		{failures: 4, want: 8 * time.Second},
+		{failures: 5, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := opts.delay(tt.failures); got != tt.want {
+			t.Errorf("delay after %d failures: got %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginFailed(t *testing.T) {
+	const (
+		accountKey = "user:1"
+		ipKey      = "ip:192.0.2.1"
+	)
+	opts := LoginThrottlingOptions{
+		BaseDelay:          time.Second,
+		MaxDelay:           8 * time.Second,
+		MaxAccountFailures: 3,
+		MaxIPFailures:      5,
+		LockoutDuration:    time.Hour,
+		FailureWindow:      time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		// wantAccountBlock and wantIPBlock are the blocks after the last failure
+		wantAccountBlock time.Duration
+		wantIPBlock      time.Duration
+	}{
+		{name: "first failure", failures: 1, wantAccountBlock: time.Second, wantIPBlock: time.Second},
+		{name: "backoff doubles", failures: 2, wantAccountBlock: 2 * time.Second, wantIPBlock: 2 * time.Second},
+		{name: "account lockout", failures: 3, wantAccountBlock: time.Hour, wantIPBlock: 4 * time.Second},
+		{name: "ip backoff is capped", failures: 4, wantAccountBlock: time.Hour, wantIPBlock: 8 * time.Second},
+		{name: "ip lockout", failures: 5, wantAccountBlock: time.Hour, wantIPBlock: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := entity.ContextWithClientIP(context.Background(), "192.0.2.1")
+			store := newLoginThrottleStore()
+			u := userUsecase{}.WithLoginThrottling(store, opts)
+			keys := u.loginThrottleKeys(ctx, entity.User{}, 1)
+
+			for i := 0; i < tt.failures; i++ {
+				if err := u.loginFailed(ctx, keys); !errors.Is(err, entity.ErrInvalidCredentials) {
+					t.Fatalf("failure #%d: got error %v, want %v", i, err, entity.ErrInvalidCredentials)
+				}
+			}
+
+			assertBlock := func(key string, want time.Duration) {
+				t.Helper()
+				if got := store.blockedFor(key); got > want || got < want-time.Second {
+					t.Errorf("%s is blocked for %s, want %s", key, got, want)
+				}
+			}
+			assertBlock(accountKey, tt.wantAccountBlock)
+			assertBlock(ipKey, tt.wantIPBlock)
+
+			err := u.checkLoginThrottle(ctx, keys)
+			var tooManyRequests entity.TooManyRequestsError
+			if !errors.As(err, &tooManyRequests) {
+				t.Fatalf("got error %v, want %v", err, entity.ErrTooManyRequests)
+			}
+			if want := max(tt.wantAccountBlock, tt.wantIPBlock); tooManyRequests.RetryAfter != want {
+				t.Errorf("got retry after %s, want %s", tooManyRequests.RetryAfter, want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientIP string
+		login    entity.User
+		userID   int64
+		want     []string
+	}{
+		{name: "known user", userID: 1, want: []string{"user:1"}},
+		{name: "known user from client ip", clientIP: "192.0.2.1", userID: 1, want: []string{"user:1", "ip:192.0.2.1"}},
+		{
+			name:  "unknown user is counted by case-insensitive name",
+			login: entity.User{Name: "Alice"},
+			want:  []string{"login:" + entity.HashToken("alice")},
+		},
+		{
+			name:  "unknown user is counted by email without name",
+			login: entity.User{Email: "alice@example.com"},
+			want:  []string{"login:" + entity.HashToken("alice@example.com")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.clientIP != "" {
+				ctx = entity.ContextWithClientIP(ctx, tt.clientIP)
+			}
+
+			keys := userUsecase{}.loginThrottleKeys(ctx, tt.login, tt.userID)
+			if len(keys) != len(tt.want) {
+				t.Fatalf("got %d keys, want %v", len(keys), tt.want)
+			}
+			for i, key := range keys {
+				if key.key != tt.want[i] {
+					t.Errorf("got key %q, want %q", key.key, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResetLoginFailures(t *testing.T) {
+	ctx := entity.ContextWithClientIP(context.Background(), "192.0.2.1")
+	store := newLoginThrottleStore()
+	u := userUsecase{}.WithLoginThrottling(store, LoginThrottlingOptions{
+		BaseDelay:     time.Minute,
+		MaxDelay:      time.Minute,
+		FailureWindow: time.Hour,
+	})
+	keys := u.loginThrottleKeys(ctx, entity.User{}, 1)
+
+	if err := u.loginFailed(ctx, keys); !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Fatalf("got error %v, want %v", err, entity.ErrInvalidCredentials)
+	}
+	if err := u.resetLoginFailures(ctx, 1); err != nil {
+		t.Fatalf("unable to reset failures: %v", err)
+	}
+
+	if got := store.blockedFor("user:1"); got != 0 {
+		t.Errorf("account is blocked for %s after reset", got)
+	}
+	// the client ip may have failed on other accounts
+	if got := store.blockedFor("ip:192.0.2.1"); got == 0 {
+		t.Errorf("client ip is unblocked after reset")
+	}
+	if err := u.checkLoginThrottle(ctx, keys); !errors.Is(err, entity.ErrTooManyRequests) {
+		t.Errorf("got error %v, want %v", err, entity.ErrTooManyRequests)
+	}
+}
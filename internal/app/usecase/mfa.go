@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 8
+	mfaTokenLength     = 32
+	// maxMFAAttempts limits guesses of one-time passwords per sign-in,
+	// failures are also throttled per account across sign-ins as failed passwords are.
+	maxMFAAttempts = 5
+)
+
+// mfaUsecase manages the second factor of users: TOTP enrollment, recovery codes and admin reset.
+type mfaUsecase struct {
+	repo   MFARepository
+	users  UserRepository
+	issuer string
+}
+
+// NewMFAUsecase creates the usecase, the issuer is shown by authenticator apps next to the account.
+func NewMFAUsecase(repo MFARepository, users UserRepository, issuer string) mfaUsecase {
+	return mfaUsecase{
+		repo:   repo,
+		users:  users,
+		issuer: issuer,
+	}
+}
+
+// EnrollTOTP generates a new TOTP secret of the caller and returns it with otpauth:// URI for authenticator apps.
+// The secret is not enforced until the caller confirms it with a first code.
+func (u mfaUsecase) EnrollTOTP(ctx context.Context) (string, string, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := u.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      u.issuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to generate totp secret: %w", err)
+	}
+
+	if err := u.repo.UpsertTOTP(ctx, userID, key.Secret()); err != nil {
+		return "", "", fmt.Errorf("unable to upsert totp in repo: %w", err)
+	}
+
+	return key.Secret(), key.URL(), nil
+}
+
+// ConfirmTOTP enables TOTP of the caller if the code matches the enrolled secret.
+// It returns recovery codes, they are stored hashed, so they are returned only here.
+func (u mfaUsecase) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userTOTP, err := u.repo.GetTOTP(ctx, userID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil, fmt.Errorf("%w: totp is not enrolled", entity.ErrValidation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get totp from repo: %w", err)
+	}
+	if userTOTP.Confirmed() {
+		return nil, fmt.Errorf("%w: totp is already confirmed", entity.ErrAlreadyExists)
+	}
+
+	if err := verifyTOTP(ctx, u.repo, userTOTP, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	codeHashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		recoveryCode, err := newRandomString(recoveryCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = entity.FormatRecoveryCode(recoveryCode)
+		codeHashes[i] = entity.HashToken(recoveryCode)
+	}
+
+	if err := u.repo.ConfirmTOTP(ctx, userID, codeHashes); err != nil {
+		return nil, fmt.Errorf("unable to confirm totp in repo: %w", err)
+	}
+
+	return codes, nil
+}
+
+// ResetMFA removes the second factor of the user who lost both the device and recovery codes.
+func (u mfaUsecase) ResetMFA(ctx context.Context, userID int64) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionMFAReset); err != nil {
+		return 0, err
+	}
+
+	removedCount, err := u.repo.RemoveTOTP(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove totp in repo: %w", err)
+	}
+
+	return removedCount, nil
+}
+
+// StartMFAChallenge returns MFA token to complete sign-in of the user with confirmed second factor,
+// an empty token means the user has no second factor and sign-in is complete.
+// It's rejected while the account is throttled, so a known password doesn't give unlimited guesses of codes.
+func (u userUsecase) StartMFAChallenge(ctx context.Context, userID int64) (string, error) {
+	if err := u.checkLoginThrottle(ctx, u.loginThrottleKeys(ctx, entity.User{}, userID)); err != nil {
+		return "", err
+	}
+
+	userTOTP, err := u.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return "", fmt.Errorf("unable to get totp from repo: %w", err)
+	}
+	if err != nil || !userTOTP.Confirmed() {
+		return "", u.resetLoginFailures(ctx, userID)
+	}
+
+	token, err := newRandomString(mfaTokenLength)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.challenges.InsertMFAChallenge(ctx, entity.MFAChallenge{
+		TokenHash: entity.HashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(u.mfaChallengeExpirationDuration),
+	}); err != nil {
+		return "", fmt.Errorf("unable to insert mfa challenge in repo: %w", err)
+	}
+
+	return token, nil
+}
+
+// CompleteMFAChallenge checks TOTP or recovery code for the MFA token and returns the user id.
+// The token allows a few attempts only and is removed once completed,
+// failed attempts are counted against the account and the client IP as failed passwords.
+func (u userUsecase) CompleteMFAChallenge(ctx context.Context, mfaToken, code string) (int64, error) {
+	tokenHash := entity.HashToken(mfaToken)
+	challenge, err := u.challenges.AttemptMFAChallenge(ctx, tokenHash, maxMFAAttempts)
+	if errors.Is(err, entity.ErrNotFound) {
+		return 0, fmt.Errorf("%w: mfa token is invalid, expired or out of attempts", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to attempt mfa challenge in repo: %w", err)
+	}
+
+	throttleKeys := u.loginThrottleKeys(ctx, entity.User{}, challenge.UserID)
+	if err := u.checkLoginThrottle(ctx, throttleKeys); err != nil {
+		return 0, err
+	}
+
+	userTOTP, err := u.mfaRepo.GetTOTP(ctx, challenge.UserID)
+	if errors.Is(err, entity.ErrNotFound) {
+		// the second factor is reset in the middle of sign-in
+		return 0, fmt.Errorf("%w: totp is not enrolled", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get totp from repo: %w", err)
+	}
+
+	if err := verifySecondFactor(ctx, u.mfaRepo, userTOTP, code); err != nil {
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			return 0, u.loginFailed(ctx, throttleKeys)
+		}
+		return 0, err
+	}
+
+	if _, err := u.challenges.RemoveMFAChallenge(ctx, tokenHash); err != nil {
+		return 0, fmt.Errorf("unable to remove mfa challenge in repo: %w", err)
+	}
+	if err := u.resetLoginFailures(ctx, challenge.UserID); err != nil {
+		return 0, err
+	}
+
+	return challenge.UserID, nil
+}
+
+// verifySecondFactor accepts either TOTP or a recovery code of the user.
+func verifySecondFactor(ctx context.Context, repo MFARepository, userTOTP entity.TOTP, code string) error {
+	err := verifyTOTP(ctx, repo, userTOTP, code)
+	if !errors.Is(err, entity.ErrInvalidCredentials) {
+		return err
+	}
+
+	usedCount, err := repo.UseRecoveryCode(ctx, userTOTP.UserID, entity.HashToken(entity.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("unable to use recovery code in repo: %w", err)
+	}
+	if usedCount == 0 {
+		return fmt.Errorf("%w: invalid one-time password or recovery code", entity.ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// verifyTOTP checks the code and records its time step, so the code can't be replayed.
+func verifyTOTP(ctx context.Context, repo MFARepository, userTOTP entity.TOTP, code string) error {
+	step, err := userTOTP.Verify(code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	usedCount, err := repo.UseTOTPStep(ctx, userTOTP.UserID, step)
+	if err != nil {
+		return fmt.Errorf("unable to use totp step in repo: %w", err)
+	}
+	if usedCount == 0 {
+		return fmt.Errorf("%w: one-time password is already used", entity.ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// callerUserID returns the user the caller is authenticated as, tokens without user are rejected.
+func callerUserID(ctx context.Context) (int64, error) {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return 0, fmt.Errorf("%w: no authenticated principal", entity.ErrInvalidCredentials)
+	}
+	if principal.UserID == 0 {
+		return 0, fmt.Errorf("%w: token is not issued to a user", entity.ErrPermissionDenied)
+	}
+	return principal.UserID, nil
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// totpStore records used steps and recovery codes as the postgresql repository does,
+// other methods of the repository are not expected to be called.
+type totpStore struct {
+	MFARepository
+
+	mu            sync.Mutex
+	lastUsedStep  int64
+	recoveryCodes map[string]bool
+}
+
+func (s *totpStore) UseTOTPStep(_ context.Context, _ int64, step int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastUsedStep >= step {
+		return 0, nil
+	}
+	s.lastUsedStep = step
+	return 1, nil
+}
+
+func (s *totpStore) UseRecoveryCode(_ context.Context, _ int64, codeHash string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.recoveryCodes[codeHash] {
+		return 0, nil
+	}
+	delete(s.recoveryCodes, codeHash)
+	return 1, nil
+}
+
+// testTOTPCode returns the code of the step shifted from the current one.
+func testTOTPCode(t *testing.T, shift int64) string {
+	t.Helper()
+
+	code, err := totp.GenerateCodeCustom(testTOTPSecret, time.Now().Add(time.Duration(shift)*30*time.Second), totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatalf("unable to generate code: %v", err)
+	}
+	return code
+}
+
+func TestVerifySecondFactorReplay(t *testing.T) {
+	const recoveryCode = "abcde-fghij"
+
+	type attempt struct {
+		// shift is the step of TOTP code relative to the current one, unless code is set
+		shift   int64
+		code    string
+		wantErr error
+	}
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+	}{
+		{
+			name: "code is accepted once",
+			attempts: []attempt{
+				{shift: 0},
+				{shift: 0, wantErr: entity.ErrInvalidCredentials},
+			},
+		},
+		{
+			name: "code of the earlier step is rejected after the later one",
+			attempts: []attempt{
+				{shift: 0},
+				{shift: -1, wantErr: entity.ErrInvalidCredentials},
+			},
+		},
+		{
+			name: "code of the later step is accepted after the earlier one",
+			attempts: []attempt{
+				{shift: -1},
+				{shift: 0},
+				{shift: 1},
+			},
+		},
+		{
+			name: "recovery code is accepted once",
+			attempts: []attempt{
+				{code: recoveryCode},
+				{code: "ABCDEFGHIJ", wantErr: entity.ErrInvalidCredentials},
+			},
+		},
+		{
+			name: "recovery code is accepted after the used totp code",
+			attempts: []attempt{
+				{shift: 0},
+				{code: recoveryCode},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// codes are generated and verified within the same step
+			if time.Now().Unix()%30 == 29 {
+				time.Sleep(time.Second)
+			}
+			ctx := context.Background()
+			repo := &totpStore{recoveryCodes: map[string]bool{
+				entity.HashToken(entity.NormalizeRecoveryCode(recoveryCode)): true,
+			}}
+			userTOTP := entity.TOTP{UserID: 1, Secret: testTOTPSecret}
+
+			for i, a := range tt.attempts {
+				code := a.code
+				if code == "" {
+					code = testTOTPCode(t, a.shift)
+				}
+				if err := verifySecondFactor(ctx, repo, userTOTP, code); !errors.Is(err, a.wantErr) {
+					t.Fatalf("attempt #%d: got error %v, want %v", i, err, a.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,474 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const authorizationCodeLength = 32
+
+// UserSessions signs users in, it's implemented by userUsecase,
+// so OAuth2 flows share credential checks and token issuance with AuthenticateUser.
+type UserSessions interface {
+	VerifyUserCredentials(ctx context.Context, user entity.User) (entity.User, error)
+	StartMFAChallenge(ctx context.Context, userID int64) (mfaToken string, err error)
+	CompleteMFAChallenge(ctx context.Context, mfaToken, code string) (userID int64, err error)
+	StartClientSession(ctx context.Context, userID int64, clientID string, scopes []string) (entity.TokenSet, error)
+	RefreshClientSession(ctx context.Context, clientID string, refreshToken string) (entity.TokenSet, error)
+}
+
+// oauth2Usecase implements OAuth2 endpoints used by clients of the service:
+// authorization code flow with PKCE, token issuance, introspection (RFC 7662) and revocation (RFC 7009).
+// Clients requesting the openid scope also get OpenID Connect id_token and userinfo.
+type oauth2Usecase struct {
+	users                               UserRepository
+	serviceAccounts                     ServiceAccountRepository
+	clients                             ClientRepository
+	codes                               AuthorizationCodeRepository
+	tokenRepo                           RefreshTokenRepository
+	revocations                         RevocationRepository
+	authenticator                       Authenticator
+	sessions                            UserSessions
+	authorizationCodeExpirationDuration time.Duration
+}
+
+func NewOAuth2Usecase(
+	users UserRepository,
+	serviceAccounts ServiceAccountRepository,
+	clients ClientRepository,
+	codes AuthorizationCodeRepository,
+	tokenRepo RefreshTokenRepository,
+	revocations RevocationRepository,
+	authenticator Authenticator,
+	sessions UserSessions,
+	authorizationCodeExpirationDuration time.Duration,
+) oauth2Usecase {
+	return oauth2Usecase{
+		users:                               users,
+		serviceAccounts:                     serviceAccounts,
+		clients:                             clients,
+		codes:                               codes,
+		tokenRepo:                           tokenRepo,
+		revocations:                         revocations,
+		authenticator:                       authenticator,
+		sessions:                            sessions,
+		authorizationCodeExpirationDuration: authorizationCodeExpirationDuration,
+	}
+}
+
+func (u oauth2Usecase) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (entity.Client, error) {
+	client, err := u.clients.GetClient(ctx, clientID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.Client{}, fmt.Errorf("%w: unknown client", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.Client{}, fmt.Errorf("unable to get client from repo: %w", err)
+	}
+
+	if err := client.CompareSecret(clientSecret); err != nil {
+		return entity.Client{}, fmt.Errorf("%w: invalid client secret", entity.ErrInvalidCredentials)
+	}
+
+	return client, nil
+}
+
+// ResolveAuthorizationClient returns the client of the authorization request and its redirect URI.
+// Errors mean the redirect URI can't be trusted, so they must not be sent to it.
+func (u oauth2Usecase) ResolveAuthorizationClient(ctx context.Context, request entity.AuthorizationRequest) (entity.Client, string, error) {
+	client, err := u.clients.GetClient(ctx, request.ClientID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.Client{}, "", fmt.Errorf("%w: unknown client", entity.ErrValidation)
+	}
+	if err != nil {
+		return entity.Client{}, "", fmt.Errorf("unable to get client from repo: %w", err)
+	}
+
+	redirectURI, err := client.ResolveRedirectURI(request.RedirectURI)
+	if err != nil {
+		return entity.Client{}, "", err
+	}
+
+	return client, redirectURI, nil
+}
+
+// ValidateAuthorizationRequest checks PKCE parameters, nonce and requested scopes of the client.
+func (u oauth2Usecase) ValidateAuthorizationRequest(client entity.Client, request entity.AuthorizationRequest) error {
+	if err := request.ValidateCodeChallenge(); err != nil {
+		return err
+	}
+	if err := request.ValidateNonce(); err != nil {
+		return err
+	}
+	if _, err := client.GrantScopes(request.Scopes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Authorize signs the user in and returns authorization code for the client.
+// Users with enrolled second factor get MFA token instead of the code, the code is returned by AuthorizeMFA then.
+func (u oauth2Usecase) Authorize(ctx context.Context, request entity.AuthorizationRequest, user entity.User) (string, string, error) {
+	client, redirectURI, err := u.ResolveAuthorizationClient(ctx, request)
+	if err != nil {
+		return "", "", err
+	}
+	if err := u.ValidateAuthorizationRequest(client, request); err != nil {
+		return "", "", err
+	}
+
+	repoUser, err := u.sessions.VerifyUserCredentials(ctx, user)
+	if err != nil {
+		return "", "", err
+	}
+
+	mfaToken, err := u.sessions.StartMFAChallenge(ctx, repoUser.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if mfaToken != "" {
+		return "", mfaToken, nil
+	}
+
+	code, err := u.issueAuthorizationCode(ctx, client, redirectURI, request, repoUser.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return code, "", nil
+}
+
+// AuthorizeMFA completes sign-in with TOTP or recovery code and returns authorization code for the client.
+func (u oauth2Usecase) AuthorizeMFA(ctx context.Context, request entity.AuthorizationRequest, mfaToken, mfaCode string) (string, error) {
+	client, redirectURI, err := u.ResolveAuthorizationClient(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	if err := u.ValidateAuthorizationRequest(client, request); err != nil {
+		return "", err
+	}
+
+	userID, err := u.sessions.CompleteMFAChallenge(ctx, mfaToken, mfaCode)
+	if err != nil {
+		return "", err
+	}
+
+	return u.issueAuthorizationCode(ctx, client, redirectURI, request, userID)
+}
+
+// AuthorizeUser returns authorization code for the user already signed in another way,
+// e.g. with an upstream identity provider. The second factor is still required as by Authorize,
+// users with enrolled second factor get MFA token instead of the code.
+func (u oauth2Usecase) AuthorizeUser(ctx context.Context, request entity.AuthorizationRequest, userID int64) (string, string, error) {
+	client, redirectURI, err := u.ResolveAuthorizationClient(ctx, request)
+	if err != nil {
+		return "", "", err
+	}
+	if err := u.ValidateAuthorizationRequest(client, request); err != nil {
+		return "", "", err
+	}
+
+	mfaToken, err := u.sessions.StartMFAChallenge(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if mfaToken != "" {
+		return "", mfaToken, nil
+	}
+
+	code, err := u.issueAuthorizationCode(ctx, client, redirectURI, request, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return code, "", nil
+}
+
+// issueAuthorizationCode stores the code for the validated request.
+// The code is single use and short living, only its hash is stored.
+func (u oauth2Usecase) issueAuthorizationCode(
+	ctx context.Context,
+	client entity.Client,
+	redirectURI string,
+	request entity.AuthorizationRequest,
+	userID int64,
+) (string, error) {
+	scopes, err := client.GrantScopes(request.Scopes)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := newRandomString(authorizationCodeLength)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.codes.InsertAuthorizationCode(ctx, entity.AuthorizationCode{
+		CodeHash:      entity.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		CodeChallenge: request.CodeChallenge,
+		Nonce:         request.Nonce,
+		ExpiresAt:     time.Now().Add(u.authorizationCodeExpirationDuration),
+	}); err != nil {
+		return "", fmt.Errorf("unable to insert authorization code in repo: %w", err)
+	}
+
+	return code, nil
+}
+
+// ExchangeAuthorizationCode issues tokens for the code of the authorization_code grant.
+// The code must be issued to the client with the same redirect URI and match the PKCE code verifier.
+func (u oauth2Usecase) ExchangeAuthorizationCode(
+	ctx context.Context,
+	client entity.Client,
+	code string,
+	redirectURI string,
+	codeVerifier string,
+) (entity.TokenSet, error) {
+	authorizationCode, err := u.codes.UseAuthorizationCode(ctx, entity.HashToken(code))
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.TokenSet{}, fmt.Errorf("%w: authorization code is invalid, expired or already used", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to use authorization code in repo: %w", err)
+	}
+
+	if authorizationCode.ClientID != client.ID {
+		return entity.TokenSet{}, fmt.Errorf("%w: authorization code is issued to another client", entity.ErrInvalidCredentials)
+	}
+	if authorizationCode.RedirectURI != redirectURI {
+		return entity.TokenSet{}, fmt.Errorf("%w: redirect uri doesn't match", entity.ErrInvalidCredentials)
+	}
+	if err := authorizationCode.VerifyCodeVerifier(codeVerifier); err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	tokens, err := u.sessions.StartClientSession(ctx, authorizationCode.UserID, client.ID, authorizationCode.Scopes)
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	return u.withIDToken(ctx, tokens, authorizationCode.Nonce)
+}
+
+// RefreshClientToken rotates refresh token issued to the client by the refresh_token grant.
+// OpenID Connect clients get a new id_token without nonce.
+func (u oauth2Usecase) RefreshClientToken(ctx context.Context, client entity.Client, refreshToken string) (entity.TokenSet, error) {
+	tokens, err := u.sessions.RefreshClientSession(ctx, client.ID, refreshToken)
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	return u.withIDToken(ctx, tokens, "")
+}
+
+// GetUserInfo returns claims of the user the access token is issued to,
+// the token must be granted the openid scope.
+func (u oauth2Usecase) GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error) {
+	claims, err := u.authenticator.VerifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return entity.UserInfo{}, fmt.Errorf("unable to verify access token: %w", err)
+	}
+	if claims.UserID == 0 || !entity.HasOpenIDScope(claims.Scopes) {
+		return entity.UserInfo{}, fmt.Errorf("%w: token is not granted the %s scope", entity.ErrInvalidScope, entity.ScopeOpenID)
+	}
+
+	user, err := u.users.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, entity.ErrNotFound) {
+		// the user is removed after the token is issued
+		return entity.UserInfo{}, fmt.Errorf("%w: unknown user", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.UserInfo{}, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	return entity.NewUserInfo(user, claims.Scopes), nil
+}
+
+// withIDToken adds id_token to tokens issued with the openid scope.
+func (u oauth2Usecase) withIDToken(ctx context.Context, tokens entity.TokenSet, nonce string) (entity.TokenSet, error) {
+	claims := tokens.Claims
+	if !entity.HasOpenIDScope(claims.Scopes) {
+		return tokens, nil
+	}
+
+	user, err := u.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	tokens.IDToken, err = u.authenticator.CreateIDToken(entity.IDTokenClaims{
+		UserInfo: entity.NewUserInfo(user, claims.Scopes),
+		ClientID: claims.ClientID,
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to create id token: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// IssueClientToken issues access token to the client itself by the client_credentials grant.
+// Such tokens have no user and no refresh token, public clients can't use the grant.
+func (u oauth2Usecase) IssueClientToken(ctx context.Context, client entity.Client, scopes []string) (string, entity.TokenClaims, error) {
+	if client.Public {
+		return "", entity.TokenClaims{}, fmt.Errorf("%w: public client can't use client credentials", entity.ErrPermissionDenied)
+	}
+
+	grantedScopes, err := client.GrantScopes(scopes)
+	if err != nil {
+		return "", entity.TokenClaims{}, err
+	}
+
+	accessToken, claims, err := u.authenticator.CreateAccessToken(entity.TokenClaims{
+		ClientID: client.ID,
+		Scopes:   grantedScopes,
+	})
+	if err != nil {
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to create access token: %w", err)
+	}
+
+	return accessToken, claims, nil
+}
+
+// IntrospectToken returns state of the token, invalid, expired and revoked tokens are inactive.
+// Public clients can't introspect tokens.
+func (u oauth2Usecase) IntrospectToken(ctx context.Context, client entity.Client, token, tokenTypeHint string) (entity.TokenIntrospection, error) {
+	if client.Public {
+		return entity.TokenIntrospection{}, fmt.Errorf("%w: public client can't introspect tokens", entity.ErrPermissionDenied)
+	}
+
+	for _, tokenType := range tokenTypesByHint(tokenTypeHint) {
+		claims, err := u.verifyToken(ctx, token, tokenType)
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			continue
+		}
+		if err != nil {
+			return entity.TokenIntrospection{}, err
+		}
+
+		return entity.TokenIntrospection{
+			Active:    true,
+			TokenType: tokenType,
+			Claims:    claims,
+		}, nil
+	}
+
+	return entity.TokenIntrospection{}, nil
+}
+
+// RevokeToken revokes access token or the family of refresh token.
+// Invalid tokens are ignored, as there is nothing to revoke.
+// Tokens issued to another client can not be revoked by the client.
+func (u oauth2Usecase) RevokeToken(ctx context.Context, client entity.Client, token, tokenTypeHint string) error {
+	for _, tokenType := range tokenTypesByHint(tokenTypeHint) {
+		var err error
+		switch tokenType {
+		case entity.AccessTokenTypeHint:
+			err = u.revokeAccessToken(ctx, client, token)
+		case entity.RefreshTokenTypeHint:
+			err = u.revokeRefreshToken(ctx, client, token)
+		}
+		if errors.Is(err, entity.ErrInvalidCredentials) {
+			continue
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (u oauth2Usecase) verifyToken(ctx context.Context, token, tokenType string) (entity.TokenClaims, error) {
+	if tokenType == entity.AccessTokenTypeHint {
+		claims, err := u.authenticator.VerifyAccessToken(ctx, token)
+		if err != nil {
+			return entity.TokenClaims{}, fmt.Errorf("unable to verify access token: %w", err)
+		}
+		// tokens of removed service accounts are not revoked, they are rejected by the lookup
+		if claims.ServiceAccountID != 0 {
+			_, err := u.serviceAccounts.GetServiceAccount(ctx, claims.ServiceAccountID)
+			if errors.Is(err, entity.ErrNotFound) {
+				return entity.TokenClaims{}, fmt.Errorf("%w: service account is removed", entity.ErrInvalidCredentials)
+			}
+			if err != nil {
+				return entity.TokenClaims{}, fmt.Errorf("unable to get service account from repo: %w", err)
+			}
+		}
+		return claims, nil
+	}
+
+	claims, err := u.authenticator.VerifyRefreshToken(ctx, token)
+	if err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("unable to verify refresh token: %w", err)
+	}
+
+	// refresh token is single use, so a valid signature is not enough
+	storedToken, err := u.tokenRepo.GetRefreshToken(ctx, claims.ID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.TokenClaims{}, fmt.Errorf("%w: unknown refresh token", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("unable to get refresh token from repo: %w", err)
+	}
+	if storedToken.UsedAt != nil || storedToken.RevokedAt != nil {
+		return entity.TokenClaims{}, fmt.Errorf("%w: refresh token is already used or revoked", entity.ErrInvalidCredentials)
+	}
+
+	return claims, nil
+}
+
+func (u oauth2Usecase) revokeAccessToken(ctx context.Context, client entity.Client, token string) error {
+	claims, err := u.authenticator.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("unable to verify access token: %w", err)
+	}
+	if claims.ClientID != "" && claims.ClientID != client.ID {
+		return fmt.Errorf("%w: token is issued to another client", entity.ErrPermissionDenied)
+	}
+
+	if err := u.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		return fmt.Errorf("unable to revoke access token in repo: %w", err)
+	}
+
+	return nil
+}
+
+func (u oauth2Usecase) revokeRefreshToken(ctx context.Context, client entity.Client, token string) error {
+	claims, err := u.authenticator.VerifyRefreshToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("unable to verify refresh token: %w", err)
+	}
+
+	storedToken, err := u.tokenRepo.GetRefreshToken(ctx, claims.ID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get refresh token from repo: %w", err)
+	}
+	if storedToken.ClientID != "" && storedToken.ClientID != client.ID {
+		return fmt.Errorf("%w: token is issued to another client", entity.ErrPermissionDenied)
+	}
+
+	if _, err := u.tokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+		return fmt.Errorf("unable to revoke refresh token family in repo: %w", err)
+	}
+
+	return nil
+}
+
+// tokenTypesByHint returns token types to try, the hinted one goes first.
+// Unknown hints are ignored as RFC 7662 and RFC 7009 allow.
+func tokenTypesByHint(tokenTypeHint string) []string {
+	if tokenTypeHint == entity.RefreshTokenTypeHint {
+		return []string{entity.RefreshTokenTypeHint, entity.AccessTokenTypeHint}
+	}
+	return []string{entity.AccessTokenTypeHint, entity.RefreshTokenTypeHint}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// RFC 7636 appendix B example
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// authorizationCodeStore keeps authorization codes in memory, used codes are removed.
+type authorizationCodeStore map[string]entity.AuthorizationCode
+
+func (s authorizationCodeStore) InsertAuthorizationCode(_ context.Context, code entity.AuthorizationCode) error {
+	s[code.CodeHash] = code
+	return nil
+}
+
+func (s authorizationCodeStore) UseAuthorizationCode(_ context.Context, codeHash string) (entity.AuthorizationCode, error) {
+	code, ok := s[codeHash]
+	if !ok || !code.ExpiresAt.After(time.Now()) {
+		return entity.AuthorizationCode{}, entity.ErrNotFound
+	}
+	delete(s, codeHash)
+	return code, nil
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	const redirectURI = "https://client.example.com/callback"
+	client := entity.Client{ID: "client", Scopes: []string{entity.ScopeOpenID, entity.ScopeProfile}, Public: true}
+
+	tests := []struct {
+		name         string
+		client       entity.Client
+		redirectURI  string
+		codeVerifier string
+		wantErr      error
+	}{
+		{name: "valid", client: client, redirectURI: redirectURI, codeVerifier: testCodeVerifier},
+		{name: "another client", client: entity.Client{ID: "other"}, redirectURI: redirectURI, codeVerifier: testCodeVerifier, wantErr: entity.ErrInvalidCredentials},
+		{name: "another redirect uri", client: client, redirectURI: "https://evil.example.com/callback", codeVerifier: testCodeVerifier, wantErr: entity.ErrInvalidCredentials},
+		{name: "wrong code verifier", client: client, redirectURI: redirectURI, codeVerifier: testCodeVerifier[1:] + "a", wantErr: entity.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authenticator := newTestAuthenticator(t)
+			users := &userStore{users: []entity.User{{ID: 1, Name: "alice"}}}
+			codes := authorizationCodeStore{}
+			u := oauth2Usecase{
+				users:         users,
+				codes:         codes,
+				authenticator: authenticator,
+				sessions: userUsecase{
+					repo:          users,
+					roleRepo:      noRoles{},
+					tokenRepo:     newRefreshTokenStore(),
+					authenticator: authenticator,
+				},
+				authorizationCodeExpirationDuration: time.Minute,
+			}
+			request := entity.AuthorizationRequest{
+				ClientID:            client.ID,
+				RedirectURI:         redirectURI,
+				Scopes:              []string{entity.ScopeOpenID, entity.ScopeProfile},
+				CodeChallenge:       testCodeChallenge,
+				CodeChallengeMethod: entity.CodeChallengeMethodS256,
+			}
+			code, err := u.issueAuthorizationCode(ctx, client, redirectURI, request, 1)
+			if err != nil {
+				t.Fatalf("unable to issue code: %v", err)
+			}
+
+			tokens, err := u.ExchangeAuthorizationCode(ctx, tt.client, code, tt.redirectURI, tt.codeVerifier)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tokens.IDToken == "" {
+				t.Errorf("no id_token for the openid scope")
+			}
+
+			// the token lets the client read and update the profile of the signed in user
+			claims, err := authenticator.VerifyAccessToken(ctx, tokens.AccessToken)
+			if err != nil {
+				t.Fatalf("unable to verify access token: %v", err)
+			}
+			if claims.UserID != 1 || claims.ClientID != client.ID {
+				t.Errorf("got token of user %d and client %q, want user 1 and client %q", claims.UserID, claims.ClientID, client.ID)
+			}
+			for _, permission := range []string{entity.PermissionUsersRead, entity.PermissionUsersUpdate} {
+				if !entity.ScopesAllow(claims.Scopes, permission) {
+					t.Errorf("scopes %v don't allow %s", claims.Scopes, permission)
+				}
+			}
+
+			if _, err := u.ExchangeAuthorizationCode(ctx, tt.client, code, tt.redirectURI, tt.codeVerifier); !errors.Is(err, entity.ErrInvalidCredentials) {
+				t.Errorf("got error %v on the code reuse, want %v", err, entity.ErrInvalidCredentials)
+			}
+		})
+	}
+}
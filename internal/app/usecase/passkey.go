@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const webAuthnSessionIDLength = 32
+
+// RelyingParty runs WebAuthn ceremonies. Options are JSON for the browser WebAuthn API,
+// session data is opaque and kept until the ceremony is finished.
+// Finish methods return errors wrapping entity.ErrInvalidCredentials for rejected responses.
+type RelyingParty interface {
+	BeginRegistration(user entity.User, passkeys []entity.Passkey) (options []byte, sessionData []byte, err error)
+	FinishRegistration(user entity.User, passkeys []entity.Passkey, sessionData, response []byte) (entity.Passkey, error)
+	BeginLogin() (options []byte, sessionData []byte, err error)
+	// FinishLogin finds the user of the passkey with lookup and returns the passkey with updated sign count.
+	FinishLogin(
+		sessionData []byte,
+		response []byte,
+		lookup func(userID int64) (entity.User, []entity.Passkey, error),
+	) (entity.Passkey, error)
+}
+
+// passkeyUsecase registers passkeys of users and signs users in with them.
+// Passkeys require user verification by the authenticator, so they are
+// a second factor on their own and skip the TOTP challenge.
+type passkeyUsecase struct {
+	rp                        RelyingParty
+	repo                      PasskeyRepository
+	users                     UserRepository
+	sessions                  UserSessions
+	sessionExpirationDuration time.Duration
+}
+
+func NewPasskeyUsecase(
+	rp RelyingParty,
+	repo PasskeyRepository,
+	users UserRepository,
+	sessions UserSessions,
+	sessionExpirationDuration time.Duration,
+) passkeyUsecase {
+	return passkeyUsecase{
+		rp:                        rp,
+		repo:                      repo,
+		users:                     users,
+		sessions:                  sessions,
+		sessionExpirationDuration: sessionExpirationDuration,
+	}
+}
+
+// BeginPasskeyRegistration returns credential creation options for the caller
+// and the session id to finish the registration with.
+func (u passkeyUsecase) BeginPasskeyRegistration(ctx context.Context) (string, string, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, passkeys, err := u.userPasskeys(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	options, sessionData, err := u.rp.BeginRegistration(user, passkeys)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to begin passkey registration: %w", err)
+	}
+
+	sessionID, err := u.startSession(ctx, &userID, entity.WebAuthnRegistration, sessionData)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(options), sessionID, nil
+}
+
+// FinishPasskeyRegistration verifies the credential created by the authenticator and stores it as a passkey of the caller.
+func (u passkeyUsecase) FinishPasskeyRegistration(ctx context.Context, sessionID, credential, name string) (entity.Passkey, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+	if err := entity.ValidatePasskeyName(name); err != nil {
+		return entity.Passkey{}, err
+	}
+
+	session, err := u.useSession(ctx, sessionID, entity.WebAuthnRegistration)
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+	if session.UserID == nil || *session.UserID != userID {
+		return entity.Passkey{}, fmt.Errorf("%w: registration is started by another user", entity.ErrPermissionDenied)
+	}
+
+	user, passkeys, err := u.userPasskeys(ctx, userID)
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+
+	passkey, err := u.rp.FinishRegistration(user, passkeys, session.Data, []byte(credential))
+	if err != nil {
+		return entity.Passkey{}, fmt.Errorf("unable to finish passkey registration: %w", err)
+	}
+	passkey.Name = name
+
+	if err := u.repo.InsertPasskey(ctx, passkey); err != nil {
+		return entity.Passkey{}, fmt.Errorf("unable to insert passkey in repo: %w", err)
+	}
+
+	return passkey, nil
+}
+
+// BeginPasskeyLogin returns credential request options accepting any passkey
+// and the session id to finish the login with.
+func (u passkeyUsecase) BeginPasskeyLogin(ctx context.Context) (string, string, error) {
+	options, sessionData, err := u.rp.BeginLogin()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to begin passkey login: %w", err)
+	}
+
+	sessionID, err := u.startSession(ctx, nil, entity.WebAuthnLogin, sessionData)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(options), sessionID, nil
+}
+
+// FinishPasskeyLogin verifies the assertion of the authenticator and issues tokens to the owner of the passkey.
+func (u passkeyUsecase) FinishPasskeyLogin(ctx context.Context, sessionID, assertion string) (entity.TokenSet, error) {
+	session, err := u.useSession(ctx, sessionID, entity.WebAuthnLogin)
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	lookup := func(userID int64) (entity.User, []entity.Passkey, error) {
+		user, passkeys, err := u.userPasskeys(ctx, userID)
+		if errors.Is(err, entity.ErrNotFound) {
+			return entity.User{}, nil, fmt.Errorf("%w: unknown passkey", entity.ErrInvalidCredentials)
+		}
+		return user, passkeys, err
+	}
+	passkey, err := u.rp.FinishLogin(session.Data, []byte(assertion), lookup)
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to finish passkey login: %w", err)
+	}
+
+	updatedCount, err := u.repo.UpdatePasskeyUsage(ctx, passkey)
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to update passkey usage in repo: %w", err)
+	}
+	if updatedCount == 0 {
+		// the passkey is removed in the middle of login
+		return entity.TokenSet{}, fmt.Errorf("%w: unknown passkey", entity.ErrInvalidCredentials)
+	}
+
+	return u.sessions.StartClientSession(ctx, passkey.UserID, "", nil)
+}
+
+// ListPasskeys returns passkeys of the caller.
+func (u passkeyUsecase) ListPasskeys(ctx context.Context) ([]entity.Passkey, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := u.repo.ListUserPasskeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list passkeys in repo: %w", err)
+	}
+	return passkeys, nil
+}
+
+// RemovePasskey removes the passkey of the caller by its base64url encoded credential id.
+func (u passkeyUsecase) RemovePasskey(ctx context.Context, id string) (int64, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	credentialID, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: passkey id is not base64url encoded", entity.ErrValidation)
+	}
+
+	removedCount, err := u.repo.RemovePasskey(ctx, userID, credentialID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove passkey in repo: %w", err)
+	}
+	return removedCount, nil
+}
+
+func (u passkeyUsecase) userPasskeys(ctx context.Context, userID int64) (entity.User, []entity.Passkey, error) {
+	user, err := u.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, nil, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	passkeys, err := u.repo.ListUserPasskeys(ctx, userID)
+	if err != nil {
+		return entity.User{}, nil, fmt.Errorf("unable to list passkeys in repo: %w", err)
+	}
+
+	return user, passkeys, nil
+}
+
+// startSession stores session data of the ceremony and returns the session id, only its hash is stored.
+func (u passkeyUsecase) startSession(ctx context.Context, userID *int64, ceremony string, data []byte) (string, error) {
+	sessionID, err := newRandomString(webAuthnSessionIDLength)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.repo.InsertWebAuthnSession(ctx, entity.WebAuthnSession{
+		IDHash:    entity.HashToken(sessionID),
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Data:      data,
+		ExpiresAt: time.Now().Add(u.sessionExpirationDuration),
+	}); err != nil {
+		return "", fmt.Errorf("unable to insert webauthn session in repo: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// useSession returns the session of the ceremony, it can be used once.
+func (u passkeyUsecase) useSession(ctx context.Context, sessionID, ceremony string) (entity.WebAuthnSession, error) {
+	session, err := u.repo.UseWebAuthnSession(ctx, entity.HashToken(sessionID), ceremony)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.WebAuthnSession{}, fmt.Errorf("%w: webauthn session is invalid, used or expired", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.WebAuthnSession{}, fmt.Errorf("unable to use webauthn session in repo: %w", err)
+	}
+	return session, nil
+}
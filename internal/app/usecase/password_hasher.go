@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// PasswordHasher hashes passwords of users, encoded hashes carry the algorithm and its parameters.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(encoded, password string) error
+	// NeedsRehash reports whether the hash uses an outdated algorithm or parameters.
+	NeedsRehash(encoded string) bool
+}
+
+// hashPassword replaces the password of the user with its hash.
+func (u userUsecase) hashPassword(user *entity.User) error {
+	hash, err := u.hasher.Hash(user.Password)
+	if err != nil {
+		return fmt.Errorf("unable to hash password: %w", err)
+	}
+	user.Password = hash
+	return nil
+}
+
+// comparePassword checks the password against the hash stored for the user.
+func (u userUsecase) comparePassword(user entity.User, password string) error {
+	if err := u.hasher.Compare(user.Password, password); err != nil {
+		return entity.ErrInvalidCredentials
+	}
+	return nil
+}
+
+// tryRehashPassword replaces the outdated hash of the user once the password is checked,
+// so hashes migrate on sign-in. Failures are only logged, the old hash keeps working.
+func (u userUsecase) tryRehashPassword(ctx context.Context, user entity.User, password string) {
+	if !u.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		log.Printf("unable to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+	// the hash isn't replaced if the password is changed meanwhile
+	if _, err := u.repo.ReplaceUserPasswordHash(ctx, user.ID, user.Password, hash); err != nil {
+		log.Printf("unable to replace password hash of user %d: %v", user.ID, err)
+	}
+}
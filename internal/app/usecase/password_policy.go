@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// BreachedPasswords is a corpus of passwords known from data breaches.
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+// WithPasswordPolicy returns a copy of the usecase checking new passwords with the policy,
+// breached passwords are rejected too unless breached is nil.
+func (u userUsecase) WithPasswordPolicy(policy entity.PasswordPolicy, breached BreachedPasswords) userUsecase {
+	u.passwordPolicy = policy
+	u.breachedPasswords = breached
+	return u
+}
+
+// validatePassword checks the new password of the user, violations are reported for the request field.
+func (u userUsecase) validatePassword(field, password string, user entity.User) error {
+	descriptions := u.passwordPolicy.Violations(password, user)
+	if password != "" && u.breachedPasswords != nil && u.breachedPasswords.Contains(password) {
+		descriptions = append(descriptions, "password is known from data breaches, choose another one")
+	}
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	violations := make([]entity.FieldViolation, len(descriptions))
+	for i, description := range descriptions {
+		violations[i] = entity.FieldViolation{Field: field, Description: description}
+	}
+	return entity.ValidationError{Violations: violations}
+}
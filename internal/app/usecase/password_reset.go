@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const passwordResetTokenLength = 32
+
+// PasswordResetOptions configure recovery of accounts with forgotten passwords.
+type PasswordResetOptions struct {
+	TokenExpirationDuration time.Duration
+	// RequestInterval is the minimal interval between reset emails to the user.
+	RequestInterval time.Duration
+	// LinkURL is the page choosing a new password, the token is added as 'token' query parameter.
+	// The token itself is put to the email if it's empty.
+	LinkURL string
+}
+
+// WithPasswordReset returns a copy of the usecase resetting passwords with the options.
+func (u userUsecase) WithPasswordReset(opts PasswordResetOptions) userUsecase {
+	u.passwordReset = opts
+	return u
+}
+
+// RequestPasswordReset sends the password reset email to the user with the email.
+// It succeeds the same way for unknown emails and throttled requests, so it doesn't reveal registered emails.
+func (u userUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	if err := entity.ValidateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := u.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	token, err := newRandomString(passwordResetTokenLength)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(u.passwordReset.TokenExpirationDuration)
+
+	insertedCount, err := u.resetTokens.InsertPasswordResetToken(ctx, entity.PasswordResetToken{
+		TokenHash: entity.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}, u.passwordReset.RequestInterval)
+	if err != nil {
+		return fmt.Errorf("unable to insert password reset token in repo: %w", err)
+	}
+	if insertedCount == 0 {
+		return nil
+	}
+
+	data, err := newTokenEmail(user.Name, token, u.passwordReset.LinkURL, expiresAt)
+	if err != nil {
+		return err
+	}
+	// delivery failures are not returned, they would reveal the email is registered
+	if err := u.sendEmail(ctx, user.Email, resetPasswordTemplate, data); err != nil {
+		log.Printf("unable to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with the token from the password reset email.
+// Sessions of the user are revoked, so whoever knew the old password is signed out.
+func (u userUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	tokenHash := entity.HashToken(token)
+	resetToken, err := u.resetTokens.GetPasswordResetToken(ctx, tokenHash)
+	if errors.Is(err, entity.ErrNotFound) {
+		return fmt.Errorf("%w: password reset token is invalid, used or expired", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get password reset token from repo: %w", err)
+	}
+
+	// the password is checked before the token is used, so a rejected password doesn't waste the token
+	user, err := u.repo.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("unable to get user from repo: %w", err)
+	}
+	if err := u.validatePassword("new_password", newPassword, user); err != nil {
+		return err
+	}
+
+	if _, err := u.resetTokens.UsePasswordResetToken(ctx, tokenHash); err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: password reset token is invalid, used or expired", entity.ErrInvalidCredentials)
+		}
+		return fmt.Errorf("unable to use password reset token in repo: %w", err)
+	}
+
+	updatedUser := entity.User{ID: user.ID, Password: newPassword}
+	if err := u.hashPassword(&updatedUser); err != nil {
+		return err
+	}
+	if _, err := u.repo.UpdateUserByID(ctx, updatedUser); err != nil {
+		return fmt.Errorf("unable to update user in repo: %w", err)
+	}
+
+	// other emails sent before are useless now
+	if _, err := u.resetTokens.RemoveUserPasswordResetTokens(ctx, user.ID); err != nil {
+		return fmt.Errorf("unable to remove password reset tokens in repo: %w", err)
+	}
+
+	return u.revokeUserSessions(ctx, user.ID)
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// newRandomString returns hex encoded random bytes of the given length,
+// used for generated identifiers and secrets.
+func newRandomString(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate random string: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/ziyadovea/task_manager/users/internal/app/entity"
 )
@@ -14,4 +15,105 @@ type UserRepository interface {
 	ListUsers(ctx context.Context) ([]entity.User, error)
 	UpdateUserByID(ctx context.Context, user entity.User) (int64, error)
 	RemoveUserByID(ctx context.Context, id int64) (int64, error)
+	MarkEmailVerificationSent(ctx context.Context, id int64, resendInterval time.Duration) (int64, error)
+	VerifyUserEmail(ctx context.Context, id int64, email string) (int64, error)
+	ReplaceUserPasswordHash(ctx context.Context, id int64, oldHash, newHash string) (int64, error)
+}
+
+type RoleRepository interface {
+	InsertRole(ctx context.Context, role entity.Role) (entity.Role, error)
+	InsertUserRole(ctx context.Context, userID int64, roleName string) error
+	RemoveUserRole(ctx context.Context, userID int64, roleName string) (int64, error)
+	ListUserRoles(ctx context.Context, userID int64) ([]string, error)
+	ListRolesPermissions(ctx context.Context, roles []string) ([]string, error)
+}
+
+type RefreshTokenRepository interface {
+	InsertRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	GetRefreshToken(ctx context.Context, id string) (entity.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, id string, clientID string) (entity.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) (int64, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) (int64, error)
+}
+
+type RevocationRepository interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID int64, before time.Time) error
+	KeepUserTokens(ctx context.Context, userID int64, tokenIDs []string) error
+}
+
+type ClientRepository interface {
+	InsertClient(ctx context.Context, client entity.Client) error
+	GetClient(ctx context.Context, id string) (entity.Client, error)
+	ListClients(ctx context.Context) ([]entity.Client, error)
+	UpdateClient(ctx context.Context, client entity.Client) (int64, error)
+	UpdateClientSecret(ctx context.Context, id string, secretHash string) (int64, error)
+	RemoveClient(ctx context.Context, id string) (int64, error)
+}
+
+type AuthorizationCodeRepository interface {
+	InsertAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error
+	UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error)
+}
+
+type IdentityRepository interface {
+	InsertIdentity(ctx context.Context, identity entity.Identity) error
+	GetIdentity(ctx context.Context, provider, subject string) (entity.Identity, error)
+}
+
+type MFARepository interface {
+	UpsertTOTP(ctx context.Context, userID int64, secret string) error
+	GetTOTP(ctx context.Context, userID int64) (entity.TOTP, error)
+	UseTOTPStep(ctx context.Context, userID int64, step int64) (int64, error)
+	ConfirmTOTP(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	RemoveTOTP(ctx context.Context, userID int64) (int64, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (int64, error)
+}
+
+type MFAChallengeRepository interface {
+	InsertMFAChallenge(ctx context.Context, challenge entity.MFAChallenge) error
+	AttemptMFAChallenge(ctx context.Context, tokenHash string, maxAttempts int) (entity.MFAChallenge, error)
+	RemoveMFAChallenge(ctx context.Context, tokenHash string) (int64, error)
+}
+
+type PasskeyRepository interface {
+	InsertPasskey(ctx context.Context, passkey entity.Passkey) error
+	ListUserPasskeys(ctx context.Context, userID int64) ([]entity.Passkey, error)
+	UpdatePasskeyUsage(ctx context.Context, passkey entity.Passkey) (int64, error)
+	RemovePasskey(ctx context.Context, userID int64, id []byte) (int64, error)
+	InsertWebAuthnSession(ctx context.Context, session entity.WebAuthnSession) error
+	UseWebAuthnSession(ctx context.Context, idHash, ceremony string) (entity.WebAuthnSession, error)
+}
+
+type PasswordResetRepository interface {
+	InsertPasswordResetToken(ctx context.Context, token entity.PasswordResetToken, requestInterval time.Duration) (int64, error)
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (entity.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (entity.PasswordResetToken, error)
+	RemoveUserPasswordResetTokens(ctx context.Context, userID int64) (int64, error)
+}
+
+type LoginThrottleRepository interface {
+	GetLoginBlockedUntil(ctx context.Context, keys []string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, key string, failureWindow time.Duration) (int, error)
+	BlockLogin(ctx context.Context, key string, until time.Time) (int64, error)
+	RemoveLoginFailures(ctx context.Context, key string) (int64, error)
+}
+
+type APIKeyRepository interface {
+	InsertAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	GetAPIKey(ctx context.Context, id string) (entity.APIKey, error)
+	ListUserAPIKeys(ctx context.Context, userID int64) ([]entity.APIKey, error)
+	UpdateAPIKeyUsage(ctx context.Context, id string, interval time.Duration) (int64, error)
+	RemoveAPIKey(ctx context.Context, userID int64, id string) (int64, error)
+}
+
+type ServiceAccountRepository interface {
+	InsertServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, id int64) (entity.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, account entity.ServiceAccount) (int64, error)
+	UpdateServiceAccountSecret(ctx context.Context, id int64, secretHash string) (int64, error)
+	RemoveServiceAccount(ctx context.Context, id int64) (int64, error)
+	InsertServiceAccountRole(ctx context.Context, id int64, roleName string) error
+	RemoveServiceAccountRole(ctx context.Context, id int64, roleName string) (int64, error)
 }
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+type roleUsecase struct {
+	repo RoleRepository
+}
+
+func NewRoleUsecase(repo RoleRepository) roleUsecase {
+	return roleUsecase{
+		repo: repo,
+	}
+}
+
+func (u roleUsecase) CreateRole(ctx context.Context, role entity.Role) (entity.Role, error) {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return entity.Role{}, err
+	}
+
+	if err := role.Validate(); err != nil {
+		return entity.Role{}, fmt.Errorf("invalid role: %w", err)
+	}
+
+	insertedRole, err := u.repo.InsertRole(ctx, role)
+	if err != nil {
+		return entity.Role{}, fmt.Errorf("unable to insert role in repo: %w", err)
+	}
+
+	return insertedRole, nil
+}
+
+func (u roleUsecase) GrantRole(ctx context.Context, userID int64, roleName string) error {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return err
+	}
+
+	if err := u.repo.InsertUserRole(ctx, userID, roleName); err != nil {
+		return fmt.Errorf("unable to insert user role in repo: %w", err)
+	}
+
+	return nil
+}
+
+func (u roleUsecase) RevokeRole(ctx context.Context, userID int64, roleName string) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return 0, err
+	}
+	return u.repo.RemoveUserRole(ctx, userID, roleName)
+}
+
+// ListUserPermissions returns roles of the user and effective permissions granted by them.
+func (u roleUsecase) ListUserPermissions(ctx context.Context, userID int64) ([]string, []string, error) {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return nil, nil, err
+	}
+
+	roles, err := u.repo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list user roles from repo: %w", err)
+	}
+
+	permissions, err := u.PermissionsForRoles(ctx, roles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return roles, permissions, nil
+}
+
+// PermissionsForRoles returns effective permissions granted by roles,
+// it's used to authorize callers by roles from their tokens.
+func (u roleUsecase) PermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	permissions, err := u.repo.ListRolesPermissions(ctx, roles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list roles permissions from repo: %w", err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const serviceAccountSecretLength = 32
+
+// serviceAccountUsecase manages service accounts and issues access tokens to them.
+type serviceAccountUsecase struct {
+	repo          ServiceAccountRepository
+	roleRepo      RoleRepository
+	revocations   RevocationRepository
+	authenticator Authenticator
+	assertions    AssertionVerifier
+}
+
+func NewServiceAccountUsecase(
+	repo ServiceAccountRepository,
+	roleRepo RoleRepository,
+	revocations RevocationRepository,
+	authenticator Authenticator,
+	assertions AssertionVerifier,
+) serviceAccountUsecase {
+	return serviceAccountUsecase{
+		repo:          repo,
+		roleRepo:      roleRepo,
+		revocations:   revocations,
+		authenticator: authenticator,
+		assertions:    assertions,
+	}
+}
+
+// CreateServiceAccount creates the account owned by the caller with generated secret,
+// the secret is stored hashed, so it's returned only here. The account has no roles yet.
+func (u serviceAccountUsecase) CreateServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, string, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return entity.ServiceAccount{}, "", err
+	}
+	ownerID, err := callerUserID(ctx)
+	if err != nil {
+		return entity.ServiceAccount{}, "", err
+	}
+
+	if err := account.Validate(); err != nil {
+		return entity.ServiceAccount{}, "", fmt.Errorf("invalid service account: %w", err)
+	}
+	if account.PublicKey != "" {
+		if err := u.assertions.ValidatePublicKey(account.PublicKey); err != nil {
+			return entity.ServiceAccount{}, "", fmt.Errorf("invalid service account public key: %w", err)
+		}
+	}
+
+	secret, err := newRandomString(serviceAccountSecretLength)
+	if err != nil {
+		return entity.ServiceAccount{}, "", err
+	}
+
+	account.OwnerID = ownerID
+	account.SecretHash = entity.HashToken(secret)
+	createdAccount, err := u.repo.InsertServiceAccount(ctx, account)
+	if err != nil {
+		return entity.ServiceAccount{}, "", fmt.Errorf("unable to insert service account in repo: %w", err)
+	}
+
+	return createdAccount, secret, nil
+}
+
+func (u serviceAccountUsecase) GetServiceAccount(ctx context.Context, id int64) (entity.ServiceAccount, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return entity.ServiceAccount{}, err
+	}
+
+	account, err := u.repo.GetServiceAccount(ctx, id)
+	if err != nil {
+		return entity.ServiceAccount{}, fmt.Errorf("unable to get service account from repo: %w", err)
+	}
+
+	return account, nil
+}
+
+func (u serviceAccountUsecase) ListServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return nil, err
+	}
+
+	accounts, err := u.repo.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list service accounts from repo: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// UpdateServiceAccount updates the description and the public key of the account, an empty key removes it.
+// Assertions signed by the previous key are rejected right away.
+func (u serviceAccountUsecase) UpdateServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return entity.ServiceAccount{}, err
+	}
+
+	stored, err := u.repo.GetServiceAccount(ctx, account.ID)
+	if err != nil {
+		return entity.ServiceAccount{}, fmt.Errorf("unable to get service account from repo: %w", err)
+	}
+
+	stored.Description = account.Description
+	stored.PublicKey = account.PublicKey
+	if err := stored.Validate(); err != nil {
+		return entity.ServiceAccount{}, fmt.Errorf("invalid service account: %w", err)
+	}
+	if stored.PublicKey != "" {
+		if err := u.assertions.ValidatePublicKey(stored.PublicKey); err != nil {
+			return entity.ServiceAccount{}, fmt.Errorf("invalid service account public key: %w", err)
+		}
+	}
+
+	updatedCount, err := u.repo.UpdateServiceAccount(ctx, stored)
+	if err != nil {
+		return entity.ServiceAccount{}, fmt.Errorf("unable to update service account in repo: %w", err)
+	}
+	if updatedCount == 0 {
+		return entity.ServiceAccount{}, fmt.Errorf("%w: service account %d", entity.ErrNotFound, account.ID)
+	}
+
+	return stored, nil
+}
+
+// RotateServiceAccountSecret replaces the secret of the account with a new generated one.
+func (u serviceAccountUsecase) RotateServiceAccountSecret(ctx context.Context, id int64) (string, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return "", err
+	}
+
+	secret, err := newRandomString(serviceAccountSecretLength)
+	if err != nil {
+		return "", err
+	}
+
+	updatedCount, err := u.repo.UpdateServiceAccountSecret(ctx, id, entity.HashToken(secret))
+	if err != nil {
+		return "", fmt.Errorf("unable to update service account secret in repo: %w", err)
+	}
+	if updatedCount == 0 {
+		return "", fmt.Errorf("%w: service account %d", entity.ErrNotFound, id)
+	}
+
+	return secret, nil
+}
+
+// RemoveServiceAccount removes the account with its roles,
+// access tokens issued to it are rejected by ServiceAccountPrincipal right away.
+func (u serviceAccountUsecase) RemoveServiceAccount(ctx context.Context, id int64) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionServiceAccountsManage); err != nil {
+		return 0, err
+	}
+
+	removedCount, err := u.repo.RemoveServiceAccount(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove service account in repo: %w", err)
+	}
+
+	return removedCount, nil
+}
+
+func (u serviceAccountUsecase) GrantServiceAccountRole(ctx context.Context, id int64, roleName string) error {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return err
+	}
+
+	if err := u.repo.InsertServiceAccountRole(ctx, id, roleName); err != nil {
+		return fmt.Errorf("unable to insert service account role in repo: %w", err)
+	}
+
+	return nil
+}
+
+func (u serviceAccountUsecase) RevokeServiceAccountRole(ctx context.Context, id int64, roleName string) (int64, error) {
+	if err := requirePermission(ctx, entity.PermissionRolesManage); err != nil {
+		return 0, err
+	}
+
+	revokedCount, err := u.repo.RemoveServiceAccountRole(ctx, id, roleName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to remove service account role in repo: %w", err)
+	}
+
+	return revokedCount, nil
+}
+
+// ServiceAccountPrincipal returns the principal of the access token issued to a service account.
+// Roles are those the account has now rather than those in the token,
+// so removing the account or revoking its roles applies to issued tokens right away.
+func (u serviceAccountUsecase) ServiceAccountPrincipal(ctx context.Context, claims entity.TokenClaims) (entity.Principal, error) {
+	account, err := u.repo.GetServiceAccount(ctx, claims.ServiceAccountID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.Principal{}, fmt.Errorf("%w: service account is removed", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("unable to get service account from repo: %w", err)
+	}
+
+	var permissions []string
+	if len(account.Roles) > 0 {
+		permissions, err = u.roleRepo.ListRolesPermissions(ctx, account.Roles)
+		if err != nil {
+			return entity.Principal{}, fmt.Errorf("unable to list roles permissions from repo: %w", err)
+		}
+	}
+
+	return entity.Principal{
+		ServiceAccountID: account.ID,
+		PrincipalType:    entity.PrincipalTypeServiceAccount,
+		Roles:            account.Roles,
+		Permissions:      permissions,
+		TokenID:          claims.ID,
+		TokenExpiresAt:   claims.ExpiresAt,
+	}, nil
+}
+
+// IssueServiceAccountToken authenticates the account either with its secret or with the assertion
+// signed by its private key and issues access token with its roles.
+// There is no refresh token, the account authenticates again once the token expires.
+func (u serviceAccountUsecase) IssueServiceAccountToken(ctx context.Context, id int64, secret, assertion string) (string, entity.TokenClaims, error) {
+	if (secret == "") == (assertion == "") {
+		return "", entity.TokenClaims{}, fmt.Errorf("%w: either client secret or assertion is required", entity.ErrValidation)
+	}
+
+	account, err := u.repo.GetServiceAccount(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		return "", entity.TokenClaims{}, fmt.Errorf("%w: unknown service account", entity.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to get service account from repo: %w", err)
+	}
+
+	if secret != "" {
+		if account.SecretHash == "" ||
+			subtle.ConstantTimeCompare([]byte(entity.HashToken(secret)), []byte(account.SecretHash)) != 1 {
+			return "", entity.TokenClaims{}, fmt.Errorf("%w: invalid service account secret", entity.ErrInvalidCredentials)
+		}
+	} else {
+		assertionClaims, err := u.assertions.VerifyAssertion(ctx, assertion, account)
+		if err != nil {
+			return "", entity.TokenClaims{}, fmt.Errorf("unable to verify assertion: %w", err)
+		}
+		// the assertion is accepted once, it stays in the deny list until it expires
+		if err := u.revocations.RevokeToken(ctx, assertionClaims.ID, assertionClaims.ExpiresAt); err != nil {
+			return "", entity.TokenClaims{}, fmt.Errorf("unable to revoke assertion in repo: %w", err)
+		}
+	}
+
+	accessToken, claims, err := u.authenticator.CreateAccessToken(entity.TokenClaims{
+		ServiceAccountID: account.ID,
+		PrincipalType:    entity.PrincipalTypeServiceAccount,
+		Roles:            account.Roles,
+	})
+	if err != nil {
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to create access token: %w", err)
+	}
+
+	return accessToken, claims, nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// Logout ends the current session: the access token of the caller is revoked
+// and so is the family of the refresh token, if one is given.
+func (u userUsecase) Logout(ctx context.Context, refreshToken string) error {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no authenticated principal", entity.ErrInvalidCredentials)
+	}
+
+	if principal.TokenID != "" {
+		if err := u.revocations.RevokeToken(ctx, principal.TokenID, principal.TokenExpiresAt); err != nil {
+			return fmt.Errorf("unable to revoke access token in repo: %w", err)
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	claims, err := u.authenticator.VerifyRefreshToken(ctx, refreshToken)
+	if errors.Is(err, entity.ErrInvalidCredentials) {
+		// already invalid, nothing to revoke
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to verify refresh token: %w", err)
+	}
+	if claims.UserID != principal.UserID {
+		return fmt.Errorf("%w: refresh token belongs to another user", entity.ErrPermissionDenied)
+	}
+
+	storedToken, err := u.tokenRepo.GetRefreshToken(ctx, claims.ID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get refresh token from repo: %w", err)
+	}
+
+	if _, err := u.tokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+		return fmt.Errorf("unable to revoke refresh token family in repo: %w", err)
+	}
+
+	return nil
+}
+
+// LogoutAllSessions ends all sessions of the caller on all devices.
+func (u userUsecase) LogoutAllSessions(ctx context.Context) error {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no authenticated principal", entity.ErrInvalidCredentials)
+	}
+	return u.revokeUserSessions(ctx, principal.UserID)
+}
+
+// revokeUserSessions invalidates all access and refresh tokens issued to the user so far.
+// Tokens keep issue time in seconds, so the watermark is the end of the current second
+// and tokens issued later within it are revoked too, unless they are kept by KeepUserTokens.
+// API keys are not sessions, they are kept until the user removes them or the account is removed.
+func (u userUsecase) revokeUserSessions(ctx context.Context, userID int64) error {
+	before := time.Now().Truncate(time.Second).Add(time.Second)
+	if err := u.revocations.RevokeUserTokens(ctx, userID, before); err != nil {
+		return fmt.Errorf("unable to revoke user tokens in repo: %w", err)
+	}
+
+	if _, err := u.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("unable to revoke user refresh tokens in repo: %w", err)
+	}
+
+	return nil
+}
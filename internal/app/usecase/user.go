@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,15 +22,66 @@ var userRegistrationDuration = promauto.NewSummary(
 	},
 )
 
+// prometheus metric to count detected refresh token reuses
+var refreshTokenReuseCount = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "user_usecase_refresh_token_reuse_total",
+		Help: "Count of already used refresh tokens presented again, each one revokes the token family",
+	},
+)
+
 type userUsecase struct {
-	repo          UserRepository
-	authenticator Authenticator
+	repo                           UserRepository
+	roleRepo                       RoleRepository
+	tokenRepo                      RefreshTokenRepository
+	revocations                    RevocationRepository
+	mfaRepo                        MFARepository
+	challenges                     MFAChallengeRepository
+	resetTokens                    PasswordResetRepository
+	authenticator                  Authenticator
+	hasher                         PasswordHasher
+	dummyPasswordHash              string
+	claimsHooks                    []ClaimsHook
+	mfaChallengeExpirationDuration time.Duration
+	mailer                         Mailer
+	emailVerification              EmailVerificationOptions
+	passwordReset                  PasswordResetOptions
+	passwordPolicy                 entity.PasswordPolicy
+	breachedPasswords              BreachedPasswords
+	loginThrottles                 LoginThrottleRepository
+	loginThrottling                LoginThrottlingOptions
 }
 
-func NewUserUsecase(repo UserRepository, authenticator Authenticator) userUsecase {
+func NewUserUsecase(
+	repo UserRepository,
+	roleRepo RoleRepository,
+	tokenRepo RefreshTokenRepository,
+	revocations RevocationRepository,
+	mfaRepo MFARepository,
+	challenges MFAChallengeRepository,
+	resetTokens PasswordResetRepository,
+	authenticator Authenticator,
+	hasher PasswordHasher,
+	mfaChallengeExpirationDuration time.Duration,
+) userUsecase {
+	// unknown users are compared with it, so sign-in takes as long as for registered ones
+	dummyPasswordHash, err := hasher.Hash("dummy password")
+	if err != nil {
+		log.Printf("unable to hash dummy password: %v", err)
+	}
+
 	return userUsecase{
-		repo:          repo,
-		authenticator: authenticator,
+		repo:                           repo,
+		roleRepo:                       roleRepo,
+		tokenRepo:                      tokenRepo,
+		revocations:                    revocations,
+		mfaRepo:                        mfaRepo,
+		challenges:                     challenges,
+		resetTokens:                    resetTokens,
+		authenticator:                  authenticator,
+		hasher:                         hasher,
+		dummyPasswordHash:              dummyPasswordHash,
+		mfaChallengeExpirationDuration: mfaChallengeExpirationDuration,
 	}
 }
 
@@ -43,9 +95,26 @@ func (u userUsecase) RegisterUser(ctx context.Context, user entity.User) (entity
 	if err := user.Validate(); err != nil {
 		return entity.User{}, fmt.Errorf("invalid user: %w", err)
 	}
+	if err := u.validatePassword("password", user.Password, user); err != nil {
+		return entity.User{}, fmt.Errorf("invalid user: %w", err)
+	}
+
+	return u.insertUser(ctx, user)
+}
 
-	if err := user.HashPassword(); err != nil {
-		return entity.User{}, fmt.Errorf("unable to hash password: %w", err)
+// ProvisionUser registers the user signing in with an identity provider.
+// Its password is random and never used, so the password policy is not applied.
+func (u userUsecase) ProvisionUser(ctx context.Context, user entity.User) (entity.User, error) {
+	if err := user.Validate(); err != nil {
+		return entity.User{}, fmt.Errorf("invalid user: %w", err)
+	}
+
+	return u.insertUser(ctx, user)
+}
+
+func (u userUsecase) insertUser(ctx context.Context, user entity.User) (entity.User, error) {
+	if err := u.hashPassword(&user); err != nil {
+		return entity.User{}, err
 	}
 
 	insertedUser, err := u.repo.InsertUser(ctx, user)
@@ -53,99 +122,413 @@ func (u userUsecase) RegisterUser(ctx context.Context, user entity.User) (entity
 		return entity.User{}, fmt.Errorf("unable to insert user in repo: %w", err)
 	}
 
+	if !insertedUser.EmailVerified {
+		u.trySendVerificationEmail(ctx, insertedUser)
+	}
+
 	return insertedUser, nil
 }
 
-func (u userUsecase) AuthenticateUser(ctx context.Context, user entity.User) (string, string, error) {
-	var repoUser entity.User
-	if user.Name != "" {
-		u, err := u.repo.GetUserByName(ctx, user.Name)
+// AuthenticateUser signs the user in with the password.
+// Users with enrolled second factor get MFA token instead of access and refresh tokens,
+// tokens are issued by VerifyMFA then.
+func (u userUsecase) AuthenticateUser(ctx context.Context, user entity.User) (string, string, string, error) {
+	repoUser, err := u.VerifyUserCredentials(ctx, user)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	mfaToken, err := u.StartMFAChallenge(ctx, repoUser.ID)
+	if err != nil {
+		return "", "", "", err
+	}
+	if mfaToken != "" {
+		return "", "", mfaToken, nil
+	}
+
+	tokens, err := u.StartClientSession(ctx, repoUser.ID, "", nil)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return tokens.AccessToken, tokens.RefreshToken, "", nil
+}
+
+// VerifyMFA completes sign-in with TOTP or recovery code for the MFA token of AuthenticateUser.
+func (u userUsecase) VerifyMFA(ctx context.Context, mfaToken, code string) (string, string, error) {
+	userID, err := u.CompleteMFAChallenge(ctx, mfaToken, code)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokens, err := u.StartClientSession(ctx, userID, "", nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokens.AccessToken, tokens.RefreshToken, nil
+}
+
+// VerifyUserCredentials returns the user with the given name or email if the password matches.
+// Every sign-in flow checks credentials here and continues with StartMFAChallenge,
+// failures of the account are forgotten there or by CompleteMFAChallenge once sign-in is complete.
+func (u userUsecase) VerifyUserCredentials(ctx context.Context, user entity.User) (entity.User, error) {
+	var (
+		repoUser entity.User
+		err      error
+	)
+	switch {
+	case user.Name != "":
+		repoUser, err = u.repo.GetUserByName(ctx, user.Name)
+	case user.Email != "":
+		repoUser, err = u.repo.GetUserByEmail(ctx, user.Email)
+	default:
+		return entity.User{}, fmt.Errorf("%w: empty name and email", entity.ErrValidation)
+	}
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return entity.User{}, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+	found := err == nil
+
+	throttleKeys := u.loginThrottleKeys(ctx, user, repoUser.ID)
+	if err := u.checkLoginThrottle(ctx, throttleKeys); err != nil {
+		return entity.User{}, err
+	}
+
+	// do not reveal whether the user exists, neither by the error nor by the response time
+	if !found {
+		_ = u.hasher.Compare(u.dummyPasswordHash, user.Password)
+		return entity.User{}, u.loginFailed(ctx, throttleKeys)
+	}
+	if err := u.comparePassword(repoUser, user.Password); err != nil {
+		return entity.User{}, u.loginFailed(ctx, throttleKeys)
+	}
+	u.tryRehashPassword(ctx, repoUser, user.Password)
+
+	// checked after the password, so it doesn't reveal registered emails,
+	// StartClientSession checks it again for sign-in without password
+	if u.emailVerification.Required && !repoUser.EmailVerified {
+		return entity.User{}, entity.ErrEmailNotVerified
+	}
+
+	return repoUser, nil
+}
+
+// StartClientSession issues tokens of a new family to the signed in user,
+// empty clientID stands for tokens issued by the service itself.
+// Every sign-in ends here whatever the credentials are: password, passkey or upstream identity provider,
+// so users with unverified email are refused here while verification is required.
+func (u userUsecase) StartClientSession(ctx context.Context, userID int64, clientID string, scopes []string) (entity.TokenSet, error) {
+	if u.emailVerification.Required {
+		user, err := u.repo.GetUserByID(ctx, userID)
 		if err != nil {
-			return "", "", fmt.Errorf("unable to get user by name from repo: %w", err)
+			return entity.TokenSet{}, fmt.Errorf("unable to get user from repo: %w", err)
 		}
-		repoUser = u
-	} else if user.Email != "" {
-		u, err := u.repo.GetUserByEmail(ctx, user.Email)
-		if err != nil {
-			return "", "", fmt.Errorf("unable to get user by email from repo: %w", err)
+		if !user.EmailVerified {
+			return entity.TokenSet{}, entity.ErrEmailNotVerified
 		}
-		repoUser = u
 	}
 
-	if err := repoUser.ComparePassword(user.Password); err != nil {
-		return "", "", fmt.Errorf("invalid password: %w", err)
+	return u.issueTokens(ctx, entity.RefreshToken{
+		UserID:   userID,
+		ClientID: clientID,
+		Scopes:   scopes,
+	})
+}
+
+// RefreshUserToken rotates refresh token: the presented token is invalidated
+// and a new access and refresh token pair of the same family is returned.
+// Presenting an already used token revokes the whole family,
+// because either the legitimate user or an attacker holds a stolen copy.
+func (u userUsecase) RefreshUserToken(ctx context.Context, refreshToken string) (string, string, error) {
+	tokens, err := u.RefreshClientSession(ctx, "", refreshToken)
+	if err != nil {
+		return "", "", err
 	}
+	return tokens.AccessToken, tokens.RefreshToken, nil
+}
 
-	accessToken, err := u.authenticator.CreateAccessToken(repoUser.ID)
+// RefreshClientSession rotates refresh token issued to the OAuth2 client as RefreshUserToken does,
+// empty clientID stands for tokens issued by the service itself.
+func (u userUsecase) RefreshClientSession(ctx context.Context, clientID string, refreshToken string) (entity.TokenSet, error) {
+	claims, err := u.authenticator.VerifyRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return "", "", fmt.Errorf("unable to create access token: %w", err)
+		return entity.TokenSet{}, fmt.Errorf("unable to verify refresh token: %w", err)
 	}
 
-	refreshToken, err := u.authenticator.CreateRefreshToken(repoUser.ID)
+	storedToken, err := u.tokenRepo.UseRefreshToken(ctx, claims.ID, clientID)
+	if errors.Is(err, entity.ErrNotFound) {
+		if err := u.detectRefreshTokenReuse(ctx, claims.ID); err != nil {
+			return entity.TokenSet{}, err
+		}
+		return entity.TokenSet{}, fmt.Errorf("%w: refresh token is already used or revoked", entity.ErrInvalidCredentials)
+	}
 	if err != nil {
-		return "", "", fmt.Errorf("unable to create refresh token: %w", err)
+		return entity.TokenSet{}, fmt.Errorf("unable to use refresh token in repo: %w", err)
 	}
 
-	return accessToken, refreshToken, nil
+	return u.issueTokens(ctx, storedToken)
 }
 
-func (u userUsecase) RefreshUserToken(ctx context.Context, refreshToken string) (string, error) {
-	userID, err := u.authenticator.VerifyRefreshToken(refreshToken)
+// detectRefreshTokenReuse revokes token family if the token was used before.
+func (u userUsecase) detectRefreshTokenReuse(ctx context.Context, tokenID string) error {
+	storedToken, err := u.tokenRepo.GetRefreshToken(ctx, tokenID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("unable to verify refresh token: %w", err)
+		return fmt.Errorf("unable to get refresh token from repo: %w", err)
 	}
 
-	accessToken, err := u.authenticator.CreateAccessToken(userID)
-	if err != nil {
-		return "", fmt.Errorf("unable to create access token: %w", err)
+	if storedToken.UsedAt == nil {
+		return nil
 	}
 
-	return accessToken, nil
+	refreshTokenReuseCount.Inc()
+	log.Printf("refresh token %s reuse detected, revoking family %s of user %d", storedToken.ID, storedToken.FamilyID, storedToken.UserID)
+
+	if _, err := u.tokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+		return fmt.Errorf("unable to revoke refresh token family in repo: %w", err)
+	}
+
+	return nil
 }
 
-func (u userUsecase) ValidateUserToken(ctx context.Context, token string) (int64, error) {
-	return u.authenticator.VerifyAccessToken(token)
+func (u userUsecase) ValidateUserToken(ctx context.Context, token string) (entity.TokenClaims, error) {
+	claims, err := u.authenticator.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("unable to verify access token: %w", err)
+	}
+	return claims, nil
 }
 
+// UpdateUser updates name and email of the user, the password is changed by ChangePassword only.
 func (u userUsecase) UpdateUser(ctx context.Context, user entity.User) (int64, error) {
-	if err := user.HashPassword(); err != nil {
-		return 0, fmt.Errorf("unable to hash password: %w", err)
+	if err := authorizeUserAccess(ctx, user.ID); err != nil {
+		return 0, err
+	}
+
+	if user.Password != "" {
+		return 0, fmt.Errorf("%w: password is changed by ChangePassword", entity.ErrValidation)
+	}
+	if user.Name == "" && user.Email == "" {
+		return 0, fmt.Errorf("%w: empty name and email", entity.ErrValidation)
+	}
+	if user.Email != "" {
+		if err := entity.ValidateEmail(user.Email); err != nil {
+			return 0, err
+		}
 	}
-	return u.repo.UpdateUserByID(ctx, user)
+
+	updatedCount, err := u.repo.UpdateUserByID(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+
+	// a changed email must be verified again
+	if user.Email != "" && updatedCount > 0 {
+		updatedUser, err := u.repo.GetUserByID(ctx, user.ID)
+		if err != nil {
+			return 0, fmt.Errorf("unable to get user from repo: %w", err)
+		}
+		if !updatedUser.EmailVerified {
+			u.trySendVerificationEmail(ctx, updatedUser)
+		}
+	}
+
+	return updatedCount, nil
+}
+
+// ChangePassword sets a new password of the caller if the current one matches,
+// mismatches are counted against the account and the client IP as failed sign-in.
+// All sessions of the user are revoked, the caller continues with the returned tokens.
+func (u userUsecase) ChangePassword(ctx context.Context, currentPassword, newPassword string) (entity.TokenSet, error) {
+	userID, err := callerUserID(ctx)
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to get user from repo: %w", err)
+	}
+
+	// a stolen access token must not help guessing the password, so guesses are throttled as sign-in
+	throttleKeys := u.loginThrottleKeys(ctx, entity.User{}, userID)
+	if err := u.checkLoginThrottle(ctx, throttleKeys); err != nil {
+		return entity.TokenSet{}, err
+	}
+	if err := u.comparePassword(user, currentPassword); err != nil {
+		return entity.TokenSet{}, u.loginFailed(ctx, throttleKeys)
+	}
+
+	if err := u.validatePassword("new_password", newPassword, user); err != nil {
+		return entity.TokenSet{}, err
+	}
+	if newPassword == currentPassword {
+		return entity.TokenSet{}, fmt.Errorf("%w: new password is the same as the current one", entity.ErrValidation)
+	}
+
+	updatedUser := entity.User{ID: userID, Password: newPassword}
+	if err := u.hashPassword(&updatedUser); err != nil {
+		return entity.TokenSet{}, err
+	}
+	if _, err := u.repo.UpdateUserByID(ctx, updatedUser); err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to update user in repo: %w", err)
+	}
+
+	// reset emails sent before would undo the change
+	if _, err := u.resetTokens.RemoveUserPasswordResetTokens(ctx, userID); err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to remove password reset tokens in repo: %w", err)
+	}
+
+	// sessions started with the old password must end
+	if err := u.revokeUserSessions(ctx, userID); err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	tokens, err := u.issueTokens(ctx, entity.RefreshToken{UserID: userID})
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+	// the new tokens are issued within the second of the watermark, they would be revoked with the old ones
+	if err := u.revocations.KeepUserTokens(ctx, userID, []string{tokens.Claims.ID, tokens.RefreshTokenID}); err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to keep user tokens in repo: %w", err)
+	}
+
+	return tokens, nil
 }
 
 func (u userUsecase) RemoveUser(ctx context.Context, user entity.User) (int64, error) {
-	return u.repo.RemoveUserByID(ctx, user.ID)
+	if err := authorizeUserAccess(ctx, user.ID); err != nil {
+		return 0, err
+	}
+
+	removedCount, err := u.repo.RemoveUserByID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if removedCount > 0 {
+		if err := u.revokeUserSessions(ctx, user.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return removedCount, nil
 }
 
 func (u userUsecase) GetUser(ctx context.Context, user entity.User) (entity.User, error) {
-	if user.ID != 0 {
-		repoUser, err := u.repo.GetUserByID(ctx, user.ID)
+	var (
+		repoUser entity.User
+		err      error
+	)
+	switch {
+	case user.ID != 0:
+		// authorize before lookup, so existence of other users is not revealed
+		if err := authorizeUserAccess(ctx, user.ID); err != nil {
+			return entity.User{}, err
+		}
+		repoUser, err = u.repo.GetUserByID(ctx, user.ID)
 		if err != nil {
 			return entity.User{}, fmt.Errorf("unable to get user by id from repo: %w", err)
 		}
-		return repoUser, nil
-	}
-
-	if user.Name != "" {
-		repoUser, err := u.repo.GetUserByName(ctx, user.Name)
+	case user.Name != "":
+		repoUser, err = u.repo.GetUserByName(ctx, user.Name)
 		if err != nil {
 			return entity.User{}, fmt.Errorf("unable to get user by name from repo: %w", err)
 		}
-		return repoUser, nil
-	}
-
-	if user.Email != "" {
-		repoUser, err := u.repo.GetUserByEmail(ctx, user.Email)
+	case user.Email != "":
+		repoUser, err = u.repo.GetUserByEmail(ctx, user.Email)
 		if err != nil {
 			return entity.User{}, fmt.Errorf("unable to get user by email from repo: %w", err)
 		}
-		return repoUser, nil
+	default:
+		return entity.User{}, fmt.Errorf("%w: empty id, name and email", entity.ErrValidation)
+	}
+
+	if user.ID == 0 {
+		if err := authorizeUserAccess(ctx, repoUser.ID); err != nil {
+			return entity.User{}, err
+		}
 	}
 
-	return entity.User{}, errors.New("invalid user")
+	return repoUser, nil
 }
 
+// ListUsers returns human users only, service accounts are listed by ListServiceAccounts.
 func (u userUsecase) ListUsers(ctx context.Context) ([]entity.User, error) {
 	return u.repo.ListUsers(ctx)
 }
+
+// WithClaimsHooks returns a copy of the usecase adding extra claims to access tokens with hooks.
+func (u userUsecase) WithClaimsHooks(hooks ...ClaimsHook) userUsecase {
+	u.claimsHooks = append(u.claimsHooks[:len(u.claimsHooks):len(u.claimsHooks)], hooks...)
+	return u
+}
+
+// createAccessToken creates access token with actual roles of the user,
+// so role changes take effect on the next token refresh.
+func (u userUsecase) createAccessToken(ctx context.Context, session entity.RefreshToken) (string, entity.TokenClaims, error) {
+	roles, err := u.roleRepo.ListUserRoles(ctx, session.UserID)
+	if err != nil {
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to list user roles from repo: %w", err)
+	}
+
+	claims := entity.TokenClaims{
+		UserID:   session.UserID,
+		Roles:    roles,
+		ClientID: session.ClientID,
+		Scopes:   session.Scopes,
+	}
+	for _, hook := range u.claimsHooks {
+		if err := hook(ctx, &claims); err != nil {
+			return "", entity.TokenClaims{}, fmt.Errorf("unable to add extra claims: %w", err)
+		}
+	}
+
+	accessToken, issuedClaims, err := u.authenticator.CreateAccessToken(claims)
+	if err != nil {
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to create access token: %w", err)
+	}
+
+	return accessToken, issuedClaims, nil
+}
+
+// issueTokens creates access and refresh tokens of the session: user, OAuth2 client and granted scopes.
+// Refresh token joins session family, an empty family starts a new one.
+func (u userUsecase) issueTokens(ctx context.Context, session entity.RefreshToken) (entity.TokenSet, error) {
+	accessToken, accessClaims, err := u.createAccessToken(ctx, session)
+	if err != nil {
+		return entity.TokenSet{}, err
+	}
+
+	refreshToken, claims, err := u.authenticator.CreateRefreshToken(session.UserID)
+	if err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to create refresh token: %w", err)
+	}
+
+	familyID := session.FamilyID
+	if familyID == "" {
+		familyID = claims.ID
+	}
+
+	if err := u.tokenRepo.InsertRefreshToken(ctx, entity.RefreshToken{
+		ID:        claims.ID,
+		FamilyID:  familyID,
+		UserID:    session.UserID,
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+		ClientID:  session.ClientID,
+		Scopes:    session.Scopes,
+	}); err != nil {
+		return entity.TokenSet{}, fmt.Errorf("unable to insert refresh token in repo: %w", err)
+	}
+
+	return entity.TokenSet{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		RefreshTokenID: claims.ID,
+		Claims:         accessClaims,
+	}, nil
+}
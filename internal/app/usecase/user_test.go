@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/memory"
+	"github.com/ziyadovea/task_manager/users/internal/authentication/jwt"
+)
+
+// newTestAuthenticator returns the real authenticator signing with HMAC key and revoking tokens in memory.
+func newTestAuthenticator(t *testing.T) Authenticator {
+	t.Helper()
+
+	keys, err := jwt.NewKeyring(jwt.NewHMACKey("test", []byte("0123456789abcdef0123456789abcdef")))
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	return jwt.NewAuthenticator(keys, keys, time.Minute, time.Hour, jwt.ClaimsOptions{}, memory.NewRevocationStore())
+}
+
+// refreshTokenStore keeps refresh tokens as the postgresql repository does.
+type refreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]entity.RefreshToken
+}
+
+func newRefreshTokenStore() *refreshTokenStore {
+	return &refreshTokenStore{tokens: make(map[string]entity.RefreshToken)}
+}
+
+func (s *refreshTokenStore) InsertRefreshToken(_ context.Context, token entity.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.ID] = token
+	return nil
+}
+
+func (s *refreshTokenStore) GetRefreshToken(_ context.Context, id string) (entity.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return entity.RefreshToken{}, entity.ErrNotFound
+	}
+	return token, nil
+}
+
+func (s *refreshTokenStore) UseRefreshToken(_ context.Context, id string, clientID string) (entity.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.ClientID != clientID || token.UsedAt != nil || token.RevokedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return entity.RefreshToken{}, entity.ErrNotFound
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	s.tokens[id] = token
+	return token, nil
+}
+
+func (s *refreshTokenStore) RevokeRefreshTokenFamily(_ context.Context, familyID string) (int64, error) {
+	return s.revoke(func(token entity.RefreshToken) bool { return token.FamilyID == familyID }), nil
+}
+
+func (s *refreshTokenStore) RevokeUserRefreshTokens(_ context.Context, userID int64) (int64, error) {
+	return s.revoke(func(token entity.RefreshToken) bool { return token.UserID == userID }), nil
+}
+
+func (s *refreshTokenStore) revoke(match func(token entity.RefreshToken) bool) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var revoked int64
+	now := time.Now()
+	for id, token := range s.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			s.tokens[id] = token
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// noRoles grants no roles to any user, other methods of the repository are not expected to be called.
+type noRoles struct {
+	RoleRepository
+}
+
+func (noRoles) ListUserRoles(context.Context, int64) ([]string, error) {
+	return nil, nil
+}
+
+// plainHasher keeps passwords as they are, so tests don't wait for real hashing.
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return password, nil
+}
+
+func (plainHasher) Compare(encoded, password string) error {
+	if encoded != password {
+		return errors.New("password mismatch")
+	}
+	return nil
+}
+
+func (plainHasher) NeedsRehash(string) bool {
+	return false
+}
+
+func TestRefreshClientSession(t *testing.T) {
+	type refresh struct {
+		// token is the index of the presented token, the initial token is 0 and each refresh issues the next one
+		token    int
+		clientID string
+		wantErr  error
+	}
+
+	tests := []struct {
+		name      string
+		refreshes []refresh
+	}{
+		{
+			name: "rotated tokens are refreshed",
+			refreshes: []refresh{
+				{token: 0},
+				{token: 1},
+				{token: 2},
+			},
+		},
+		{
+			name: "reused token revokes the family",
+			refreshes: []refresh{
+				{token: 0},
+				{token: 0, wantErr: entity.ErrInvalidCredentials},
+				{token: 1, wantErr: entity.ErrInvalidCredentials},
+			},
+		},
+		{
+			name: "reused older token revokes the latest one",
+			refreshes: []refresh{
+				{token: 0},
+				{token: 1},
+				{token: 1, wantErr: entity.ErrInvalidCredentials},
+				{token: 2, wantErr: entity.ErrInvalidCredentials},
+			},
+		},
+		{
+			name: "token presented by another client is rejected without revoking the family",
+			refreshes: []refresh{
+				{token: 0, clientID: "other", wantErr: entity.ErrInvalidCredentials},
+				{token: 0},
+				{token: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tokenRepo := newRefreshTokenStore()
+			u := userUsecase{
+				roleRepo:      noRoles{},
+				tokenRepo:     tokenRepo,
+				authenticator: newTestAuthenticator(t),
+			}
+
+			session, err := u.issueTokens(ctx, entity.RefreshToken{UserID: 1})
+			if err != nil {
+				t.Fatalf("unable to issue tokens: %v", err)
+			}
+			issued := []string{session.RefreshToken}
+
+			for i, r := range tt.refreshes {
+				tokens, err := u.RefreshClientSession(ctx, r.clientID, issued[r.token])
+				if !errors.Is(err, r.wantErr) {
+					t.Fatalf("refresh #%d: got error %v, want %v", i, err, r.wantErr)
+				}
+				if err == nil {
+					issued = append(issued, tokens.RefreshToken)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshClientSessionKeepsOtherFamilies(t *testing.T) {
+	ctx := context.Background()
+	u := userUsecase{
+		roleRepo:      noRoles{},
+		tokenRepo:     newRefreshTokenStore(),
+		authenticator: newTestAuthenticator(t),
+	}
+
+	stolen, err := u.issueTokens(ctx, entity.RefreshToken{UserID: 1})
+	if err != nil {
+		t.Fatalf("unable to issue tokens: %v", err)
+	}
+	other, err := u.issueTokens(ctx, entity.RefreshToken{UserID: 1})
+	if err != nil {
+		t.Fatalf("unable to issue tokens: %v", err)
+	}
+
+	if _, err := u.RefreshClientSession(ctx, "", stolen.RefreshToken); err != nil {
+		t.Fatalf("unable to refresh: %v", err)
+	}
+	if _, err := u.RefreshClientSession(ctx, "", stolen.RefreshToken); !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Fatalf("got error %v on reuse, want %v", err, entity.ErrInvalidCredentials)
+	}
+	if _, err := u.RefreshClientSession(ctx, "", other.RefreshToken); err != nil {
+		t.Fatalf("token of another family is revoked: %v", err)
+	}
+}
+
+func TestStartClientSessionEmailVerification(t *testing.T) {
+	tests := []struct {
+		name          string
+		required      bool
+		emailVerified bool
+		wantErr       error
+	}{
+		{name: "verified", required: true, emailVerified: true},
+		{name: "unverified", required: true, wantErr: entity.ErrEmailNotVerified},
+		{name: "unverified while verification is optional", required: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tokenRepo := newRefreshTokenStore()
+			u := userUsecase{
+				repo:          &userStore{users: []entity.User{{ID: 1, Name: "alice", EmailVerified: tt.emailVerified}}},
+				roleRepo:      noRoles{},
+				tokenRepo:     tokenRepo,
+				authenticator: newTestAuthenticator(t),
+			}.WithEmailVerification(EmailVerificationOptions{Required: tt.required})
+
+			// passkey and federated sign-in start the session without the password
+			_, err := u.StartClientSession(ctx, 1, "", nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && len(tokenRepo.tokens) != 0 {
+				t.Errorf("refresh token is issued: %v", tokenRepo.tokens)
+			}
+		})
+	}
+}
+
+func TestChangePasswordThrottle(t *testing.T) {
+	ctx := entity.ContextWithClientIP(context.Background(), "192.0.2.1")
+	ctx = entity.ContextWithPrincipal(ctx, entity.Principal{UserID: 1, PrincipalType: entity.PrincipalTypeUser})
+	store := newLoginThrottleStore()
+	u := userUsecase{
+		repo:   &userStore{users: []entity.User{{ID: 1, Name: "alice", Password: "current password"}}},
+		hasher: plainHasher{},
+	}.WithLoginThrottling(store, LoginThrottlingOptions{
+		BaseDelay:     time.Minute,
+		MaxDelay:      time.Minute,
+		FailureWindow: time.Hour,
+	})
+
+	if _, err := u.ChangePassword(ctx, "wrong password", "new password"); !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Fatalf("got error %v, want %v", err, entity.ErrInvalidCredentials)
+	}
+	for _, key := range []string{"user:1", "ip:192.0.2.1"} {
+		if store.failures[key] != 1 {
+			t.Errorf("got %d failures of %s, want 1", store.failures[key], key)
+		}
+	}
+
+	// the current password doesn't help while guesses are blocked
+	_, err := u.ChangePassword(ctx, "current password", "new password")
+	var tooManyRequests entity.TooManyRequestsError
+	if !errors.As(err, &tooManyRequests) {
+		t.Fatalf("got error %v, want %v", err, entity.ErrTooManyRequests)
+	}
+}
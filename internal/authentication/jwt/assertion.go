@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// maxAssertionLifetime limits how long a signed assertion is accepted, so it can't serve as a long-lived secret.
+const maxAssertionLifetime = 5 * time.Minute
+
+// assertionMethods are algorithms of the service account key pairs.
+var assertionMethods = []string{RS256, ES256, EdDSA}
+
+// ValidatePublicKey checks that the PEM encoded public key may verify assertions.
+func (a authenticator) ValidatePublicKey(publicKey string) error {
+	if _, err := parsePublicKey(publicKey); err != nil {
+		return fmt.Errorf("%w: %s", entity.ErrValidation, err)
+	}
+	return nil
+}
+
+// VerifyAssertion checks JWT the service account signed with its private key, in the manner of RFC 7523:
+// iss and sub are the account id prefixed with "sa:" as in its access tokens, aud is the issuer of this service if it's configured,
+// jti is unique and the assertion expires within maxAssertionLifetime.
+// The returned claims identify the assertion in the deny list, used assertions are put there by the caller.
+func (a authenticator) VerifyAssertion(ctx context.Context, assertion string, account entity.ServiceAccount) (entity.TokenClaims, error) {
+	if account.PublicKey == "" {
+		return entity.TokenClaims{}, fmt.Errorf("%w: service account has no public key", entity.ErrInvalidCredentials)
+	}
+	publicKey, err := parsePublicKey(account.PublicKey)
+	if err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("unable to parse service account public key: %w", err)
+	}
+
+	// claims are validated below with the configured leeway
+	parser := jwt.NewParser(jwt.WithValidMethods(assertionMethods), jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(assertion, &jwt.RegisteredClaims{}, func(*jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	})
+	if err != nil || !token.Valid {
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid assertion", entity.ErrInvalidCredentials)
+	}
+
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	if !ok {
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid assertion claims", entity.ErrInvalidCredentials)
+	}
+	if err := a.validateAssertion(claims, account.ID); err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid assertion claims: %s", entity.ErrInvalidCredentials, err)
+	}
+
+	// jti is chosen by the account, so it's scoped to the account and hashed to fit the deny list
+	assertionID := entity.HashToken("assertion:" + claims.Subject + ":" + claims.ID)
+	revoked, err := a.revocations.IsTokenRevoked(ctx, assertionID)
+	if err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("unable to check assertion revocation: %w", err)
+	}
+	if revoked {
+		return entity.TokenClaims{}, fmt.Errorf("%w: assertion is already used", entity.ErrInvalidCredentials)
+	}
+
+	return entity.TokenClaims{
+		ID:               assertionID,
+		ServiceAccountID: account.ID,
+		PrincipalType:    entity.PrincipalTypeServiceAccount,
+		ExpiresAt:        claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (a authenticator) validateAssertion(claims *jwt.RegisteredClaims, accountID int64) error {
+	id := serviceAccountSubjectPrefix + strconv.FormatInt(accountID, 10)
+	if claims.Issuer != id || claims.Subject != id {
+		return fmt.Errorf("unexpected assertion issuer '%s' or subject '%s'", claims.Issuer, claims.Subject)
+	}
+	if claims.ID == "" {
+		return errors.New("empty assertion id")
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("empty assertion exp")
+	}
+
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-a.claimsOptions.Leeway), true) {
+		return errors.New("assertion is expired")
+	}
+	if claims.ExpiresAt.After(now.Add(maxAssertionLifetime + a.claimsOptions.Leeway)) {
+		return fmt.Errorf("assertion expires later than in %s", maxAssertionLifetime)
+	}
+	if !claims.VerifyNotBefore(now.Add(a.claimsOptions.Leeway), false) {
+		return errors.New("assertion is not valid yet")
+	}
+	if !claims.VerifyIssuedAt(now.Add(a.claimsOptions.Leeway), false) {
+		return errors.New("assertion is issued in the future")
+	}
+
+	if a.claimsOptions.Issuer != "" && !claims.VerifyAudience(a.claimsOptions.Issuer, true) {
+		return errors.New("unexpected assertion audience")
+	}
+
+	return nil
+}
+
+// parsePublicKey accepts PEM encoded RSA, ECDSA P-256 or Ed25519 public key.
+func parsePublicKey(data string) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(data)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM([]byte(data)); err == nil {
+		if key.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("ES256 requires P-256 curve, got %s", key.Curve.Params().Name)
+		}
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM([]byte(data)); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("public key is not PEM encoded RSA, ECDSA or Ed25519 key")
+}
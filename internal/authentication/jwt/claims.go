@@ -0,0 +1,223 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// Token types put to the "typ" claim, so a token of one type is never accepted as another.
+const (
+	AccessTokenType            = "access"
+	RefreshTokenType           = "refresh"
+	EmailVerificationTokenType = "email_verification"
+)
+
+// ClaimsOptions configure standard claims of issued tokens and their verification.
+// Empty Issuer and Audience are neither issued nor enforced.
+type ClaimsOptions struct {
+	Issuer   string
+	Audience []string
+	// Leeway is the allowed clock skew for exp, nbf and iat checks.
+	Leeway time.Duration
+}
+
+// registeredClaimNames can not be overridden by extra claims.
+var registeredClaimNames = map[string]struct{}{
+	"iss": {}, "sub": {}, "aud": {}, "exp": {}, "nbf": {}, "iat": {}, "jti": {}, "typ": {}, "roles": {},
+	"scope": {}, "client_id": {}, "principal_type": {},
+}
+
+type tokenClaims struct {
+	Type  string   `json:"typ"`
+	Roles []string `json:"roles,omitempty"`
+	// Scope is a space separated list of scopes
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// PrincipalType tells humans and machines apart, it's set for access tokens only
+	PrincipalType string `json:"principal_type,omitempty"`
+	jwt.RegisteredClaims
+
+	// extra are custom claims put next to the registered ones
+	extra map[string]interface{}
+}
+
+func (c tokenClaims) MarshalJSON() ([]byte, error) {
+	type plain tokenClaims
+	data, err := json.Marshal(plain(c))
+	if err != nil || len(c.extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]interface{}, len(c.extra))
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for name, value := range c.extra {
+		if _, ok := registeredClaimNames[name]; ok {
+			continue
+		}
+		merged[name] = value
+	}
+
+	return json.Marshal(merged)
+}
+
+func (c *tokenClaims) UnmarshalJSON(data []byte) error {
+	type plain tokenClaims
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for name := range registeredClaimNames {
+		delete(all, name)
+	}
+	if len(all) > 0 {
+		c.extra = all
+	}
+
+	return nil
+}
+
+// serviceAccountSubjectPrefix starts the "sub" claim of service account tokens,
+// ids of service accounts are apart from user ids.
+const serviceAccountSubjectPrefix = "sa:"
+
+// userID returns the user from the "sub" claim,
+// tokens issued to a client itself or to a service account have no user.
+func (c *tokenClaims) userID() (int64, error) {
+	if c.ClientID != "" && c.Subject == c.ClientID {
+		return 0, nil
+	}
+	if strings.HasPrefix(c.Subject, serviceAccountSubjectPrefix) {
+		return 0, nil
+	}
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// serviceAccountID returns the service account from the "sub" claim, other tokens have no service account.
+func (c *tokenClaims) serviceAccountID() (int64, error) {
+	if c.ClientID != "" && c.Subject == c.ClientID {
+		return 0, nil
+	}
+	id, ok := strings.CutPrefix(c.Subject, serviceAccountSubjectPrefix)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// subject is the "sub" claim: the user id or the client id for tokens without a user.
+func subject(userID int64, clientID string) string {
+	if userID == 0 && clientID != "" {
+		return clientID
+	}
+	return strconv.FormatInt(userID, 10)
+}
+
+// accessSubject is the "sub" claim of access tokens,
+// it's the prefixed service account id for tokens issued to service accounts.
+func accessSubject(claims entity.TokenClaims) string {
+	if claims.ServiceAccountID != 0 {
+		return serviceAccountSubjectPrefix + strconv.FormatInt(claims.ServiceAccountID, 10)
+	}
+	return subject(claims.UserID, claims.ClientID)
+}
+
+// principalType returns the "principal_type" claim, tokens without the claim are issued
+// to service accounts, to users or, if there is neither, to clients.
+func principalType(claimed string, userID, serviceAccountID int64) string {
+	switch {
+	case claimed != "":
+		return claimed
+	case serviceAccountID != 0:
+		return entity.PrincipalTypeServiceAccount
+	case userID == 0:
+		return entity.PrincipalTypeClient
+	default:
+		return entity.PrincipalTypeUser
+	}
+}
+
+// newTokenClaims fills registered claims of a new token.
+func (o ClaimsOptions) newTokenClaims(tokenType, tokenID, subject string, issuedAt, expiresAt time.Time) tokenClaims {
+	claims := tokenClaims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Subject:   subject,
+			Issuer:    o.Issuer,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if len(o.Audience) > 0 {
+		claims.Audience = o.Audience
+	}
+	return claims
+}
+
+// validate checks claims of a parsed token, time based claims are checked with the leeway.
+func (o ClaimsOptions) validate(claims *tokenClaims, tokenType string) error {
+	if claims.Type != tokenType {
+		return fmt.Errorf("unexpected token type '%s'", claims.Type)
+	}
+	if claims.ID == "" {
+		return errors.New("empty token id")
+	}
+	if claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return errors.New("empty token exp or iat")
+	}
+	if _, err := claims.userID(); err != nil {
+		return fmt.Errorf("invalid token subject '%s'", claims.Subject)
+	}
+	serviceAccountID, err := claims.serviceAccountID()
+	if err != nil || serviceAccountID < 0 {
+		return fmt.Errorf("invalid token subject '%s'", claims.Subject)
+	}
+	if (serviceAccountID != 0) != (claims.PrincipalType == entity.PrincipalTypeServiceAccount) {
+		return fmt.Errorf("unexpected principal type '%s' of token subject '%s'", claims.PrincipalType, claims.Subject)
+	}
+
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-o.Leeway), true) {
+		return errors.New("token is expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(o.Leeway), false) {
+		return errors.New("token is not valid yet")
+	}
+	if !claims.VerifyIssuedAt(now.Add(o.Leeway), true) {
+		return errors.New("token is issued in the future")
+	}
+
+	if o.Issuer != "" && !claims.VerifyIssuer(o.Issuer, true) {
+		return fmt.Errorf("unexpected token issuer '%s'", claims.Issuer)
+	}
+	if len(o.Audience) > 0 && !o.verifyAudience(claims) {
+		return errors.New("unexpected token audience")
+	}
+
+	return nil
+}
+
+// verifyAudience accepts token intended for any of the configured audiences.
+func (o ClaimsOptions) verifyAudience(claims *tokenClaims) bool {
+	for _, audience := range o.Audience {
+		if claims.VerifyAudience(audience, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+func TestClaimsOptionsValidate(t *testing.T) {
+	options := ClaimsOptions{
+		Issuer:   "https://auth.example.com",
+		Audience: []string{"api", "web"},
+		Leeway:   30 * time.Second,
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		options ClaimsOptions
+		// modify breaks claims of the valid access token
+		modify  func(claims *tokenClaims)
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			options: options,
+			modify:  func(*tokenClaims) {},
+		},
+		{
+			name:    "unexpected type",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Type = RefreshTokenType },
+			wantErr: true,
+		},
+		{
+			name:    "empty id",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.ID = "" },
+			wantErr: true,
+		},
+		{
+			name:    "empty exp",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.ExpiresAt = nil },
+			wantErr: true,
+		},
+		{
+			name:    "empty iat",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.IssuedAt = nil },
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric subject",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Subject = "admin" },
+			wantErr: true,
+		},
+		{
+			name:    "client subject",
+			options: options,
+			modify: func(claims *tokenClaims) {
+				claims.Subject, claims.ClientID, claims.PrincipalType = "client", "client", entity.PrincipalTypeClient
+			},
+		},
+		{
+			name:    "service account subject",
+			options: options,
+			modify: func(claims *tokenClaims) {
+				claims.Subject, claims.PrincipalType = "sa:5", entity.PrincipalTypeServiceAccount
+			},
+		},
+		{
+			name:    "service account subject of user principal",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Subject = "sa:5" },
+			wantErr: true,
+		},
+		{
+			name:    "user subject of service account principal",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.PrincipalType = entity.PrincipalTypeServiceAccount },
+			wantErr: true,
+		},
+		{
+			name:    "invalid service account subject",
+			options: options,
+			modify: func(claims *tokenClaims) {
+				claims.Subject, claims.PrincipalType = "sa:admin", entity.PrincipalTypeServiceAccount
+			},
+			wantErr: true,
+		},
+		{
+			name:    "expired within leeway",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second)) },
+		},
+		{
+			name:    "expired",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) },
+			wantErr: true,
+		},
+		{
+			name:    "not valid yet within leeway",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.NotBefore = jwt.NewNumericDate(now.Add(10 * time.Second)) },
+		},
+		{
+			name:    "not valid yet",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) },
+			wantErr: true,
+		},
+		{
+			name:    "issued in the future",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) },
+			wantErr: true,
+		},
+		{
+			name:    "unexpected issuer",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Issuer = "https://evil.example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "any issuer if none is configured",
+			options: ClaimsOptions{Audience: options.Audience},
+			modify:  func(claims *tokenClaims) { claims.Issuer = "https://other.example.com" },
+		},
+		{
+			name:    "another configured audience",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Audience = jwt.ClaimStrings{"web"} },
+		},
+		{
+			name:    "unexpected audience",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Audience = jwt.ClaimStrings{"billing"} },
+			wantErr: true,
+		},
+		{
+			name:    "empty audience",
+			options: options,
+			modify:  func(claims *tokenClaims) { claims.Audience = nil },
+			wantErr: true,
+		},
+		{
+			name:    "any audience if none is configured",
+			options: ClaimsOptions{Issuer: options.Issuer},
+			modify:  func(claims *tokenClaims) { claims.Audience = jwt.ClaimStrings{"billing"} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := options.newTokenClaims(AccessTokenType, "id", "1", now, now.Add(time.Minute))
+			claims.PrincipalType = entity.PrincipalTypeUser
+			tt.modify(&claims)
+
+			err := tt.options.validate(&claims, AccessTokenType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// emailClaim binds email verification token to the verified email.
+const emailClaim = "email"
+
+// CreateEmailVerificationToken creates token proving the user owns the email, it expires at claims.ExpiresAt.
+// It's signed with refresh token keys, so it's verified by this service only.
+func (a authenticator) CreateEmailVerificationToken(claims entity.EmailVerificationClaims) (string, entity.EmailVerificationClaims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", entity.EmailVerificationClaims{}, err
+	}
+
+	claims.ID = tokenID
+	claims.ExpiresAt = time.Unix(claims.ExpiresAt.Unix(), 0)
+
+	emailClaims := a.claimsOptions.newTokenClaims(
+		EmailVerificationTokenType, claims.ID, subject(claims.UserID, ""), time.Unix(time.Now().Unix(), 0), claims.ExpiresAt,
+	)
+	emailClaims.extra = map[string]interface{}{emailClaim: claims.Email}
+
+	tokenString, err := a.refreshKeys.sign(emailClaims)
+	if err != nil {
+		return "", entity.EmailVerificationClaims{}, fmt.Errorf("unable to signed token: %w", err)
+	}
+
+	return tokenString, claims, nil
+}
+
+// VerifyEmailVerificationToken checks token signature, standard claims and the deny list,
+// used tokens are put to the deny list by the caller.
+func (a authenticator) VerifyEmailVerificationToken(ctx context.Context, tokenString string) (entity.EmailVerificationClaims, error) {
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, &tokenClaims{}, a.refreshKeys.keyFunc)
+	if err != nil || !token.Valid {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("%w: invalid token", entity.ErrInvalidCredentials)
+	}
+
+	claims, ok := token.Claims.(*tokenClaims)
+	if !ok {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("%w: invalid token claims", entity.ErrInvalidCredentials)
+	}
+	if err := a.claimsOptions.validate(claims, EmailVerificationTokenType); err != nil {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("%w: invalid token claims: %s", entity.ErrInvalidCredentials, err)
+	}
+	email, _ := claims.extra[emailClaim].(string)
+	if email == "" {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("%w: invalid token claims: empty email", entity.ErrInvalidCredentials)
+	}
+
+	revoked, err := a.revocations.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("unable to check token revocation: %w", err)
+	}
+	if revoked {
+		return entity.EmailVerificationClaims{}, fmt.Errorf("%w: token is already used", entity.ErrInvalidCredentials)
+	}
+
+	// subject is checked by validate
+	userID, _ := claims.userID()
+	return entity.EmailVerificationClaims{
+		ID:        claims.ID,
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// idTokenClaims are claims of OpenID Connect id_token.
+// It has no "typ" claim, so it's never accepted as an access or refresh token.
+type idTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CreateIDToken creates OpenID Connect id_token for the client, the audience is the client id.
+// It's signed with access token keys, so clients verify it with public keys from JWKS.
+func (a authenticator) CreateIDToken(claims entity.IDTokenClaims) (string, error) {
+	now := time.Unix(time.Now().Unix(), 0)
+	idClaims := idTokenClaims{
+		Nonce:         claims.Nonce,
+		Name:          claims.Name,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.claimsOptions.Issuer,
+			Subject:   claims.Subject,
+			Audience:  jwt.ClaimStrings{claims.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTokenExpirationDuration)),
+		},
+	}
+
+	tokenString, err := a.accessKeys.sign(idClaims)
+	if err != nil {
+		return "", fmt.Errorf("unable to signed token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// IDTokenSigningAlgorithms returns algorithms of id_token signatures for the discovery document.
+func (a authenticator) IDTokenSigningAlgorithms() []string {
+	return []string{a.accessKeys.Active().Algorithm()}
+}
@@ -1,114 +1,197 @@
 package jwt
 
 import (
-	"errors"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
-)
+	"github.com/golang-jwt/jwt/v4"
 
-type accessTokenClaims struct {
-	UserID int64 `json:"sub"`
-	jwt.StandardClaims
-}
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
 
-type refreshTokenClaims struct {
-	UserID int64 `json:"sub"`
-	jwt.StandardClaims
+// RevocationStore is consulted on every token verification.
+type RevocationStore interface {
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	// UserTokensRevokedBefore returns time before which all tokens of the user are invalid
+	// except the kept ones, zero time means there is no such restriction.
+	UserTokensRevokedBefore(ctx context.Context, userID int64) (before time.Time, keptTokenIDs []string, err error)
 }
 
+// authenticator issues and verifies tokens.
+// Access tokens may be signed with an asymmetric key, so other services verify them
+// with public keys from JWKS. Refresh tokens are verified by this service only.
+// Keyrings are shared between copies of the authenticator, so rotation affects all of them.
 type authenticator struct {
-	accessTokenSecretKey           []byte
-	refreshTokenSecretKey          []byte
+	accessKeys                     *Keyring
+	refreshKeys                    *Keyring
 	accessTokenExpirationDuration  time.Duration
 	refreshTokenExpirationDuration time.Duration
+	claimsOptions                  ClaimsOptions
+	revocations                    RevocationStore
 }
 
 func NewAuthenticator(
-	accessTokenSecretKey []byte,
-	refreshTokenSecretKey []byte,
+	accessKeys *Keyring,
+	refreshKeys *Keyring,
 	accessTokenExpirationDuration time.Duration,
 	refreshTokenExpirationDuration time.Duration,
+	claimsOptions ClaimsOptions,
+	revocations RevocationStore,
 ) authenticator {
 	return authenticator{
-		accessTokenSecretKey:           accessTokenSecretKey,
-		refreshTokenSecretKey:          refreshTokenSecretKey,
+		accessKeys:                     accessKeys,
+		refreshKeys:                    refreshKeys,
 		accessTokenExpirationDuration:  accessTokenExpirationDuration,
 		refreshTokenExpirationDuration: refreshTokenExpirationDuration,
+		claimsOptions:                  claimsOptions,
+		revocations:                    revocations,
 	}
 }
 
-func (a authenticator) CreateAccessToken(userID int64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims{
-		UserID: userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(a.accessTokenExpirationDuration).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-	})
+// CreateAccessToken creates access token, extra claims are put next to the registered ones
+// and can not override them.
+// Tokens issued to a client itself have no user, their subject is the client id,
+// the subject of tokens issued to a service account is its id prefixed with "sa:".
+// The principal type is derived from the user and the service account if it's not set.
+func (a authenticator) CreateAccessToken(claims entity.TokenClaims) (string, entity.TokenClaims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", entity.TokenClaims{}, err
+	}
 
-	tokenString, err := token.SignedString(a.accessTokenSecretKey)
+	now := time.Now()
+	claims.ID = tokenID
+	claims.IssuedAt = time.Unix(now.Unix(), 0)
+	claims.ExpiresAt = time.Unix(now.Add(a.accessTokenExpirationDuration).Unix(), 0)
+	claims.PrincipalType = principalType(claims.PrincipalType, claims.UserID, claims.ServiceAccountID)
+
+	accessClaims := a.claimsOptions.newTokenClaims(
+		AccessTokenType, claims.ID, accessSubject(claims), claims.IssuedAt, claims.ExpiresAt,
+	)
+	accessClaims.Roles = claims.Roles
+	accessClaims.Scope = strings.Join(claims.Scopes, " ")
+	accessClaims.ClientID = claims.ClientID
+	accessClaims.PrincipalType = claims.PrincipalType
+	accessClaims.extra = claims.Extra
+
+	tokenString, err := a.accessKeys.sign(accessClaims)
 	if err != nil {
-		return "", fmt.Errorf("unable to signed token: %w", err)
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to signed token: %w", err)
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
-func (a authenticator) CreateRefreshToken(userID int64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims{
-		UserID: userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(a.refreshTokenExpirationDuration).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-	})
+// CreateRefreshToken creates refresh token with unique id (jti),
+// returned claims allow the caller to track the token server-side.
+func (a authenticator) CreateRefreshToken(userID int64) (string, entity.TokenClaims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", entity.TokenClaims{}, err
+	}
 
-	tokenString, err := token.SignedString(a.refreshTokenSecretKey)
+	now := time.Now()
+	claims := entity.TokenClaims{
+		ID:        tokenID,
+		UserID:    userID,
+		IssuedAt:  time.Unix(now.Unix(), 0),
+		ExpiresAt: time.Unix(now.Add(a.refreshTokenExpirationDuration).Unix(), 0),
+	}
+
+	tokenString, err := a.refreshKeys.sign(a.claimsOptions.newTokenClaims(
+		RefreshTokenType, claims.ID, subject(userID, ""), claims.IssuedAt, claims.ExpiresAt,
+	))
 	if err != nil {
-		return "", fmt.Errorf("unable to signed token: %w", err)
+		return "", entity.TokenClaims{}, fmt.Errorf("unable to signed token: %w", err)
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
+}
+
+func (a authenticator) VerifyAccessToken(ctx context.Context, tokenString string) (entity.TokenClaims, error) {
+	return a.verify(ctx, tokenString, AccessTokenType, a.accessKeys)
 }
 
-func (a authenticator) VerifyAccessToken(tokenString string) (int64, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &accessTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return a.accessTokenSecretKey, nil
-	})
+func (a authenticator) VerifyRefreshToken(ctx context.Context, tokenString string) (entity.TokenClaims, error) {
+	return a.verify(ctx, tokenString, RefreshTokenType, a.refreshKeys)
+}
 
+// verify checks token signature, standard claims, token type and revocation.
+func (a authenticator) verify(ctx context.Context, tokenString, tokenType string, keys *Keyring) (entity.TokenClaims, error) {
+	// claims are validated below with the configured leeway
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, &tokenClaims{}, keys.keyFunc)
 	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid token", entity.ErrInvalidCredentials)
 	}
 
-	claims, ok := token.Claims.(*accessTokenClaims)
+	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return 0, errors.New("invalid token claims")
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid token claims", entity.ErrInvalidCredentials)
+	}
+	if err := a.claimsOptions.validate(claims, tokenType); err != nil {
+		return entity.TokenClaims{}, fmt.Errorf("%w: invalid token claims: %s", entity.ErrInvalidCredentials, err)
 	}
 
-	return claims.UserID, nil
+	// subject is checked by validate
+	userID, _ := claims.userID()
+	serviceAccountID, _ := claims.serviceAccountID()
+	verifiedClaims := entity.TokenClaims{
+		ID:               claims.ID,
+		UserID:           userID,
+		ServiceAccountID: serviceAccountID,
+		PrincipalType:    principalType(claims.PrincipalType, userID, serviceAccountID),
+		Roles:            claims.Roles,
+		ClientID:         claims.ClientID,
+		Scopes:           strings.Fields(claims.Scope),
+		IssuedAt:         claims.IssuedAt.Time,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		Extra:            claims.extra,
+	}
+	if err := a.checkRevocation(ctx, verifiedClaims); err != nil {
+		return entity.TokenClaims{}, err
+	}
+
+	return verifiedClaims, nil
 }
 
-func (a authenticator) VerifyRefreshToken(tokenString string) (int64, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &refreshTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return a.refreshTokenSecretKey, nil
-	})
+// checkRevocation rejects tokens from the deny list
+// and tokens issued before the user watermark, unless they are kept by their id.
+func (a authenticator) checkRevocation(ctx context.Context, claims entity.TokenClaims) error {
+	revoked, err := a.revocations.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return fmt.Errorf("unable to check token revocation: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("%w: token is revoked", entity.ErrInvalidCredentials)
+	}
 
-	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+	// tokens issued to a client itself or to a service account have no user watermark
+	if claims.UserID == 0 {
+		return nil
 	}
 
-	claims, ok := token.Claims.(*refreshTokenClaims)
-	if !ok {
-		return 0, errors.New("invalid token claims")
+	revokedBefore, keptTokenIDs, err := a.revocations.UserTokensRevokedBefore(ctx, claims.UserID)
+	if err != nil {
+		return fmt.Errorf("unable to check user tokens revocation: %w", err)
+	}
+	if claims.IssuedAt.Before(revokedBefore) && !slices.Contains(keptTokenIDs, claims.ID) {
+		return fmt.Errorf("%w: token is revoked", entity.ErrInvalidCredentials)
 	}
 
-	return claims.UserID, nil
+	return nil
+}
+
+// newTokenID generates random unique token id (jti).
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/memory"
+)
+
+func TestCheckRevocationWatermark(t *testing.T) {
+	issuedAt := time.Unix(1792321555, 0)
+
+	tests := []struct {
+		name string
+		// revokedBefore is the user watermark, zero time if there is none
+		revokedBefore time.Time
+		keptTokenIDs  []string
+		wantErr       error
+	}{
+		{name: "no watermark", wantErr: nil},
+		{name: "issued after the watermark", revokedBefore: issuedAt.Add(-time.Second), wantErr: nil},
+		{name: "issued at the watermark", revokedBefore: issuedAt, wantErr: nil},
+		{name: "issued within the second of the watermark", revokedBefore: issuedAt.Add(time.Second), wantErr: entity.ErrInvalidCredentials},
+		{name: "issued seconds before the watermark", revokedBefore: issuedAt.Add(time.Hour), wantErr: entity.ErrInvalidCredentials},
+		{name: "kept token", revokedBefore: issuedAt.Add(time.Second), keptTokenIDs: []string{"other", "id"}, wantErr: nil},
+		{name: "another token is kept", revokedBefore: issuedAt.Add(time.Second), keptTokenIDs: []string{"other"}, wantErr: entity.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			revocations := memory.NewRevocationStore()
+			if !tt.revokedBefore.IsZero() {
+				if err := revocations.RevokeUserTokens(ctx, 1, tt.revokedBefore); err != nil {
+					t.Fatalf("unable to revoke tokens: %v", err)
+				}
+				if err := revocations.KeepUserTokens(ctx, 1, tt.keptTokenIDs); err != nil {
+					t.Fatalf("unable to keep tokens: %v", err)
+				}
+			}
+			a := authenticator{revocations: revocations}
+
+			err := a.checkRevocation(ctx, entity.TokenClaims{ID: "id", UserID: 1, IssuedAt: issuedAt})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessTokenAfterRevocation(t *testing.T) {
+	ctx := context.Background()
+	keyring, err := NewKeyring(NewHMACKey("test", testSecret(1)))
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	revocations := memory.NewRevocationStore()
+	a := NewAuthenticator(keyring, keyring, time.Minute, time.Hour, ClaimsOptions{}, revocations)
+
+	revokedToken, _, err := a.CreateAccessToken(entity.TokenClaims{UserID: 1})
+	if err != nil {
+		t.Fatalf("unable to create token: %v", err)
+	}
+	// the watermark is the end of the second as the sessions of the user are revoked
+	if err := revocations.RevokeUserTokens(ctx, 1, time.Now().Truncate(time.Second).Add(time.Second)); err != nil {
+		t.Fatalf("unable to revoke tokens: %v", err)
+	}
+	keptToken, keptClaims, err := a.CreateAccessToken(entity.TokenClaims{UserID: 1})
+	if err != nil {
+		t.Fatalf("unable to create token: %v", err)
+	}
+	if err := revocations.KeepUserTokens(ctx, 1, []string{keptClaims.ID}); err != nil {
+		t.Fatalf("unable to keep token: %v", err)
+	}
+
+	if _, err := a.VerifyAccessToken(ctx, revokedToken); !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Errorf("got error %v for the token issued before revocation, want %v", err, entity.ErrInvalidCredentials)
+	}
+	if _, err := a.VerifyAccessToken(ctx, keptToken); err != nil {
+		t.Errorf("token kept after revocation is rejected: %v", err)
+	}
+
+	// the next revocation doesn't keep the token anymore
+	if err := revocations.RevokeUserTokens(ctx, 1, time.Now().Truncate(time.Second).Add(time.Second)); err != nil {
+		t.Fatalf("unable to revoke tokens: %v", err)
+	}
+	if _, err := a.VerifyAccessToken(ctx, keptToken); !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Errorf("got error %v for the kept token after the next revocation, want %v", err, entity.ErrInvalidCredentials)
+	}
+}
@@ -0,0 +1,99 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Key is a token signing key identified by ID, which is put to the "kid" token header.
+// Symmetric keys sign and verify with the same secret,
+// asymmetric keys sign with the private key and verify with the public one.
+type Key struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey returns HS256 key with the shared secret.
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{
+		ID:        id,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// LoadKey reads PEM encoded private key for asymmetric algorithm from path.
+func LoadKey(id, algorithm, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("unable to read key file '%s': %w", path, err)
+	}
+
+	switch algorithm {
+	case RS256:
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("unable to parse RSA private key: %w", err)
+		}
+		return Key{ID: id, method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: &privateKey.PublicKey}, nil
+	case ES256:
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("unable to parse EC private key: %w", err)
+		}
+		if privateKey.Curve.Params().Name != "P-256" {
+			return Key{}, fmt.Errorf("ES256 requires P-256 curve, got %s", privateKey.Curve.Params().Name)
+		}
+		return Key{ID: id, method: jwt.SigningMethodES256, signKey: privateKey, verifyKey: &privateKey.PublicKey}, nil
+	case EdDSA:
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("unable to parse Ed25519 private key: %w", err)
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return Key{}, fmt.Errorf("invalid Ed25519 private key")
+		}
+		return Key{ID: id, method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: signer.Public()}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported signing algorithm '%s'", algorithm)
+	}
+}
+
+// Algorithm returns JWS algorithm name of the key.
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// IsAsymmetric reports whether the key has a public part that may be published.
+func (k Key) IsAsymmetric() bool {
+	switch k.verifyKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return true
+	default:
+		return false
+	}
+}
+
+func (k Key) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.signKey)
+}
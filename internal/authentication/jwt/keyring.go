@@ -0,0 +1,100 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Keyring holds the active signing key and previous keys that still verify tokens,
+// so keys can be rotated without invalidating outstanding tokens.
+// It's safe for concurrent use.
+type Keyring struct {
+	mu     sync.RWMutex
+	active Key
+	keys   map[string]Key
+}
+
+func NewKeyring(active Key, previous ...Key) (*Keyring, error) {
+	k := &Keyring{}
+	if err := k.Set(active, previous...); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Set replaces keys of the keyring, active key signs new tokens,
+// previous keys only verify tokens issued before rotation.
+func (k *Keyring) Set(active Key, previous ...Key) error {
+	if active.ID == "" {
+		return errors.New("empty active key id")
+	}
+
+	keys := map[string]Key{active.ID: active}
+	for _, key := range previous {
+		if key.ID == "" {
+			return errors.New("empty key id")
+		}
+		if _, ok := keys[key.ID]; ok {
+			return fmt.Errorf("duplicate key id '%s'", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.active = active
+	k.keys = keys
+	return nil
+}
+
+// Active returns the key that signs new tokens.
+func (k *Keyring) Active() Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.active
+}
+
+// Keys returns all keys of the keyring, the active one goes first.
+func (k *Keyring) Keys() []Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]Key, 0, len(k.keys))
+	keys = append(keys, k.active)
+	for id, key := range k.keys {
+		if id != k.active.ID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	return k.Active().sign(claims)
+}
+
+// keyFunc selects verification key by the "kid" token header.
+// Tokens without the header were issued before key rotation support and are verified with the active key.
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key := k.active
+	if kid, ok := token.Header["kid"]; ok {
+		kidStr, _ := kid.(string)
+		key, ok = k.keys[kidStr]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %v", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
@@ -0,0 +1,237 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+	"github.com/ziyadovea/task_manager/users/internal/app/repository/memory"
+)
+
+// testSecret returns HMAC secret of 32 bytes filled with b.
+func testSecret(b byte) []byte {
+	secret := make([]byte, 32)
+	for i := range secret {
+		secret[i] = b
+	}
+	return secret
+}
+
+// loadTestECKey generates ES256 key and loads it as the configured keys are loaded.
+func loadTestECKey(t *testing.T, id string) Key {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("unable to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), id+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("unable to write key: %v", err)
+	}
+
+	key, err := LoadKey(id, ES256, path)
+	if err != nil {
+		t.Fatalf("unable to load key: %v", err)
+	}
+	return key
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name     string
+		active   Key
+		previous []Key
+		wantErr  bool
+	}{
+		{
+			name:   "active key only",
+			active: NewHMACKey("a", testSecret(1)),
+		},
+		{
+			name:     "active and previous keys",
+			active:   NewHMACKey("b", testSecret(2)),
+			previous: []Key{NewHMACKey("a", testSecret(1))},
+		},
+		{
+			name:    "empty active key id",
+			active:  NewHMACKey("", testSecret(1)),
+			wantErr: true,
+		},
+		{
+			name:     "empty previous key id",
+			active:   NewHMACKey("b", testSecret(2)),
+			previous: []Key{NewHMACKey("", testSecret(1))},
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate key id",
+			active:   NewHMACKey("a", testSecret(2)),
+			previous: []Key{NewHMACKey("a", testSecret(1))},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.active, tt.previous...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := keyring.Active().ID; got != tt.active.ID {
+				t.Errorf("got active key %q, want %q", got, tt.active.ID)
+			}
+			if got := len(keyring.Keys()); got != len(tt.previous)+1 {
+				t.Errorf("got %d keys, want %d", got, len(tt.previous)+1)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey := NewHMACKey("old", testSecret(1))
+	newKey := NewHMACKey("new", testSecret(2))
+	ecKey := loadTestECKey(t, "ec")
+
+	tests := []struct {
+		name string
+		// rotate is applied to the keyring after the token is signed with oldKey
+		rotate    func(keyring *Keyring) error
+		wantValid bool
+	}{
+		{
+			name:      "no rotation",
+			rotate:    func(*Keyring) error { return nil },
+			wantValid: true,
+		},
+		{
+			name:      "old key is kept as previous",
+			rotate:    func(keyring *Keyring) error { return keyring.Set(newKey, oldKey) },
+			wantValid: true,
+		},
+		{
+			name:      "old key is kept as previous of asymmetric key",
+			rotate:    func(keyring *Keyring) error { return keyring.Set(ecKey, oldKey) },
+			wantValid: true,
+		},
+		{
+			name:      "old key is dropped",
+			rotate:    func(keyring *Keyring) error { return keyring.Set(newKey) },
+			wantValid: false,
+		},
+		{
+			name:      "old key id is reused with another secret",
+			rotate:    func(keyring *Keyring) error { return keyring.Set(NewHMACKey(oldKey.ID, testSecret(3))) },
+			wantValid: false,
+		},
+		{
+			name:      "old key id is reused with another algorithm",
+			rotate:    func(keyring *Keyring) error { return keyring.Set(loadTestECKey(t, oldKey.ID)) },
+			wantValid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			keyring, err := NewKeyring(oldKey)
+			if err != nil {
+				t.Fatalf("unable to create keyring: %v", err)
+			}
+			a := NewAuthenticator(keyring, keyring, time.Minute, time.Hour, ClaimsOptions{}, memory.NewRevocationStore())
+
+			token, _, err := a.CreateAccessToken(entity.TokenClaims{UserID: 1})
+			if err != nil {
+				t.Fatalf("unable to create token: %v", err)
+			}
+			if err := tt.rotate(keyring); err != nil {
+				t.Fatalf("unable to rotate keys: %v", err)
+			}
+
+			_, err = a.VerifyAccessToken(ctx, token)
+			if tt.wantValid && err != nil {
+				t.Fatalf("token is rejected: %v", err)
+			}
+			if !tt.wantValid && !errors.Is(err, entity.ErrInvalidCredentials) {
+				t.Fatalf("got error %v, want %v", err, entity.ErrInvalidCredentials)
+			}
+
+			// new tokens are signed with the active key and verified after rotation
+			newToken, _, err := a.CreateAccessToken(entity.TokenClaims{UserID: 1})
+			if err != nil {
+				t.Fatalf("unable to create token: %v", err)
+			}
+			if kid := tokenKeyID(t, newToken); kid != keyring.Active().ID {
+				t.Errorf("got kid %q, want the active key %q", kid, keyring.Active().ID)
+			}
+			if _, err := a.VerifyAccessToken(ctx, newToken); err != nil {
+				t.Errorf("token of the active key is rejected: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyringTokenWithoutKeyID(t *testing.T) {
+	ctx := context.Background()
+	activeKey := NewHMACKey("active", testSecret(1))
+	keyring, err := NewKeyring(activeKey, NewHMACKey("previous", testSecret(2)))
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	a := NewAuthenticator(keyring, keyring, time.Minute, time.Hour, ClaimsOptions{}, memory.NewRevocationStore())
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		wantValid bool
+	}{
+		{name: "signed with the active key", secret: testSecret(1), wantValid: true},
+		{name: "signed with a previous key", secret: testSecret(2), wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			claims := ClaimsOptions{}.newTokenClaims(AccessTokenType, "id", "1", now, now.Add(time.Minute))
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tt.secret)
+			if err != nil {
+				t.Fatalf("unable to sign token: %v", err)
+			}
+
+			_, err = a.VerifyAccessToken(ctx, token)
+			if (err == nil) != tt.wantValid {
+				t.Fatalf("got error %v, want valid %t", err, tt.wantValid)
+			}
+		})
+	}
+}
+
+// tokenKeyID returns the "kid" header of the token without verifying it.
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("unable to parse token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const requestTimeout = 10 * time.Second
+
+// ProviderConfig configures an upstream OpenID Connect provider.
+type ProviderConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested in addition to openid.
+	Scopes []string
+}
+
+// Provider signs users in with an upstream OpenID Connect provider by the authorization code flow with PKCE.
+// The provider is discovered on first use and discovery is retried until it succeeds,
+// so an unavailable provider doesn't prevent the service from starting.
+type Provider struct {
+	cfg    ProviderConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewProvider(cfg ProviderConfig) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+type idTokenClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (entity.ExternalIdentity, error) {
+	ctx = oidc.ClientContext(ctx, p.client)
+	config, verifier, err := p.discover(ctx)
+	if err != nil {
+		return entity.ExternalIdentity{}, err
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return entity.ExternalIdentity{}, fmt.Errorf("%w: %s", entity.ErrInvalidCredentials, retrieveErr.ErrorCode)
+	}
+	if err != nil {
+		return entity.ExternalIdentity{}, fmt.Errorf("unable to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return entity.ExternalIdentity{}, fmt.Errorf("%w: no id_token in token response", entity.ErrInvalidCredentials)
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return entity.ExternalIdentity{}, fmt.Errorf("%w: invalid id_token: %s", entity.ErrInvalidCredentials, err)
+	}
+	if idToken.Nonce != nonce {
+		return entity.ExternalIdentity{}, fmt.Errorf("%w: id_token nonce doesn't match", entity.ErrInvalidCredentials)
+	}
+
+	var claims idTokenClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return entity.ExternalIdentity{}, fmt.Errorf("%w: invalid id_token claims: %s", entity.ErrInvalidCredentials, err)
+	}
+
+	return entity.ExternalIdentity{
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// discover fetches the provider discovery document once,
+// the id_token verifier fetches and caches the provider JWKS itself.
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	// the provider keeps only the client of the context to fetch keys later
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, p.client), p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to discover identity provider: %w", err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.cfg.Scopes...),
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth2, p.verifier, nil
+}
@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	testClientID     = "users"
+	testClientSecret = "secret"
+	testCode         = "code"
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testNonce        = "nonce"
+)
+
+// fakeIdentityProvider is an OpenID Connect provider issuing id_token for testCode
+// redeemed by testClientID with testCodeVerifier.
+type fakeIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// claims of id_token, the token response has no id_token if it's nil
+	claims jwt.MapClaims
+	// signingKey signs id_token, it's key if nil
+	signingKey *rsa.PrivateKey
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	idp := &fakeIdentityProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *fakeIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("code") != testCode || r.PostForm.Get("code_verifier") != testCodeVerifier {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	response := map[string]interface{}{"access_token": "access", "token_type": "Bearer"}
+	if idp.claims != nil {
+		signingKey := idp.signingKey
+		if signingKey == nil {
+			signingKey = idp.key
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "key"
+		idToken, err := token.SignedString(signingKey)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+			return
+		}
+		response["id_token"] = idToken
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestProviderAuthCodeURL(t *testing.T) {
+	idp := newFakeIdentityProvider(t)
+	p := NewProvider(ProviderConfig{
+		IssuerURL:   idp.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://auth.example.com/oauth2/federated/callback",
+		Scopes:      []string{"email"},
+	})
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state", testNonce, testCodeVerifier)
+	if err != nil {
+		t.Fatalf("unable to build url: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("unable to parse url: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(testCodeVerifier))
+	want := map[string]string{
+		"client_id":             testClientID,
+		"redirect_uri":          "https://auth.example.com/oauth2/federated/callback",
+		"response_type":         "code",
+		"scope":                 "openid email",
+		"state":                 "state",
+		"nonce":                 testNonce,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("got %s %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestProviderExchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		// modify breaks the valid id_token or the request
+		modify       func(idp *fakeIdentityProvider, code, codeVerifier, nonce *string)
+		wantIdentity entity.ExternalIdentity
+		wantErr      error
+	}{
+		{
+			name:   "valid",
+			modify: func(*fakeIdentityProvider, *string, *string, *string) {},
+			wantIdentity: entity.ExternalIdentity{
+				Subject:           "subject",
+				Email:             "alice@example.com",
+				EmailVerified:     true,
+				Name:              "Alice",
+				PreferredUsername: "alice",
+			},
+		},
+		{
+			name:    "unknown code",
+			modify:  func(_ *fakeIdentityProvider, code, _, _ *string) { *code = "other" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong code verifier",
+			modify:  func(_ *fakeIdentityProvider, _, codeVerifier, _ *string) { *codeVerifier = testCodeVerifier[1:] + "a" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "another nonce",
+			modify:  func(_ *fakeIdentityProvider, _, _, nonce *string) { *nonce = "other" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "no id_token",
+			modify:  func(idp *fakeIdentityProvider, _, _, _ *string) { idp.claims = nil },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "id_token of another audience",
+			modify:  func(idp *fakeIdentityProvider, _, _, _ *string) { idp.claims["aud"] = "other" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "id_token of another issuer",
+			modify:  func(idp *fakeIdentityProvider, _, _, _ *string) { idp.claims["iss"] = "https://evil.example.com" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name: "expired id_token",
+			modify: func(idp *fakeIdentityProvider, _, _, _ *string) {
+				idp.claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "id_token signed by unknown key",
+			modify:  func(idp *fakeIdentityProvider, _, _, _ *string) { idp.signingKey = otherKey },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdentityProvider(t)
+			idp.claims = jwt.MapClaims{
+				"iss":                idp.server.URL,
+				"aud":                testClientID,
+				"sub":                "subject",
+				"exp":                time.Now().Add(time.Minute).Unix(),
+				"iat":                time.Now().Unix(),
+				"nonce":              testNonce,
+				"email":              "alice@example.com",
+				"email_verified":     true,
+				"name":               "Alice",
+				"preferred_username": "alice",
+			}
+			code, codeVerifier, nonce := testCode, testCodeVerifier, testNonce
+			tt.modify(idp, &code, &codeVerifier, &nonce)
+			p := NewProvider(ProviderConfig{IssuerURL: idp.server.URL, ClientID: testClientID, ClientSecret: testClientSecret})
+
+			identity, err := p.Exchange(context.Background(), code, codeVerifier, nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if identity != tt.wantIdentity {
+				t.Errorf("got identity %+v, want %+v", identity, tt.wantIdentity)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// Config configures the relying party, the service users register passkeys with.
+type Config struct {
+	// RPID is the domain of the service without scheme and port, passkeys are bound to it.
+	RPID          string
+	RPDisplayName string
+	// RPOrigins are origins of pages allowed to use passkeys.
+	RPOrigins []string
+}
+
+// RelyingParty runs WebAuthn registration and login ceremonies.
+// Passkeys are discoverable credentials with user verification,
+// so they sign users in without a name and password.
+type RelyingParty struct {
+	webauthn *webauthn.WebAuthn
+}
+
+func NewRelyingParty(cfg Config) (RelyingParty, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+	})
+	if err != nil {
+		return RelyingParty{}, fmt.Errorf("unable to init webauthn: %w", err)
+	}
+	return RelyingParty{webauthn: w}, nil
+}
+
+// BeginRegistration returns credential creation options for navigator.credentials.create()
+// and session data to finish the registration with. Registered passkeys are excluded.
+func (rp RelyingParty) BeginRegistration(user entity.User, passkeys []entity.Passkey) ([]byte, []byte, error) {
+	u := newUser(user, passkeys)
+	creation, session, err := rp.webauthn.BeginRegistration(u,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		}),
+		webauthn.WithExclusions(u.descriptors()),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to begin registration: %w", err)
+	}
+	return marshalCeremony(creation, session)
+}
+
+// FinishRegistration verifies the attestation response of the authenticator and returns the new passkey.
+func (rp RelyingParty) FinishRegistration(user entity.User, passkeys []entity.Passkey, sessionData, response []byte) (entity.Passkey, error) {
+	var session webauthn.SessionData
+	if err := json.Unmarshal(sessionData, &session); err != nil {
+		return entity.Passkey{}, fmt.Errorf("unable to unmarshal session data: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return entity.Passkey{}, fmt.Errorf("%w: invalid credential: %s", entity.ErrValidation, protocolErrorDetails(err))
+	}
+	credential, err := rp.webauthn.CreateCredential(newUser(user, passkeys), session, parsed)
+	if err != nil {
+		return entity.Passkey{}, fmt.Errorf("%w: credential verification failed: %s", entity.ErrInvalidCredentials, protocolErrorDetails(err))
+	}
+
+	return fromCredential(user.ID, credential), nil
+}
+
+// BeginLogin returns credential request options for navigator.credentials.get()
+// and session data to finish the login with. Any passkey of the relying party is accepted.
+func (rp RelyingParty) BeginLogin() ([]byte, []byte, error) {
+	assertion, session, err := rp.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to begin login: %w", err)
+	}
+	return marshalCeremony(assertion, session)
+}
+
+// FinishLogin verifies the assertion response of the authenticator. The user is found by the user handle
+// of the passkey with lookup, the passkey is returned with updated sign count and backup state.
+func (rp RelyingParty) FinishLogin(
+	sessionData []byte,
+	response []byte,
+	lookup func(userID int64) (entity.User, []entity.Passkey, error),
+) (entity.Passkey, error) {
+	var session webauthn.SessionData
+	if err := json.Unmarshal(sessionData, &session); err != nil {
+		return entity.Passkey{}, fmt.Errorf("unable to unmarshal session data: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return entity.Passkey{}, fmt.Errorf("%w: invalid credential: %s", entity.ErrValidation, protocolErrorDetails(err))
+	}
+
+	var (
+		userID    int64
+		lookupErr error
+	)
+	handler := func(_, userHandle []byte) (webauthn.User, error) {
+		userID, lookupErr = strconv.ParseInt(string(userHandle), 10, 64)
+		if lookupErr != nil {
+			lookupErr = fmt.Errorf("%w: invalid user handle", entity.ErrInvalidCredentials)
+			return nil, lookupErr
+		}
+		var (
+			user     entity.User
+			passkeys []entity.Passkey
+		)
+		user, passkeys, lookupErr = lookup(userID)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		return newUser(user, passkeys), nil
+	}
+	credential, err := rp.webauthn.ValidateDiscoverableLogin(handler, session, parsed)
+	if lookupErr != nil {
+		// the library hides lookup errors, so repo failures are told apart here
+		return entity.Passkey{}, lookupErr
+	}
+	if err != nil {
+		return entity.Passkey{}, fmt.Errorf("%w: assertion verification failed: %s", entity.ErrInvalidCredentials, protocolErrorDetails(err))
+	}
+	if credential.Authenticator.CloneWarning {
+		return entity.Passkey{}, fmt.Errorf("%w: sign count of the passkey went back, it may be cloned", entity.ErrInvalidCredentials)
+	}
+
+	return fromCredential(userID, credential), nil
+}
+
+func marshalCeremony(options interface{}, session *webauthn.SessionData) ([]byte, []byte, error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to marshal options: %w", err)
+	}
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to marshal session data: %w", err)
+	}
+	return optionsJSON, sessionData, nil
+}
+
+// protocolErrorDetails returns details of WebAuthn protocol errors, they explain what's wrong with the response.
+func protocolErrorDetails(err error) string {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.DevInfo != "" {
+		return protocolErr.Details + ": " + protocolErr.DevInfo
+	}
+	return err.Error()
+}
@@ -0,0 +1,346 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// authenticator data flags of WebAuthn section 6.1
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// softwareAuthenticator is a platform authenticator keeping a single ES256 passkey in memory.
+type softwareAuthenticator struct {
+	// rpID and origin are what the browser reports, they are of the relying party unless a test breaks them
+	rpID       string
+	origin     string
+	flags      byte
+	signCount  uint32
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatalf("unable to generate credential id: %v", err)
+	}
+	return &softwareAuthenticator{
+		rpID:   testRPID,
+		origin: testOrigin,
+		flags:  flagUserPresent | flagUserVerified,
+		key:    key,
+		id:     id,
+	}
+}
+
+// create answers navigator.credentials.create() with "none" attestation.
+func (a *softwareAuthenticator) create(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var creation protocol.CredentialCreation
+	if err := json.Unmarshal(options, &creation); err != nil {
+		t.Fatalf("unable to unmarshal creation options: %v", err)
+	}
+	userID, _ := creation.Response.User.ID.(string)
+	userHandle, err := base64.RawURLEncoding.DecodeString(userID)
+	if err != nil {
+		t.Fatalf("unable to decode user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal public key: %v", err)
+	}
+	authData := a.authenticatorData(flagAttestedCredentialData)
+	authData = append(authData, make([]byte, 16)...) // zero AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.id)))
+	authData = append(authData, a.id...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(struct {
+		Format       string                 `cbor:"fmt"`
+		AttStatement map[string]interface{} `cbor:"attStmt"`
+		AuthData     []byte                 `cbor:"authData"`
+	}{Format: "none", AttStatement: map[string]interface{}{}, AuthData: authData})
+	if err != nil {
+		t.Fatalf("unable to marshal attestation object: %v", err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    a.clientData(t, protocol.CreateCeremony, creation.Response.Challenge),
+		"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+	})
+}
+
+// get answers navigator.credentials.get() with the assertion signed by the passkey.
+func (a *softwareAuthenticator) get(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var assertion protocol.CredentialAssertion
+	if err := json.Unmarshal(options, &assertion); err != nil {
+		t.Fatalf("unable to unmarshal request options: %v", err)
+	}
+
+	a.signCount++
+	authData := a.authenticatorData(0)
+	clientData := a.clientData(t, protocol.AssertCeremony, assertion.Response.Challenge)
+	clientDataJSON, _ := base64.RawURLEncoding.DecodeString(clientData)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(bytes.Clone(authData), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("unable to sign assertion: %v", err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    clientData,
+		"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+		"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], a.flags|flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softwareAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) string {
+	t.Helper()
+
+	data, err := json.Marshal(protocol.CollectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal client data: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (a *softwareAuthenticator) credential(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+
+	id := base64.RawURLEncoding.EncodeToString(a.id)
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       id,
+		"rawId":    id,
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal credential: %v", err)
+	}
+	return data
+}
+
+func newTestRelyingParty(t *testing.T) RelyingParty {
+	t.Helper()
+
+	rp, err := NewRelyingParty(Config{RPID: testRPID, RPDisplayName: "Task Manager", RPOrigins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("unable to create relying party: %v", err)
+	}
+	return rp
+}
+
+func TestRelyingPartyRegistration(t *testing.T) {
+	user := entity.User{ID: 42, Name: "alice", Email: "alice@example.com"}
+
+	tests := []struct {
+		name string
+		// modify breaks the authenticator before it answers
+		modify  func(a *softwareAuthenticator)
+		wantErr error
+	}{
+		{name: "registered", modify: func(*softwareAuthenticator) {}},
+		{name: "another origin", modify: func(a *softwareAuthenticator) { a.origin = "https://evil.example.com" }, wantErr: entity.ErrInvalidCredentials},
+		{name: "another relying party", modify: func(a *softwareAuthenticator) { a.rpID = "evil.example.com" }, wantErr: entity.ErrInvalidCredentials},
+		{name: "user is not verified", modify: func(a *softwareAuthenticator) { a.flags = flagUserPresent }, wantErr: entity.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newTestRelyingParty(t)
+			authenticator := newSoftwareAuthenticator(t)
+			tt.modify(authenticator)
+
+			options, session, err := rp.BeginRegistration(user, nil)
+			if err != nil {
+				t.Fatalf("unable to begin registration: %v", err)
+			}
+			passkey, err := rp.FinishRegistration(user, nil, session, authenticator.create(t, options))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if passkey.UserID != user.ID || !bytes.Equal(passkey.ID, authenticator.id) {
+				t.Errorf("got passkey %x of user %d, want %x of user %d", passkey.ID, passkey.UserID, authenticator.id, user.ID)
+			}
+			if string(authenticator.userHandle) != "42" {
+				t.Errorf("got user handle %q, want the user id", authenticator.userHandle)
+			}
+		})
+	}
+}
+
+func TestRelyingPartyRegistrationWithAnotherChallenge(t *testing.T) {
+	user := entity.User{ID: 42, Name: "alice", Email: "alice@example.com"}
+	rp := newTestRelyingParty(t)
+
+	options, _, err := rp.BeginRegistration(user, nil)
+	if err != nil {
+		t.Fatalf("unable to begin registration: %v", err)
+	}
+	_, session, err := rp.BeginRegistration(user, nil)
+	if err != nil {
+		t.Fatalf("unable to begin registration: %v", err)
+	}
+
+	_, err = rp.FinishRegistration(user, nil, session, newSoftwareAuthenticator(t).create(t, options))
+	if !errors.Is(err, entity.ErrInvalidCredentials) {
+		t.Fatalf("got error %v, want %v", err, entity.ErrInvalidCredentials)
+	}
+}
+
+func TestRelyingPartyLogin(t *testing.T) {
+	user := entity.User{ID: 42, Name: "alice", Email: "alice@example.com"}
+	errRepo := errors.New("repo is unavailable")
+
+	tests := []struct {
+		name string
+		// modify breaks the authenticator or the stored passkey after registration
+		modify func(a *softwareAuthenticator, stored *entity.Passkey)
+		// lookupErr fails the lookup of the user
+		lookupErr error
+		wantErr   error
+	}{
+		{
+			name:   "signed in",
+			modify: func(*softwareAuthenticator, *entity.Passkey) {},
+		},
+		{
+			name:    "another origin",
+			modify:  func(a *softwareAuthenticator, _ *entity.Passkey) { a.origin = "https://evil.example.com" },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "user is not verified",
+			modify:  func(a *softwareAuthenticator, _ *entity.Passkey) { a.flags = flagUserPresent },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name: "signed by another key",
+			modify: func(a *softwareAuthenticator, _ *entity.Passkey) {
+				a.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			},
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "sign count went back",
+			modify:  func(_ *softwareAuthenticator, stored *entity.Passkey) { stored.SignCount = 10 },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown passkey of the user",
+			modify:  func(_ *softwareAuthenticator, stored *entity.Passkey) { stored.ID = []byte("other") },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:    "invalid user handle",
+			modify:  func(a *softwareAuthenticator, _ *entity.Passkey) { a.userHandle = []byte("alice") },
+			wantErr: entity.ErrInvalidCredentials,
+		},
+		{
+			name:      "lookup fails",
+			modify:    func(*softwareAuthenticator, *entity.Passkey) {},
+			lookupErr: errRepo,
+			wantErr:   errRepo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newTestRelyingParty(t)
+			authenticator := newSoftwareAuthenticator(t)
+
+			options, session, err := rp.BeginRegistration(user, nil)
+			if err != nil {
+				t.Fatalf("unable to begin registration: %v", err)
+			}
+			stored, err := rp.FinishRegistration(user, nil, session, authenticator.create(t, options))
+			if err != nil {
+				t.Fatalf("unable to register passkey: %v", err)
+			}
+			tt.modify(authenticator, &stored)
+
+			options, session, err = rp.BeginLogin()
+			if err != nil {
+				t.Fatalf("unable to begin login: %v", err)
+			}
+			var lookedUp int64
+			lookup := func(userID int64) (entity.User, []entity.Passkey, error) {
+				lookedUp = userID
+				return user, []entity.Passkey{stored}, tt.lookupErr
+			}
+			passkey, err := rp.FinishLogin(session, authenticator.get(t, options), lookup)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if lookedUp != user.ID || passkey.UserID != user.ID || !bytes.Equal(passkey.ID, stored.ID) {
+				t.Errorf("got passkey %x of user %d, want %x of user %d", passkey.ID, passkey.UserID, stored.ID, user.ID)
+			}
+			if passkey.SignCount != int64(authenticator.signCount) {
+				t.Errorf("got sign count %d, want %d", passkey.SignCount, authenticator.signCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package webauthn
+
+import (
+	"strconv"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// user adapts the user with passkeys to webauthn.User.
+// The user handle is the decimal user id, it's not shown to the user.
+type user struct {
+	entity.User
+	credentials []webauthn.Credential
+}
+
+func newUser(u entity.User, passkeys []entity.Passkey) user {
+	credentials := make([]webauthn.Credential, len(passkeys))
+	for i, passkey := range passkeys {
+		credentials[i] = toCredential(passkey)
+	}
+	return user{User: u, credentials: credentials}
+}
+
+func (u user) WebAuthnID() []byte {
+	return []byte(strconv.FormatInt(u.ID, 10))
+}
+
+func (u user) WebAuthnName() string {
+	return u.Email
+}
+
+func (u user) WebAuthnDisplayName() string {
+	return u.Name
+}
+
+func (u user) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u user) WebAuthnIcon() string {
+	return ""
+}
+
+func (u user) descriptors() []protocol.CredentialDescriptor {
+	descriptors := make([]protocol.CredentialDescriptor, len(u.credentials))
+	for i, credential := range u.credentials {
+		descriptors[i] = credential.Descriptor()
+	}
+	return descriptors
+}
+
+func toCredential(passkey entity.Passkey) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, len(passkey.Transports))
+	for i, transport := range passkey.Transports {
+		transports[i] = protocol.AuthenticatorTransport(transport)
+	}
+	return webauthn.Credential{
+		ID:              passkey.ID,
+		PublicKey:       passkey.PublicKey,
+		AttestationType: passkey.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			UserVerified:   true,
+			BackupEligible: passkey.BackupEligible,
+			BackupState:    passkey.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    passkey.AAGUID,
+			SignCount: uint32(passkey.SignCount),
+		},
+	}
+}
+
+func fromCredential(userID int64, credential *webauthn.Credential) entity.Passkey {
+	transports := make([]string, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+	return entity.Passkey{
+		ID:              credential.ID,
+		UserID:          userID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		AAGUID:          credential.Authenticator.AAGUID,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
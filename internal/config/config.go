@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-yaml/yaml"
@@ -17,6 +18,32 @@ const (
 	ProdEnv AppEnv = "prod"
 )
 
+type RevocationStoreType string
+
+const (
+	PostgresRevocationStore RevocationStoreType = "postgres"
+	// MemoryRevocationStore is not shared between replicas, use it for tests and single instance setups only.
+	MemoryRevocationStore RevocationStoreType = "memory"
+)
+
+type MailerType string
+
+const (
+	SMTPMailer MailerType = "smtp"
+	// FileMailer writes emails to files instead of sending them, use it for development.
+	FileMailer MailerType = "file"
+	// MemoryMailer drops emails, use it for tests.
+	MemoryMailer MailerType = "memory"
+)
+
+type PasswordHashingAlgorithm string
+
+const (
+	Argon2idHashing PasswordHashingAlgorithm = "argon2id"
+	// BcryptHashing limits passwords to MaxBcryptPasswordLength bytes.
+	BcryptHashing PasswordHashingAlgorithm = "bcrypt"
+)
+
 const (
 	DBEnvKey                 = "DB_URL"
 	AccessTokenSecretEnvKey  = "ACCESS_TOKEN_SECRET"
@@ -27,10 +54,458 @@ const (
 	DefaultGRPCPort = "50051"
 	DefaultRestPort = "8080"
 
+	DefaultSigningKeyID        = "default"
+	DefaultSigningKeyAlgorithm = "HS256"
+
 	DefaultAccessTokenExpirationDuration  = 30 * time.Minute
 	DefaultRefreshTokenExpirationDuration = 24 * time.Hour
+
+	DefaultAuthorizationCodeExpirationDuration = time.Minute
+
+	DefaultMFAIssuer                      = "task_manager"
+	DefaultMFAChallengeExpirationDuration = 5 * time.Minute
+
+	DefaultWebAuthnRPID                      = "localhost"
+	DefaultWebAuthnRPDisplayName             = "task_manager"
+	DefaultWebAuthnSessionExpirationDuration = 5 * time.Minute
+
+	DefaultEmailVerificationTokenExpirationDuration = 24 * time.Hour
+	DefaultEmailVerificationResendInterval          = time.Minute
+	DefaultSMTPPort                                 = 587
+
+	DefaultPasswordResetTokenExpirationDuration = time.Hour
+	DefaultPasswordResetRequestInterval         = time.Minute
+
+	DefaultPasswordMinLength = 8
+	DefaultPasswordMaxLength = 72
+	// MaxPasswordLength in bytes keeps hashing of huge passwords from exhausting the service.
+	MaxPasswordLength = 1024
+	// MaxBcryptPasswordLength is the bcrypt limit in bytes.
+	MaxBcryptPasswordLength = 72
+
+	DefaultLoginBaseDelay          = time.Second
+	DefaultLoginMaxDelay           = 30 * time.Second
+	DefaultLoginMaxAccountFailures = 10
+	DefaultLoginMaxIPFailures      = 100
+	DefaultLoginLockoutDuration    = 15 * time.Minute
+	DefaultLoginFailureWindow      = time.Hour
+
+	DefaultBcryptCost = 10
+	// argon2id defaults follow OWASP Password Storage Cheat Sheet.
+	DefaultArgon2idMemory      = 19 * 1024
+	DefaultArgon2idIterations  = 2
+	DefaultArgon2idParallelism = 1
+
+	DefaultTokenLeeway = 30 * time.Second
 )
 
+// EmailExtraClaim puts email of the user to access tokens.
+const EmailExtraClaim = "email"
+
+// SigningKeyConfig is a token signing key, ID is put to the "kid" token header.
+// HS256 keys take the secret from SecretEnv env variable,
+// asymmetric algorithms (RS256, ES256, EdDSA) use PEM encoded private key from PrivateKeyPath.
+type SigningKeyConfig struct {
+	ID             string `yaml:"id"`
+	Algorithm      string `yaml:"algorithm"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	SecretEnv      string `yaml:"secret_env"`
+}
+
+// TokenSigningConfig is a keyring: the active key signs new tokens,
+// other keys only verify tokens issued before rotation.
+type TokenSigningConfig struct {
+	ActiveKeyID string             `yaml:"active_key_id"`
+	Keys        []SigningKeyConfig `yaml:"keys"`
+}
+
+// setDefaults falls back to a single HS256 key with the secret from defaultSecretEnv.
+func (c *TokenSigningConfig) setDefaults(defaultSecretEnv string) error {
+	if len(c.Keys) == 0 {
+		c.Keys = []SigningKeyConfig{{
+			ID:        DefaultSigningKeyID,
+			Algorithm: DefaultSigningKeyAlgorithm,
+			SecretEnv: defaultSecretEnv,
+		}}
+	}
+	if c.ActiveKeyID == "" {
+		c.ActiveKeyID = c.Keys[0].ID
+	}
+
+	activeFound := false
+	for i := range c.Keys {
+		key := &c.Keys[i]
+		if key.ID == "" {
+			return errors.New("empty signing key id")
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = DefaultSigningKeyAlgorithm
+		}
+		if key.Algorithm == DefaultSigningKeyAlgorithm && key.SecretEnv == "" {
+			return fmt.Errorf("empty secret env for signing key '%s'", key.ID)
+		}
+		if key.Algorithm != DefaultSigningKeyAlgorithm && key.PrivateKeyPath == "" {
+			return fmt.Errorf("empty private key path for signing key '%s'", key.ID)
+		}
+		activeFound = activeFound || key.ID == c.ActiveKeyID
+	}
+	if !activeFound {
+		return fmt.Errorf("unknown active signing key '%s'", c.ActiveKeyID)
+	}
+
+	return nil
+}
+
+// TokenClaimsConfig configures standard claims of issued tokens,
+// empty issuer and audience are neither issued nor enforced on verification.
+type TokenClaimsConfig struct {
+	// Issuer is the public base URL of the service for OpenID Connect,
+	// the discovery document and endpoints are located under it.
+	// Cookies of the sign-in pages are Secure if it's https URL.
+	Issuer   string        `yaml:"issuer"`
+	Audience []string      `yaml:"audience"`
+	Leeway   time.Duration `yaml:"leeway"`
+	// ExtraClaims are added to access tokens, possible values: 'email'
+	ExtraClaims []string `yaml:"extra_claims"`
+}
+
+func (c *TokenClaimsConfig) setDefaults() error {
+	switch {
+	case c.Leeway == 0:
+		c.Leeway = DefaultTokenLeeway
+	case c.Leeway < 0:
+		return fmt.Errorf("negative leeway %s", c.Leeway)
+	}
+	for _, claim := range c.ExtraClaims {
+		if claim != EmailExtraClaim {
+			return fmt.Errorf("unknown extra claim '%s'", claim)
+		}
+	}
+	return nil
+}
+
+// MFAConfig configures the second factor of users.
+type MFAConfig struct {
+	// Issuer is shown by authenticator apps next to the account.
+	Issuer string `yaml:"issuer"`
+	// ChallengeExpirationDuration is how long the user may take to enter the code after the password.
+	ChallengeExpirationDuration time.Duration `yaml:"challenge_expiration_duration"`
+}
+
+func (c *MFAConfig) setDefaults() {
+	if c.Issuer == "" {
+		c.Issuer = DefaultMFAIssuer
+	}
+	if c.ChallengeExpirationDuration == 0 {
+		c.ChallengeExpirationDuration = DefaultMFAChallengeExpirationDuration
+	}
+}
+
+// WebAuthnConfig configures the relying party users register passkeys with.
+type WebAuthnConfig struct {
+	// RPID is the domain of the service without scheme and port, passkeys are bound to it.
+	RPID          string `yaml:"rp_id"`
+	RPDisplayName string `yaml:"rp_display_name"`
+	// RPOrigins are origins of pages allowed to use passkeys, such as 'https://example.com'.
+	RPOrigins []string `yaml:"rp_origins"`
+	// SessionExpirationDuration is how long the user may take to answer the authenticator prompt.
+	SessionExpirationDuration time.Duration `yaml:"session_expiration_duration"`
+}
+
+// setDefaults falls back to localhost relying party served on restPort.
+func (c *WebAuthnConfig) setDefaults(restPort string) {
+	if c.RPID == "" {
+		c.RPID = DefaultWebAuthnRPID
+	}
+	if c.RPDisplayName == "" {
+		c.RPDisplayName = DefaultWebAuthnRPDisplayName
+	}
+	if len(c.RPOrigins) == 0 && c.RPID == DefaultWebAuthnRPID {
+		c.RPOrigins = []string{"http://localhost:" + restPort}
+	}
+	if c.SessionExpirationDuration == 0 {
+		c.SessionExpirationDuration = DefaultWebAuthnSessionExpirationDuration
+	}
+}
+
+// EmailVerificationConfig configures verification of user emails.
+type EmailVerificationConfig struct {
+	// Required blocks sign-in in any way: password, passkey or identity provider, until the email is verified.
+	Required                bool          `yaml:"required"`
+	TokenExpirationDuration time.Duration `yaml:"token_expiration_duration"`
+	// ResendInterval is the minimal interval between verification emails to the user.
+	ResendInterval time.Duration `yaml:"resend_interval"`
+	// LinkURL is the page verifying the email, the token is added as 'token' query parameter.
+	// It defaults to the VerifyEmail endpoint under the token issuer.
+	LinkURL string `yaml:"link_url"`
+}
+
+func (c *EmailVerificationConfig) setDefaults(issuer string) {
+	if c.TokenExpirationDuration == 0 {
+		c.TokenExpirationDuration = DefaultEmailVerificationTokenExpirationDuration
+	}
+	if c.ResendInterval == 0 {
+		c.ResendInterval = DefaultEmailVerificationResendInterval
+	}
+	if c.LinkURL == "" && issuer != "" {
+		c.LinkURL = strings.TrimSuffix(issuer, "/") + "/v1/users/email:verify"
+	}
+}
+
+// PasswordResetConfig configures recovery of accounts with forgotten passwords.
+type PasswordResetConfig struct {
+	TokenExpirationDuration time.Duration `yaml:"token_expiration_duration"`
+	// RequestInterval is the minimal interval between reset emails to the user.
+	RequestInterval time.Duration `yaml:"request_interval"`
+	// LinkURL is the page of the frontend choosing a new password, the token is added as 'token' query parameter.
+	// The token itself is sent if it's empty.
+	LinkURL string `yaml:"link_url"`
+}
+
+func (c *PasswordResetConfig) setDefaults() {
+	if c.TokenExpirationDuration == 0 {
+		c.TokenExpirationDuration = DefaultPasswordResetTokenExpirationDuration
+	}
+	if c.RequestInterval == 0 {
+		c.RequestInterval = DefaultPasswordResetRequestInterval
+	}
+}
+
+// PasswordPolicyConfig configures rules new passwords must follow.
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"min_length"`
+	// MaxLength is in bytes, it's MaxBcryptPasswordLength at most if passwords are hashed with bcrypt.
+	MaxLength        int  `yaml:"max_length"`
+	RequireLowercase bool `yaml:"require_lowercase"`
+	RequireUppercase bool `yaml:"require_uppercase"`
+	RequireDigit     bool `yaml:"require_digit"`
+	RequireSymbol    bool `yaml:"require_symbol"`
+	// DisallowUserInfo rejects passwords containing the name or the email of the user.
+	DisallowUserInfo bool `yaml:"disallow_user_info"`
+	// BreachedPasswordsPath is a file or a directory of k-anonymity range files with SHA-1 hashes
+	// of breached passwords in Pwned Passwords format, breached passwords aren't checked if it's empty.
+	BreachedPasswordsPath string `yaml:"breached_passwords_path"`
+}
+
+func (c *PasswordPolicyConfig) setDefaults(algorithm PasswordHashingAlgorithm) error {
+	if c.MinLength == 0 {
+		c.MinLength = DefaultPasswordMinLength
+	}
+	if c.MaxLength == 0 {
+		c.MaxLength = DefaultPasswordMaxLength
+	}
+	if c.MinLength < 0 {
+		return fmt.Errorf("negative min length %d", c.MinLength)
+	}
+	maxLength := MaxPasswordLength
+	if algorithm == BcryptHashing {
+		maxLength = MaxBcryptPasswordLength
+	}
+	if c.MaxLength > maxLength {
+		return fmt.Errorf("max length %d is longer than %d bytes allowed with %s", c.MaxLength, maxLength, algorithm)
+	}
+	if c.MinLength > c.MaxLength {
+		return fmt.Errorf("min length %d is greater than max length %d", c.MinLength, c.MaxLength)
+	}
+	return nil
+}
+
+// LoginThrottlingConfig configures blocking of sign-in after failed attempts per account and client IP.
+type LoginThrottlingConfig struct {
+	// BaseDelay blocks sign-in after a failure, each next failure doubles it up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	MaxDelay  time.Duration `yaml:"max_delay"`
+	// MaxAccountFailures and MaxIPFailures in a row lock sign-in for LockoutDuration.
+	MaxAccountFailures int           `yaml:"max_account_failures"`
+	MaxIPFailures      int           `yaml:"max_ip_failures"`
+	LockoutDuration    time.Duration `yaml:"lockout_duration"`
+	// FailureWindow forgets failures once none happens within it.
+	FailureWindow time.Duration `yaml:"failure_window"`
+}
+
+func (c *LoginThrottlingConfig) setDefaults() error {
+	if c.BaseDelay == 0 {
+		c.BaseDelay = DefaultLoginBaseDelay
+	}
+	if c.MaxDelay == 0 {
+		c.MaxDelay = DefaultLoginMaxDelay
+	}
+	if c.MaxAccountFailures == 0 {
+		c.MaxAccountFailures = DefaultLoginMaxAccountFailures
+	}
+	if c.MaxIPFailures == 0 {
+		c.MaxIPFailures = DefaultLoginMaxIPFailures
+	}
+	if c.LockoutDuration == 0 {
+		c.LockoutDuration = DefaultLoginLockoutDuration
+	}
+	if c.FailureWindow == 0 {
+		c.FailureWindow = DefaultLoginFailureWindow
+	}
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay {
+		return fmt.Errorf("invalid delays: base %s, max %s", c.BaseDelay, c.MaxDelay)
+	}
+	if c.MaxAccountFailures < 0 || c.MaxIPFailures < 0 {
+		return fmt.Errorf("negative max failures: account %d, ip %d", c.MaxAccountFailures, c.MaxIPFailures)
+	}
+	if c.FailureWindow < c.LockoutDuration {
+		return fmt.Errorf("failure window %s is shorter than lockout duration %s", c.FailureWindow, c.LockoutDuration)
+	}
+	return nil
+}
+
+// RateLimitConfig is a token bucket: Rate requests per second on average with bursts of up to Burst requests.
+// The zero limit doesn't limit requests.
+type RateLimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+func (c RateLimitConfig) validate() error {
+	if c.Rate < 0 || c.Burst < 0 {
+		return fmt.Errorf("negative rate %g or burst %d", c.Rate, c.Burst)
+	}
+	if c.Rate > 0 && c.Burst == 0 {
+		return errors.New("zero burst rejects every request")
+	}
+	return nil
+}
+
+// RateLimitingConfig limits request rates of each client IP address before authentication
+// and of each user, service account or OAuth2 client after it, both with the same limits.
+type RateLimitingConfig struct {
+	// Default applies to methods missing in Methods.
+	Default RateLimitConfig `yaml:"default"`
+	// Methods maps full gRPC method names, such as '/users.UserService/ListUsers',
+	// and paths of OAuth2 HTTP endpoints, such as '/oauth2/token', to their limits.
+	Methods map[string]RateLimitConfig `yaml:"methods"`
+}
+
+func (c RateLimitingConfig) validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("invalid default limit: %w", err)
+	}
+	for method, limit := range c.Methods {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("invalid limit of method '%s': %w", method, err)
+		}
+	}
+	return nil
+}
+
+// BcryptConfig configures bcrypt hashing of passwords.
+type BcryptConfig struct {
+	Cost int `yaml:"cost"`
+}
+
+// Argon2idConfig configures argon2id hashing of passwords.
+type Argon2idConfig struct {
+	// Memory is in KiB.
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+}
+
+// PasswordHashingConfig configures hashing of new passwords. Hashes of other algorithms or parameters
+// are still verified and replaced with current ones on sign-in.
+type PasswordHashingConfig struct {
+	Algorithm PasswordHashingAlgorithm `yaml:"algorithm"`
+	Bcrypt    BcryptConfig             `yaml:"bcrypt"`
+	Argon2id  Argon2idConfig           `yaml:"argon2id"`
+}
+
+func (c *PasswordHashingConfig) setDefaults() error {
+	switch c.Algorithm {
+	case "":
+		c.Algorithm = Argon2idHashing
+	case Argon2idHashing, BcryptHashing:
+	default:
+		return fmt.Errorf("unknown algorithm '%s'", c.Algorithm)
+	}
+	if c.Bcrypt.Cost == 0 {
+		c.Bcrypt.Cost = DefaultBcryptCost
+	}
+	if c.Argon2id.Memory == 0 {
+		c.Argon2id.Memory = DefaultArgon2idMemory
+	}
+	if c.Argon2id.Iterations == 0 {
+		c.Argon2id.Iterations = DefaultArgon2idIterations
+	}
+	if c.Argon2id.Parallelism == 0 {
+		c.Argon2id.Parallelism = DefaultArgon2idParallelism
+	}
+	return nil
+}
+
+// SMTPConfig configures the SMTP server, the password is taken from PasswordEnv env variable.
+type SMTPConfig struct {
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	Username    string `yaml:"username"`
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// MailerConfig configures delivery of emails to users.
+type MailerConfig struct {
+	Type MailerType `yaml:"type"`
+	// From is the sender address of emails.
+	From string     `yaml:"from"`
+	SMTP SMTPConfig `yaml:"smtp"`
+	// Dir is the directory of the file mailer.
+	Dir string `yaml:"dir"`
+}
+
+func (c *MailerConfig) setDefaults() error {
+	switch c.Type {
+	case "":
+		c.Type = MemoryMailer
+	case SMTPMailer:
+		if c.SMTP.Host == "" {
+			return errors.New("empty smtp host")
+		}
+		if c.SMTP.Port == 0 {
+			c.SMTP.Port = DefaultSMTPPort
+		}
+	case FileMailer:
+		if c.Dir == "" {
+			return errors.New("empty mail directory")
+		}
+	case MemoryMailer:
+	default:
+		return fmt.Errorf("unknown mailer type '%s'", c.Type)
+	}
+	if c.Type != MemoryMailer && c.From == "" {
+		return errors.New("empty sender address")
+	}
+	return nil
+}
+
+// IdentityProviderConfig configures an upstream OpenID Connect provider users sign in with.
+// The redirect URL must point to the federated login callback of the service
+// and be registered at the provider.
+type IdentityProviderConfig struct {
+	Name            string   `yaml:"name"`
+	IssuerURL       string   `yaml:"issuer_url"`
+	ClientID        string   `yaml:"client_id"`
+	ClientSecretEnv string   `yaml:"client_secret_env"`
+	RedirectURL     string   `yaml:"redirect_url"`
+	Scopes          []string `yaml:"scopes"`
+}
+
+func (c IdentityProviderConfig) validate() error {
+	switch {
+	case c.Name == "":
+		return errors.New("empty name")
+	case c.IssuerURL == "":
+		return errors.New("empty issuer url")
+	case c.ClientID == "":
+		return errors.New("empty client id")
+	case c.RedirectURL == "":
+		return errors.New("empty redirect url")
+	}
+	return nil
+}
+
 type Config struct {
 	AppEnv                         AppEnv        `yaml:"app_env"`
 	DBUrl                          string        `yaml:"db_url"`
@@ -38,6 +513,31 @@ type Config struct {
 	GRPCPort                       string        `yaml:"grpc_port"`
 	AccessTokenExpirationDuration  time.Duration `yaml:"access_token_expiration_duration"`
 	RefreshTokenExpirationDuration time.Duration `yaml:"refresh_token_expiration_duration"`
+	// AuthorizationCodeExpirationDuration is the lifetime of OAuth2 authorization codes.
+	AuthorizationCodeExpirationDuration time.Duration            `yaml:"authorization_code_expiration_duration"`
+	MigrateOnStartup                    bool                     `yaml:"migrate_on_startup"`
+	RevocationStore                     RevocationStoreType      `yaml:"revocation_store"`
+	AccessTokenSigning                  TokenSigningConfig       `yaml:"access_token_signing"`
+	RefreshTokenSigning                 TokenSigningConfig       `yaml:"refresh_token_signing"`
+	TokenClaims                         TokenClaimsConfig        `yaml:"token_claims"`
+	IdentityProviders                   []IdentityProviderConfig `yaml:"identity_providers"`
+	MFA                                 MFAConfig                `yaml:"mfa"`
+	WebAuthn                            WebAuthnConfig           `yaml:"webauthn"`
+	EmailVerification                   EmailVerificationConfig  `yaml:"email_verification"`
+	PasswordReset                       PasswordResetConfig      `yaml:"password_reset"`
+	PasswordPolicy                      PasswordPolicyConfig     `yaml:"password_policy"`
+	PasswordHashing                     PasswordHashingConfig    `yaml:"password_hashing"`
+	LoginThrottling                     LoginThrottlingConfig    `yaml:"login_throttling"`
+	RateLimiting                        RateLimitingConfig       `yaml:"rate_limiting"`
+	Mailer                              MailerConfig             `yaml:"mailer"`
+
+	// path of the config file, used to reload the config
+	path string
+}
+
+// Reload reads the config again from the same file.
+func (c Config) Reload() (Config, error) {
+	return InitConfig(c.path)
 }
 
 func InitConfig(path string) (Config, error) {
@@ -46,7 +546,7 @@ func InitConfig(path string) (Config, error) {
 		return Config{}, fmt.Errorf("unable to read path '%s': %w", path, err)
 	}
 
-	var config Config
+	config := Config{path: path}
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return Config{}, fmt.Errorf("unable to unmarshal yaml config: %w", err)
 	}
@@ -73,6 +573,57 @@ func InitConfig(path string) (Config, error) {
 	if config.RefreshTokenExpirationDuration == 0 {
 		config.RefreshTokenExpirationDuration = DefaultRefreshTokenExpirationDuration
 	}
+	if config.AuthorizationCodeExpirationDuration == 0 {
+		config.AuthorizationCodeExpirationDuration = DefaultAuthorizationCodeExpirationDuration
+	}
+	switch config.RevocationStore {
+	case "":
+		config.RevocationStore = PostgresRevocationStore
+	case PostgresRevocationStore, MemoryRevocationStore:
+	default:
+		return Config{}, fmt.Errorf("unknown revocation store '%s'", config.RevocationStore)
+	}
+	if err := config.AccessTokenSigning.setDefaults(AccessTokenSecretEnvKey); err != nil {
+		return Config{}, fmt.Errorf("invalid access token signing: %w", err)
+	}
+	if err := config.RefreshTokenSigning.setDefaults(RefreshTokenSecretEnvKey); err != nil {
+		return Config{}, fmt.Errorf("invalid refresh token signing: %w", err)
+	}
+	if err := config.TokenClaims.setDefaults(); err != nil {
+		return Config{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+	config.MFA.setDefaults()
+	config.WebAuthn.setDefaults(config.RestPort)
+	if len(config.WebAuthn.RPOrigins) == 0 {
+		return Config{}, fmt.Errorf("empty webauthn origins of relying party '%s'", config.WebAuthn.RPID)
+	}
+	config.EmailVerification.setDefaults(config.TokenClaims.Issuer)
+	config.PasswordReset.setDefaults()
+	if err := config.PasswordHashing.setDefaults(); err != nil {
+		return Config{}, fmt.Errorf("invalid password hashing: %w", err)
+	}
+	if err := config.PasswordPolicy.setDefaults(config.PasswordHashing.Algorithm); err != nil {
+		return Config{}, fmt.Errorf("invalid password policy: %w", err)
+	}
+	if err := config.LoginThrottling.setDefaults(); err != nil {
+		return Config{}, fmt.Errorf("invalid login throttling: %w", err)
+	}
+	if err := config.RateLimiting.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid rate limiting: %w", err)
+	}
+	if err := config.Mailer.setDefaults(); err != nil {
+		return Config{}, fmt.Errorf("invalid mailer: %w", err)
+	}
+	providerNames := make(map[string]struct{}, len(config.IdentityProviders))
+	for i, provider := range config.IdentityProviders {
+		if err := provider.validate(); err != nil {
+			return Config{}, fmt.Errorf("invalid identity provider #%d: %w", i, err)
+		}
+		if _, ok := providerNames[provider.Name]; ok {
+			return Config{}, fmt.Errorf("duplicate identity provider '%s'", provider.Name)
+		}
+		providerNames[provider.Name] = struct{}{}
+	}
 
 	return config, nil
 }
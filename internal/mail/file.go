@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// fileMailer writes emails to .eml files in the directory instead of sending them, use it for development.
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (fileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fileMailer{}, fmt.Errorf("unable to create mail directory '%s': %w", dir, err)
+	}
+	return fileMailer{dir: dir, from: from}, nil
+}
+
+func (m fileMailer) Send(_ context.Context, email entity.Email) error {
+	now := time.Now()
+	msg, err := formatMessage(m.from, email, now)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(m.dir, now.UTC().Format("20060102T150405.000000000")+".eml")
+	if err := os.WriteFile(path, msg, 0o600); err != nil {
+		return fmt.Errorf("unable to write email to '%s': %w", path, err)
+	}
+	return nil
+}
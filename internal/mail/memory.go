@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+// memoryMailer keeps sent emails in memory, use it for tests and local runs without mail delivery.
+type memoryMailer struct {
+	mu     sync.Mutex
+	emails []entity.Email
+}
+
+func NewMemoryMailer() *memoryMailer {
+	return &memoryMailer{}
+}
+
+func (m *memoryMailer) Send(_ context.Context, email entity.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+// Emails returns emails sent so far.
+func (m *memoryMailer) Emails() []entity.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]entity.Email(nil), m.emails...)
+}
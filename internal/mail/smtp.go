@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/ziyadovea/task_manager/users/internal/app/entity"
+)
+
+const smtpDialTimeout = 10 * time.Second
+
+// SMTPConfig configures the SMTP server emails are sent through.
+// Username and password are optional, they are sent over TLS only.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// smtpMailer sends emails through the SMTP server, STARTTLS is used when the server supports it.
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPMailer(cfg SMTPConfig) smtpMailer {
+	return smtpMailer{cfg: cfg}
+}
+
+func (m smtpMailer) Send(ctx context.Context, email entity.Email) error {
+	msg, err := formatMessage(m.cfg.From, email, time.Now())
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("unable to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("unable to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("unable to start tls: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		// PlainAuth refuses to send credentials without TLS, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("unable to authenticate to smtp server: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.cfg.From); err != nil {
+		return fmt.Errorf("unable to set sender: %w", err)
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return fmt.Errorf("unable to set recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("unable to start message data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("unable to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("unable to send message: %w", err)
+	}
+
+	return client.Quit()
+}
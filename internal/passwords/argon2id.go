@@ -0,0 +1,107 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idID     = "argon2id"
+	argon2idPrefix = "$argon2id$"
+
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idParams are cost parameters of argon2id, RFC 9106.
+type Argon2idParams struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2idScheme encodes hashes in PHC string format: '$argon2id$v=19$m=19456,t=2,p=1$SALT$KEY'
+// with unpadded base64 salt and key.
+type argon2idScheme struct {
+	params Argon2idParams
+}
+
+type argon2idHash struct {
+	params Argon2idParams
+	salt   []byte
+	key    []byte
+}
+
+func (s argon2idScheme) hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("unable to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, s.params.Iterations, s.params.Memory, s.params.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		s.params.Memory,
+		s.params.Iterations,
+		s.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (s argon2idScheme) compare(encoded, password string) error {
+	h, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(password), h.salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, uint32(len(h.key)))
+	if subtle.ConstantTimeCompare(key, h.key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (s argon2idScheme) outdated(encoded string) bool {
+	h, err := decodeArgon2id(encoded)
+	return err != nil || h.params != s.params || len(h.salt) != argon2idSaltLength || len(h.key) != argon2idKeyLength
+}
+
+func decodeArgon2id(encoded string) (argon2idHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != argon2idID {
+		return argon2idHash{}, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2idHash{}, fmt.Errorf("invalid argon2id hash version: %w", err)
+	}
+	if version != argon2.Version {
+		return argon2idHash{}, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var h argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.params.Memory, &h.params.Iterations, &h.params.Parallelism); err != nil {
+		return argon2idHash{}, fmt.Errorf("invalid argon2id hash parameters: %w", err)
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return argon2idHash{}, fmt.Errorf("invalid argon2id hash salt: %w", err)
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return argon2idHash{}, fmt.Errorf("invalid argon2id hash key: %w", err)
+	}
+	if len(h.key) == 0 {
+		return argon2idHash{}, errors.New("empty argon2id hash key")
+	}
+	return h, nil
+}
@@ -0,0 +1,44 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	bcryptID      = "bcrypt"
+	minBcryptCost = bcrypt.MinCost
+	maxBcryptCost = bcrypt.MaxCost
+)
+
+// bcryptScheme keeps the modular crypt format of bcrypt, such as '$2a$10$...',
+// so hashes stored before algorithms were configurable stay valid.
+type bcryptScheme struct {
+	cost int
+}
+
+func (s bcryptScheme) hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return "", fmt.Errorf("unable to generate bcrypt hash: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (s bcryptScheme) compare(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	if err != nil {
+		return fmt.Errorf("unable to compare bcrypt hash: %w", err)
+	}
+	return nil
+}
+
+func (s bcryptScheme) outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != s.cost
+}
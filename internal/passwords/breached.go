@@ -0,0 +1,97 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sha1HexLength = 2 * sha1.Size
+	// rangePrefixLength is the hash prefix length of k-anonymity range files.
+	rangePrefixLength = 5
+)
+
+// breachedList is a set of SHA-1 hashes of breached passwords, kept in memory.
+type breachedList struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// LoadBreachedList loads hashes of breached passwords in Pwned Passwords format:
+// path is either a file of 'SHA1[:COUNT]' lines or a directory of k-anonymity range files
+// named by the 5 hex characters hash prefix with 'SUFFIX[:COUNT]' lines.
+func LoadBreachedList(path string) (*breachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat breached passwords '%s': %w", path, err)
+	}
+
+	list := &breachedList{hashes: make(map[[sha1.Size]byte]struct{})}
+	if !info.IsDir() {
+		if err := list.loadFile(path, ""); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read breached passwords directory '%s': %w", path, err)
+	}
+	for _, entry := range entries {
+		prefix := strings.ToUpper(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if entry.IsDir() || len(prefix) != rangePrefixLength {
+			continue
+		}
+		if err := list.loadFile(filepath.Join(path, entry.Name()), prefix); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Contains reports whether the password is breached.
+func (l *breachedList) Contains(password string) bool {
+	_, ok := l.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
+
+// Len returns the number of loaded hashes.
+func (l *breachedList) Len() int {
+	return len(l.hashes)
+}
+
+// loadFile adds hashes from the file, the prefix completes hashes of range files.
+func (l *breachedList) loadFile(path, prefix string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open breached passwords '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if line == "" {
+			continue
+		}
+
+		var hash [sha1.Size]byte
+		hexHash := prefix + line
+		if len(hexHash) != sha1HexLength {
+			return fmt.Errorf("invalid hash at %s:%d", path, lineNumber)
+		}
+		if _, err := hex.Decode(hash[:], []byte(hexHash)); err != nil {
+			return fmt.Errorf("invalid hash at %s:%d: %w", path, lineNumber, err)
+		}
+		l.hashes[hash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read breached passwords '%s': %w", path, err)
+	}
+	return nil
+}
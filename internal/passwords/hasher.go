@@ -0,0 +1,76 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMismatch is returned when the password doesn't match the hash.
+var ErrMismatch = errors.New("password doesn't match the hash")
+
+// scheme is a password hashing algorithm with its parameters.
+type scheme interface {
+	// hash returns the encoded hash carrying the algorithm and parameters, so it's verified after they change.
+	hash(password string) (string, error)
+	compare(encoded, password string) error
+	// outdated reports whether the hash of the same algorithm is computed with other parameters.
+	outdated(encoded string) bool
+}
+
+// hasher hashes passwords with the current scheme and verifies hashes of any supported scheme.
+type hasher struct {
+	current   scheme
+	currentID string
+}
+
+// NewBcryptHasher returns the hasher using bcrypt with the given cost.
+func NewBcryptHasher(cost int) (hasher, error) {
+	if cost < minBcryptCost || cost > maxBcryptCost {
+		return hasher{}, fmt.Errorf("bcrypt cost %d is out of range [%d, %d]", cost, minBcryptCost, maxBcryptCost)
+	}
+	return hasher{current: bcryptScheme{cost: cost}, currentID: bcryptID}, nil
+}
+
+// NewArgon2idHasher returns the hasher using argon2id with the given parameters.
+func NewArgon2idHasher(params Argon2idParams) (hasher, error) {
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return hasher{}, errors.New("argon2id memory, iterations and parallelism must be positive")
+	}
+	return hasher{current: argon2idScheme{params: params}, currentID: argon2idID}, nil
+}
+
+// Hash returns the encoded hash of the password.
+func (h hasher) Hash(password string) (string, error) {
+	return h.current.hash(password)
+}
+
+// Compare returns ErrMismatch if the password doesn't match the encoded hash.
+func (h hasher) Compare(encoded, password string) error {
+	s, _, err := schemeOf(encoded)
+	if err != nil {
+		return err
+	}
+	return s.compare(encoded, password)
+}
+
+// NeedsRehash reports whether the hash is computed with another algorithm or parameters than the current ones.
+func (h hasher) NeedsRehash(encoded string) bool {
+	_, id, err := schemeOf(encoded)
+	if err != nil || id != h.currentID {
+		return true
+	}
+	return h.current.outdated(encoded)
+}
+
+// schemeOf detects the algorithm of the encoded hash, the returned scheme only compares passwords.
+func schemeOf(encoded string) (scheme, string, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return argon2idScheme{}, argon2idID, nil
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return bcryptScheme{}, bcryptID, nil
+	default:
+		return nil, "", errors.New("unknown password hash algorithm")
+	}
+}
@@ -0,0 +1,189 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.20.1
+// source: proto/v1/mfa_service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MFAService_EnrollTOTP_FullMethodName  = "/users.MFAService/EnrollTOTP"
+	MFAService_ConfirmTOTP_FullMethodName = "/users.MFAService/ConfirmTOTP"
+	MFAService_ResetMFA_FullMethodName    = "/users.MFAService/ResetMFA"
+)
+
+// MFAServiceClient is the client API for MFAService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MFAServiceClient interface {
+	// EnrollTOTP generates a TOTP secret of the caller, it's enforced once confirmed.
+	EnrollTOTP(ctx context.Context, in *EnrollTOTPRequest, opts ...grpc.CallOption) (*EnrollTOTPResponse, error)
+	// ConfirmTOTP enables TOTP with a first code and returns recovery codes.
+	ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*ConfirmTOTPResponse, error)
+	// ResetMFA removes the second factor of the user who lost it, admin only.
+	ResetMFA(ctx context.Context, in *ResetMFARequest, opts ...grpc.CallOption) (*ResetMFAResponse, error)
+}
+
+type mFAServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMFAServiceClient(cc grpc.ClientConnInterface) MFAServiceClient {
+	return &mFAServiceClient{cc}
+}
+
+func (c *mFAServiceClient) EnrollTOTP(ctx context.Context, in *EnrollTOTPRequest, opts ...grpc.CallOption) (*EnrollTOTPResponse, error) {
+	out := new(EnrollTOTPResponse)
+	err := c.cc.Invoke(ctx, MFAService_EnrollTOTP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*ConfirmTOTPResponse, error) {
+	out := new(ConfirmTOTPResponse)
+	err := c.cc.Invoke(ctx, MFAService_ConfirmTOTP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) ResetMFA(ctx context.Context, in *ResetMFARequest, opts ...grpc.CallOption) (*ResetMFAResponse, error) {
+	out := new(ResetMFAResponse)
+	err := c.cc.Invoke(ctx, MFAService_ResetMFA_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MFAServiceServer is the server API for MFAService service.
+// All implementations must embed UnimplementedMFAServiceServer
+// for forward compatibility
+type MFAServiceServer interface {
+	// EnrollTOTP generates a TOTP secret of the caller, it's enforced once confirmed.
+	EnrollTOTP(context.Context, *EnrollTOTPRequest) (*EnrollTOTPResponse, error)
+	// ConfirmTOTP enables TOTP with a first code and returns recovery codes.
+	ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error)
+	// ResetMFA removes the second factor of the user who lost it, admin only.
+	ResetMFA(context.Context, *ResetMFARequest) (*ResetMFAResponse, error)
+	mustEmbedUnimplementedMFAServiceServer()
+}
+
+// UnimplementedMFAServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMFAServiceServer struct {
+}
+
+func (UnimplementedMFAServiceServer) EnrollTOTP(context.Context, *EnrollTOTPRequest) (*EnrollTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTOTP not implemented")
+}
+func (UnimplementedMFAServiceServer) ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedMFAServiceServer) ResetMFA(context.Context, *ResetMFARequest) (*ResetMFAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetMFA not implemented")
+}
+func (UnimplementedMFAServiceServer) mustEmbedUnimplementedMFAServiceServer() {}
+
+// UnsafeMFAServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MFAServiceServer will
+// result in compilation errors.
+type UnsafeMFAServiceServer interface {
+	mustEmbedUnimplementedMFAServiceServer()
+}
+
+func RegisterMFAServiceServer(s grpc.ServiceRegistrar, srv MFAServiceServer) {
+	s.RegisterService(&MFAService_ServiceDesc, srv)
+}
+
+func _MFAService_EnrollTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).EnrollTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_EnrollTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).EnrollTOTP(ctx, req.(*EnrollTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_ConfirmTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).ConfirmTOTP(ctx, req.(*ConfirmTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_ResetMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetMFARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).ResetMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_ResetMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).ResetMFA(ctx, req.(*ResetMFARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MFAService_ServiceDesc is the grpc.ServiceDesc for MFAService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MFAService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.MFAService",
+	HandlerType: (*MFAServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EnrollTOTP",
+			Handler:    _MFAService_EnrollTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _MFAService_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "ResetMFA",
+			Handler:    _MFAService_ResetMFA_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/v1/mfa_service.proto",
+}
@@ -0,0 +1,419 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.20.1
+// source: proto/v1/service_account_service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ServiceAccountService_CreateServiceAccount_FullMethodName       = "/users.ServiceAccountService/CreateServiceAccount"
+	ServiceAccountService_GetServiceAccount_FullMethodName          = "/users.ServiceAccountService/GetServiceAccount"
+	ServiceAccountService_ListServiceAccounts_FullMethodName        = "/users.ServiceAccountService/ListServiceAccounts"
+	ServiceAccountService_UpdateServiceAccount_FullMethodName       = "/users.ServiceAccountService/UpdateServiceAccount"
+	ServiceAccountService_RotateServiceAccountSecret_FullMethodName = "/users.ServiceAccountService/RotateServiceAccountSecret"
+	ServiceAccountService_RemoveServiceAccount_FullMethodName       = "/users.ServiceAccountService/RemoveServiceAccount"
+	ServiceAccountService_GrantServiceAccountRole_FullMethodName    = "/users.ServiceAccountService/GrantServiceAccountRole"
+	ServiceAccountService_RevokeServiceAccountRole_FullMethodName   = "/users.ServiceAccountService/RevokeServiceAccountRole"
+	ServiceAccountService_IssueServiceAccountToken_FullMethodName   = "/users.ServiceAccountService/IssueServiceAccountToken"
+)
+
+// ServiceAccountServiceClient is the client API for ServiceAccountService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ServiceAccountServiceClient interface {
+	// CreateServiceAccount creates an account owned by the caller, client_secret is shown only once.
+	CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error)
+	GetServiceAccount(ctx context.Context, in *GetServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error)
+	// UpdateServiceAccount sets the description and the public key, an empty key removes it.
+	UpdateServiceAccount(ctx context.Context, in *UpdateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error)
+	// RotateServiceAccountSecret generates a new secret, the old one stops working immediately.
+	RotateServiceAccountSecret(ctx context.Context, in *RotateServiceAccountSecretRequest, opts ...grpc.CallOption) (*RotateServiceAccountSecretResponse, error)
+	// RemoveServiceAccount removes the account, its tokens are rejected right away.
+	RemoveServiceAccount(ctx context.Context, in *RemoveServiceAccountRequest, opts ...grpc.CallOption) (*RemoveServiceAccountResponse, error)
+	// GrantServiceAccountRole grants the role to the account, it applies to issued tokens right away.
+	GrantServiceAccountRole(ctx context.Context, in *GrantServiceAccountRoleRequest, opts ...grpc.CallOption) (*GrantServiceAccountRoleResponse, error)
+	RevokeServiceAccountRole(ctx context.Context, in *RevokeServiceAccountRoleRequest, opts ...grpc.CallOption) (*RevokeServiceAccountRoleResponse, error)
+	// IssueServiceAccountToken issues an access token to the account,
+	// which authenticates either with client_secret or with assertion signed by its private key.
+	IssueServiceAccountToken(ctx context.Context, in *IssueServiceAccountTokenRequest, opts ...grpc.CallOption) (*IssueServiceAccountTokenResponse, error)
+}
+
+type serviceAccountServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewServiceAccountServiceClient(cc grpc.ClientConnInterface) ServiceAccountServiceClient {
+	return &serviceAccountServiceClient{cc}
+}
+
+func (c *serviceAccountServiceClient) CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error) {
+	out := new(CreateServiceAccountResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_CreateServiceAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) GetServiceAccount(ctx context.Context, in *GetServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error) {
+	out := new(ServiceAccount)
+	err := c.cc.Invoke(ctx, ServiceAccountService_GetServiceAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error) {
+	out := new(ListServiceAccountsResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_ListServiceAccounts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) UpdateServiceAccount(ctx context.Context, in *UpdateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error) {
+	out := new(ServiceAccount)
+	err := c.cc.Invoke(ctx, ServiceAccountService_UpdateServiceAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) RotateServiceAccountSecret(ctx context.Context, in *RotateServiceAccountSecretRequest, opts ...grpc.CallOption) (*RotateServiceAccountSecretResponse, error) {
+	out := new(RotateServiceAccountSecretResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_RotateServiceAccountSecret_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) RemoveServiceAccount(ctx context.Context, in *RemoveServiceAccountRequest, opts ...grpc.CallOption) (*RemoveServiceAccountResponse, error) {
+	out := new(RemoveServiceAccountResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_RemoveServiceAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) GrantServiceAccountRole(ctx context.Context, in *GrantServiceAccountRoleRequest, opts ...grpc.CallOption) (*GrantServiceAccountRoleResponse, error) {
+	out := new(GrantServiceAccountRoleResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_GrantServiceAccountRole_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) RevokeServiceAccountRole(ctx context.Context, in *RevokeServiceAccountRoleRequest, opts ...grpc.CallOption) (*RevokeServiceAccountRoleResponse, error) {
+	out := new(RevokeServiceAccountRoleResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_RevokeServiceAccountRole_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAccountServiceClient) IssueServiceAccountToken(ctx context.Context, in *IssueServiceAccountTokenRequest, opts ...grpc.CallOption) (*IssueServiceAccountTokenResponse, error) {
+	out := new(IssueServiceAccountTokenResponse)
+	err := c.cc.Invoke(ctx, ServiceAccountService_IssueServiceAccountToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ServiceAccountServiceServer is the server API for ServiceAccountService service.
+// All implementations must embed UnimplementedServiceAccountServiceServer
+// for forward compatibility
+type ServiceAccountServiceServer interface {
+	// CreateServiceAccount creates an account owned by the caller, client_secret is shown only once.
+	CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountResponse, error)
+	GetServiceAccount(context.Context, *GetServiceAccountRequest) (*ServiceAccount, error)
+	ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error)
+	// UpdateServiceAccount sets the description and the public key, an empty key removes it.
+	UpdateServiceAccount(context.Context, *UpdateServiceAccountRequest) (*ServiceAccount, error)
+	// RotateServiceAccountSecret generates a new secret, the old one stops working immediately.
+	RotateServiceAccountSecret(context.Context, *RotateServiceAccountSecretRequest) (*RotateServiceAccountSecretResponse, error)
+	// RemoveServiceAccount removes the account, its tokens are rejected right away.
+	RemoveServiceAccount(context.Context, *RemoveServiceAccountRequest) (*RemoveServiceAccountResponse, error)
+	// GrantServiceAccountRole grants the role to the account, it applies to issued tokens right away.
+	GrantServiceAccountRole(context.Context, *GrantServiceAccountRoleRequest) (*GrantServiceAccountRoleResponse, error)
+	RevokeServiceAccountRole(context.Context, *RevokeServiceAccountRoleRequest) (*RevokeServiceAccountRoleResponse, error)
+	// IssueServiceAccountToken issues an access token to the account,
+	// which authenticates either with client_secret or with assertion signed by its private key.
+	IssueServiceAccountToken(context.Context, *IssueServiceAccountTokenRequest) (*IssueServiceAccountTokenResponse, error)
+	mustEmbedUnimplementedServiceAccountServiceServer()
+}
+
+// UnimplementedServiceAccountServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedServiceAccountServiceServer struct {
+}
+
+func (UnimplementedServiceAccountServiceServer) CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateServiceAccount not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) GetServiceAccount(context.Context, *GetServiceAccountRequest) (*ServiceAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServiceAccount not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServiceAccounts not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) UpdateServiceAccount(context.Context, *UpdateServiceAccountRequest) (*ServiceAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateServiceAccount not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) RotateServiceAccountSecret(context.Context, *RotateServiceAccountSecretRequest) (*RotateServiceAccountSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateServiceAccountSecret not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) RemoveServiceAccount(context.Context, *RemoveServiceAccountRequest) (*RemoveServiceAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveServiceAccount not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) GrantServiceAccountRole(context.Context, *GrantServiceAccountRoleRequest) (*GrantServiceAccountRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantServiceAccountRole not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) RevokeServiceAccountRole(context.Context, *RevokeServiceAccountRoleRequest) (*RevokeServiceAccountRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeServiceAccountRole not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) IssueServiceAccountToken(context.Context, *IssueServiceAccountTokenRequest) (*IssueServiceAccountTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueServiceAccountToken not implemented")
+}
+func (UnimplementedServiceAccountServiceServer) mustEmbedUnimplementedServiceAccountServiceServer() {}
+
+// UnsafeServiceAccountServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ServiceAccountServiceServer will
+// result in compilation errors.
+type UnsafeServiceAccountServiceServer interface {
+	mustEmbedUnimplementedServiceAccountServiceServer()
+}
+
+func RegisterServiceAccountServiceServer(s grpc.ServiceRegistrar, srv ServiceAccountServiceServer) {
+	s.RegisterService(&ServiceAccountService_ServiceDesc, srv)
+}
+
+func _ServiceAccountService_CreateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).CreateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_CreateServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).CreateServiceAccount(ctx, req.(*CreateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_GetServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).GetServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_GetServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).GetServiceAccount(ctx, req.(*GetServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_ListServiceAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServiceAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).ListServiceAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_ListServiceAccounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).ListServiceAccounts(ctx, req.(*ListServiceAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_UpdateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).UpdateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_UpdateServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).UpdateServiceAccount(ctx, req.(*UpdateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_RotateServiceAccountSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateServiceAccountSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).RotateServiceAccountSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_RotateServiceAccountSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).RotateServiceAccountSecret(ctx, req.(*RotateServiceAccountSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_RemoveServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).RemoveServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_RemoveServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).RemoveServiceAccount(ctx, req.(*RemoveServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_GrantServiceAccountRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantServiceAccountRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).GrantServiceAccountRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_GrantServiceAccountRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).GrantServiceAccountRole(ctx, req.(*GrantServiceAccountRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_RevokeServiceAccountRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeServiceAccountRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).RevokeServiceAccountRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_RevokeServiceAccountRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).RevokeServiceAccountRole(ctx, req.(*RevokeServiceAccountRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAccountService_IssueServiceAccountToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueServiceAccountTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAccountServiceServer).IssueServiceAccountToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAccountService_IssueServiceAccountToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAccountServiceServer).IssueServiceAccountToken(ctx, req.(*IssueServiceAccountTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ServiceAccountService_ServiceDesc is the grpc.ServiceDesc for ServiceAccountService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ServiceAccountService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.ServiceAccountService",
+	HandlerType: (*ServiceAccountServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateServiceAccount",
+			Handler:    _ServiceAccountService_CreateServiceAccount_Handler,
+		},
+		{
+			MethodName: "GetServiceAccount",
+			Handler:    _ServiceAccountService_GetServiceAccount_Handler,
+		},
+		{
+			MethodName: "ListServiceAccounts",
+			Handler:    _ServiceAccountService_ListServiceAccounts_Handler,
+		},
+		{
+			MethodName: "UpdateServiceAccount",
+			Handler:    _ServiceAccountService_UpdateServiceAccount_Handler,
+		},
+		{
+			MethodName: "RotateServiceAccountSecret",
+			Handler:    _ServiceAccountService_RotateServiceAccountSecret_Handler,
+		},
+		{
+			MethodName: "RemoveServiceAccount",
+			Handler:    _ServiceAccountService_RemoveServiceAccount_Handler,
+		},
+		{
+			MethodName: "GrantServiceAccountRole",
+			Handler:    _ServiceAccountService_GrantServiceAccountRole_Handler,
+		},
+		{
+			MethodName: "RevokeServiceAccountRole",
+			Handler:    _ServiceAccountService_RevokeServiceAccountRole_Handler,
+		},
+		{
+			MethodName: "IssueServiceAccountToken",
+			Handler:    _ServiceAccountService_IssueServiceAccountToken_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/v1/service_account_service.proto",
+}